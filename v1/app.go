@@ -12,10 +12,22 @@ import (
 	"sync"
 	"time"
 
+	"github.com/tylertravisty/rum-goggles/v1/internal/activity"
+	"github.com/tylertravisty/rum-goggles/v1/internal/audience"
 	"github.com/tylertravisty/rum-goggles/v1/internal/chatbot"
+	"github.com/tylertravisty/rum-goggles/v1/internal/chatexport"
+	"github.com/tylertravisty/rum-goggles/v1/internal/chatlog"
 	"github.com/tylertravisty/rum-goggles/v1/internal/config"
+	"github.com/tylertravisty/rum-goggles/v1/internal/discord"
 	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	"github.com/tylertravisty/rum-goggles/v1/internal/goals"
+	"github.com/tylertravisty/rum-goggles/v1/internal/ledger"
 	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+	"github.com/tylertravisty/rum-goggles/v1/internal/obs"
+	"github.com/tylertravisty/rum-goggles/v1/internal/overlay"
+	"github.com/tylertravisty/rum-goggles/v1/internal/report"
+	"github.com/tylertravisty/rum-goggles/v1/internal/textfile"
+	"github.com/tylertravisty/rum-goggles/v1/internal/webhook"
 	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
@@ -27,9 +39,13 @@ const (
 	ChannelType = "Channel"
 )
 
+// activityFeedSize is how many events are kept in each page's activity feed.
+const activityFeedSize = 200
+
 type ApiState struct {
 	active   bool
 	activeMu sync.Mutex
+	offline  activity.Offline
 	resp     *rumblelivestreamlib.LivestreamResponse
 	respMu   sync.Mutex
 }
@@ -40,6 +56,9 @@ type Page struct {
 	apiSt        *ApiState
 	displaying   bool
 	displayingMu sync.Mutex
+	live         bool
+	liveMu       sync.Mutex
+	livestreamID string
 	name         string
 }
 
@@ -47,30 +66,80 @@ func (p *Page) staticLiveStreamUrl() string {
 	return fmt.Sprintf("https://rumble.com%s/live", p.name)
 }
 
+// setLive records whether the page is live and which livestream is current.
+// It reports whether the live state changed and, if the page stayed live, the previous livestream ID when it changed.
+func (p *Page) setLive(resp *rumblelivestreamlib.LivestreamResponse) (bool, string) {
+	live := len(resp.Livestreams) > 0
+	id := ""
+	if live {
+		id = resp.Livestreams[0].ID
+	}
+
+	p.liveMu.Lock()
+	defer p.liveMu.Unlock()
+
+	changed := p.live != live
+	prevID := ""
+	if !changed && live && p.livestreamID != id {
+		prevID = p.livestreamID
+	}
+	p.live = live
+	p.livestreamID = id
+	return changed, prevID
+}
+
+// liveStreamUrl returns the url of the page's current livestream,
+// falling back to the static live url if the page is not known to be live.
+func (p *Page) liveStreamUrl() string {
+	p.liveMu.Lock()
+	defer p.liveMu.Unlock()
+
+	if p.live && p.livestreamID != "" {
+		return livestreamUrl(rumblelivestreamlib.Livestream{ID: p.livestreamID})
+	}
+
+	return p.staticLiveStreamUrl()
+}
+
+func livestreamUrl(ls rumblelivestreamlib.Livestream) string {
+	return fmt.Sprintf("https://rumble.com/v%s", ls.ID)
+}
+
 // App struct
 type App struct {
+	activity     *activity.Feed
+	audience     *audience.Tracker
 	cancelProc   context.CancelFunc
 	chatbot      *chatbot.Chatbot
+	chatLog      *chatlog.Writer
 	clients      map[string]*rumblelivestreamlib.Client
 	clientsMu    sync.Mutex
+	discord      *discord.Notifier
 	displaying   string
 	displayingMu sync.Mutex
+	goals        *goals.Tracker
+	ledger       *ledger.Ledger
 	logError     *log.Logger
 	logFile      *os.File
 	logFileMu    sync.Mutex
 	logInfo      *log.Logger
+	obs          *obs.Client
+	overlay      *overlay.Server
 	pages        map[string]*Page
 	pagesMu      sync.Mutex
 	producers    *events.Producers
 	services     *models.Services
+	textFiles    *textfile.Writer
 	wails        context.Context
+	webhooks     *webhook.Dispatcher
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
 	app := &App{
-		clients: map[string]*rumblelivestreamlib.Client{},
-		pages:   map[string]*Page{},
+		activity: activity.NewFeed(activityFeedSize),
+		clients:  map[string]*rumblelivestreamlib.Client{},
+		pages:    map[string]*Page{},
 	}
 	err := app.log()
 	if err != nil {
@@ -127,16 +196,145 @@ func (a *App) processApi(event events.Api) {
 		event,
 		a.pageApiProcessor,
 		a.chatbotApiProcessor,
+		a.streamStatsApiProcessor,
+		a.ledgerApiProcessor,
+		a.audienceApiProcessor,
+		a.textFileApiProcessor,
 	)
 }
 
+func (a *App) textFileApiProcessor(event events.Api) {
+	if event.Stop || event.Resp == nil || a.textFiles == nil {
+		return
+	}
+
+	a.textFiles.HandleApi(event.Name, event.Resp)
+}
+
+// audienceApiProcessor merges the page's recent followers and subscribers into their history,
+// and triggers chatbot rules for resubs.
+func (a *App) audienceApiProcessor(event events.Api) {
+	if event.Stop || event.Resp == nil {
+		return
+	}
+
+	update, err := a.audience.Merge(event.Name, event.Resp, time.Now())
+	if err != nil {
+		a.logError.Println("error merging followers and subscribers:", err)
+		return
+	}
+	if update.Empty() {
+		return
+	}
+
+	for _, resub := range update.Resubs() {
+		sub := events.ApiSubscriber{}
+		if resub.Username != nil {
+			sub.Username = *resub.Username
+		}
+		if resub.AmountCents != nil {
+			sub.AmountCents = *resub.AmountCents
+		}
+		if resub.Subscriptions != nil {
+			sub.Subscriptions = *resub.Subscriptions
+		}
+		a.chatbot.HandleResub(event.Name, sub)
+	}
+
+	runtime.EventsEmit(a.wails, "AudienceUpdate-"+event.Name, update)
+}
+
+func (a *App) ledgerApiProcessor(event events.Api) {
+	if event.Stop || event.Resp == nil {
+		return
+	}
+
+	for _, ls := range event.Resp.Livestreams {
+		for _, rant := range ls.Chat.RecentRants {
+			createdOn, err := events.ParseTime(rant.CreatedOn)
+			if err != nil {
+				a.logError.Println("error parsing rant created on:", err)
+				continue
+			}
+
+			err = a.ledger.Record(ledger.Entry{
+				Livestream:  livestreamUrl(ls),
+				Username:    rant.Username,
+				AmountCents: rant.AmountCents,
+				Text:        rant.Text,
+				Time:        createdOn,
+			}, ledger.SourceApi)
+			if err != nil {
+				a.logError.Println("error recording rant from API:", err)
+			}
+		}
+	}
+}
+
+func (a *App) streamStatsApiProcessor(event events.Api) {
+	if event.Stop || event.Resp == nil {
+		return
+	}
+
+	now := time.Now()
+	for _, ls := range event.Resp.Livestreams {
+		livestream := livestreamUrl(ls)
+		stat := &models.StreamStat{
+			Livestream:  &livestream,
+			Time:        &now,
+			WatchingNow: &ls.WatchingNow,
+			Likes:       &ls.Likes,
+			Dislikes:    &ls.Dislikes,
+			Followers:   &event.Resp.Followers.NumFollowers,
+			Subscribers: &event.Resp.Subscribers.NumSubscribers,
+		}
+
+		_, err := a.services.StreamStatS.Create(stat)
+		if err != nil {
+			a.logError.Println("error creating stream stat:", err)
+		}
+	}
+}
+
+// streamStatsDownsampling lists how old stream stats are merged into coarser intervals.
+var streamStatsDownsampling = []struct {
+	age      time.Duration
+	interval time.Duration
+}{
+	{24 * time.Hour, time.Minute},
+	{30 * 24 * time.Hour, 10 * time.Minute},
+}
+
+// downsampleStreamStats periodically merges old stream stats until ctx is done.
+func (a *App) downsampleStreamStats(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		for _, ds := range streamStatsDownsampling {
+			err := a.services.StreamStatS.Downsample(now.Add(-ds.age), ds.interval)
+			if err != nil {
+				a.logError.Println("error downsampling stream stats:", err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (a *App) pageApiProcessor(event events.Api) {
 	if event.Name == "" {
 		a.logError.Println("page cannot process API: event name is empty")
 	}
 
+	// The page's fields are guarded by their own mutexes, so pagesMu is only held to look the page up.
+	// Holding it while the response fans out would block every other page behind slow sinks.
 	a.pagesMu.Lock()
-	defer a.pagesMu.Unlock()
 	page, exists := a.pages[event.Name]
 	if !exists {
 		page = &Page{
@@ -145,10 +343,15 @@ func (a *App) pageApiProcessor(event events.Api) {
 		}
 		a.pages[event.Name] = page
 	}
+	a.pagesMu.Unlock()
 
 	page.apiSt.activeMu.Lock()
+	activeChanged := page.apiSt.active == event.Stop
 	page.apiSt.active = !event.Stop
 	page.apiSt.activeMu.Unlock()
+	if activeChanged {
+		go a.emitChatbotsArmed(page.name)
+	}
 
 	if event.Stop {
 		runtime.EventsEmit(a.wails, "ApiActive-"+page.name, false)
@@ -163,1466 +366,3377 @@ func (a *App) pageApiProcessor(event events.Api) {
 	page.displayingMu.Unlock()
 
 	page.apiSt.respMu.Lock()
+	prevResp := page.apiSt.resp
 	page.apiSt.resp = event.Resp
 	page.apiSt.respMu.Unlock()
 
 	a.updatePage(page)
-}
 
-type chatProcessor func(event events.Chat)
+	if event.Resp != nil {
+		diff := a.pageActivity(page.name, prevResp, event.Resp, &page.apiSt.offline)
+		a.pageGoals(page.name, event.Resp, diff)
 
-func (a *App) runChatProcessors(event events.Chat, procs ...chatProcessor) {
-	for _, proc := range procs {
-		proc(event)
-	}
-}
+		changed, prevID := page.setLive(event.Resp)
+		live := len(event.Resp.Livestreams) > 0
+		if changed {
+			go a.pageLiveChanged(page.name, live)
+		}
+		if prevID != "" {
+			prevUrl := livestreamUrl(rumblelivestreamlib.Livestream{ID: prevID})
+			go a.pageLivestreamChanged(page.name, prevUrl)
+		}
 
-func (a *App) processChat(event events.Chat) {
-	if event.Stop {
-		runtime.EventsEmit(a.wails, "ChatStreamActive-"+event.Url, false)
-		return
+		if prevResp != nil && ((changed && !live) || prevID != "") {
+			for _, ls := range prevResp.Livestreams {
+				if ls.ID == prevID || prevID == "" {
+					go a.createSessionReport(page.name, page.staticLiveStreamUrl(), ls)
+					break
+				}
+			}
+		}
 	}
-
-	a.runChatProcessors(
-		event,
-		a.chatbotChatProcessor,
-	)
 }
 
-// TODO: implement this
-func (a *App) chatbotApiProcessor(event events.Api) {
-	a.chatbot.HandleApi(event)
-}
-
-func (a *App) chatbotChatProcessor(event events.Chat) {
-	if event.Message.Type == rumblelivestreamlib.ChatTypeInit {
-		return
+// pageActivity adds the events between the page's previous and latest API responses to its activity feed,
+// and returns them.
+func (a *App) pageActivity(name string, prev *rumblelivestreamlib.LivestreamResponse, next *rumblelivestreamlib.LivestreamResponse, offline *activity.Offline) []activity.Event {
+	diff := activity.Diff(name, prev, next, offline, time.Now())
+	if len(diff) == 0 {
+		return diff
 	}
 
-	a.chatbot.HandleChat(event)
-}
+	for _, e := range a.activity.Add(name, diff) {
+		runtime.EventsEmit(a.wails, "Activity-"+name, e)
 
-func (a *App) shutdown(ctx context.Context) {
-	err := a.producers.Shutdown()
-	if err != nil {
-		a.logError.Println("error closing event producers:", err)
+		switch e.Kind {
+		case activity.KindFollower:
+			err := a.overlay.Alert(overlay.KindFollow, e.Username, 0, "")
+			if err != nil {
+				a.logError.Println("error sending follow alert to overlay:", err)
+			}
+			a.publishWebhook(webhook.KindFollow, name, e)
+		case activity.KindStreamStarted:
+			a.publishWebhook(webhook.KindLiveStart, name, e)
+			ls := activityLivestream(next, e.Livestream)
+			a.chatbot.HandleActivity(e, ls)
+			if a.discord != nil {
+				a.discord.Live(name, ls)
+			}
+		case activity.KindStreamEnded:
+			a.publishWebhook(webhook.KindLiveEnd, name, e)
+			// The livestream may have been missing from the last few responses, so prev may not list it.
+			ls, _ := offline.Ended(e.Livestream)
+			a.chatbot.HandleActivity(e, ls)
+		case activity.KindCategoryChanged, activity.KindTitleChanged:
+			a.saveStreamChange(e)
+			a.chatbot.HandleActivity(e, activityLivestream(next, e.Livestream))
+		}
 	}
 
-	a.cancelProc()
+	return diff
+}
 
-	if a.services != nil {
-		err := a.services.Close()
-		if err != nil {
-			a.logError.Println("error closing services:", err)
+// activityLivestream returns the livestream with the url in the API response.
+func activityLivestream(resp *rumblelivestreamlib.LivestreamResponse, livestream string) rumblelivestreamlib.Livestream {
+	for _, ls := range resp.Livestreams {
+		if livestreamUrl(ls) == livestream {
+			return ls
 		}
 	}
 
-	a.logFileMu.Lock()
-	if a.logFile != nil {
-		err := a.logFile.Close()
-		if err != nil {
-			log.Println("error closing log file:", err)
-		}
-	}
-	a.logFileMu.Unlock()
+	return rumblelivestreamlib.Livestream{}
 }
 
-func (a *App) Start() (bool, error) {
-
-	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing database...")
-	err := a.initServices()
-	if err != nil {
-		a.logError.Println("error initializing services:", err)
-		return false, fmt.Errorf("Error starting Rum Goggles. Try restarting.")
+// saveStreamChange records a livestream's title or category change in its history.
+func (a *App) saveStreamChange(e activity.Event) {
+	kind := models.StreamChangeKindTitle
+	if e.Kind == activity.KindCategoryChanged {
+		kind = models.StreamChangeKindCategory
 	}
-	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing database complete.")
 
-	runtime.EventsEmit(a.wails, "StartupMessage", "Verifying account sessions...")
-	count, err := a.verifyAccounts()
+	_, err := a.services.StreamChangeS.Create(&models.StreamChange{
+		Livestream: &e.Livestream,
+		Page:       &e.Page,
+		Kind:       &kind,
+		Previous:   &e.Previous,
+		Value:      &e.Text,
+		Time:       &e.Time,
+	})
 	if err != nil {
-		a.logError.Println("error verifying accounts:", err)
-		return false, fmt.Errorf("Error starting Rum Goggles. Try restarting.")
+		a.logError.Println("error creating stream change:", err)
 	}
-	runtime.EventsEmit(a.wails, "StartupMessage", "Verifying account sessions complete.")
+}
 
-	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing event producers...")
-	err = a.initProducers()
-	if err != nil {
-		a.logError.Println("error initializing producers:", err)
-		return false, fmt.Errorf("Error starting Rum Goggles. Try restarting.")
+// pageGoals updates the page's goals with its latest API response and the activity since the previous one,
+// announcing any milestones they reached.
+func (a *App) pageGoals(name string, resp *rumblelivestreamlib.LivestreamResponse, diff []activity.Event) {
+	if a.goals == nil {
+		return
 	}
-	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing event producers complete.")
 
-	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing chat bot...")
-	err = a.initChatbot()
+	update, err := a.goals.Update(name, resp, diff, time.Now())
 	if err != nil {
-		a.logError.Println("error initializing chat bot:", err)
-		return false, fmt.Errorf("Error starting Rum Goggles. Try restarting.")
+		a.logError.Println("error updating goals:", err)
+		return
+	}
+	if !update.Changed {
+		return
 	}
-	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing chat bot complete.")
 
-	// TODO: check for update - if available, pop up window
-	// runtime.EventsEmit(a.ctx, "StartupMessage", "Checking for updates...")
-	// update, err = a.checkForUpdate()
-	// runtime.EventsEmit(a.ctx, "StartupMessage", "Checking for updates complete.")
+	a.emitGoals(name, update.Goals)
+	for _, milestone := range update.Milestones {
+		a.chatbot.HandleGoalMilestone(name, milestone)
+	}
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	a.cancelProc = cancel
-	go a.process(ctx)
+func (a *App) emitGoals(name string, progress []goals.Progress) {
+	runtime.EventsEmit(a.wails, "Goals-"+name, progress)
 
-	signin := true
-	if count > 0 {
-		signin = false
+	err := a.overlay.Goals(name, progress)
+	if err != nil {
+		a.logError.Println("error sending goals to overlay:", err)
 	}
-
-	return signin, nil
 }
 
-func (a *App) initChatbot() error {
-	cb := chatbot.New(a.services.AccountS, a.services.ChatbotS, a.logError, a.wails)
-	a.chatbot = cb
+// createSessionReport generates and saves the report for a livestream that just ended.
+func (a *App) createSessionReport(name string, staticUrl string, ls rumblelivestreamlib.Livestream) {
+	livestream := livestreamUrl(ls)
+	end := time.Now()
 
-	return nil
-}
+	stats, err := a.services.StreamStatS.Summary(livestream)
+	if err != nil {
+		a.logError.Println("error getting stream stats summary:", err)
+	}
 
-func (a *App) initProducers() error {
-	producers, err := events.NewProducers(
-		events.WithLoggers(a.logError, a.logInfo),
-		events.WithApiProducer(),
-		events.WithChatProducer(),
-	)
+	start, err := events.ParseTime(ls.CreatedOn)
 	if err != nil {
-		return fmt.Errorf("error initializing producers: %v", err)
+		a.logError.Println("error parsing livestream created on:", err)
+		if stats != nil {
+			start = stats.Start
+		}
 	}
 
-	err = producers.Startup()
+	// Chat may be stored under the page's static live url or the livestream url, depending on how it was opened.
+	a.chatLog.Flush()
+	messages, err := a.services.ChatMessageS.ByLivestreamsBetween([]string{livestream, staticUrl}, start, end)
 	if err != nil {
-		return fmt.Errorf("error starting producers: %v", err)
+		a.logError.Println("error getting chat messages for session report:", err)
+		return
 	}
 
-	a.producers = producers
+	changes, err := a.services.StreamChangeS.ByLivestream(livestream)
+	if err != nil {
+		a.logError.Println("error getting stream changes for session report:", err)
+	}
 
-	return nil
-}
+	r := report.Generate(report.Session{
+		Livestream: livestream,
+		Page:       name,
+		Title:      ls.Title,
+		Start:      start,
+		End:        end,
+	}, messages, changes, stats)
 
-func (a *App) initServices() error {
-	db, err := config.Database()
+	body, err := json.Marshal(r)
 	if err != nil {
-		return fmt.Errorf("error getting database config: %v", err)
+		a.logError.Println("error encoding session report:", err)
+		return
 	}
+	reportStr := string(body)
 
-	services, err := models.NewServices(
-		models.WithDatabase(db),
-		models.WithAccountService(),
-		models.WithChannelService(),
-		models.WithAccountChannelService(),
-		models.WithChatbotService(),
-		models.WithChatbotRuleService(),
-	)
-	if err != nil {
-		return fmt.Errorf("error initializing services: %v", err)
+	sr := &models.SessionReport{
+		Livestream: &livestream,
+		Page:       &name,
+		Title:      &ls.Title,
+		End:        &end,
+		Report:     &reportStr,
+	}
+	if !start.IsZero() {
+		sr.Start = &start
 	}
 
-	err = services.AutoMigrate()
+	id, err := a.services.SessionReportS.Create(sr)
 	if err != nil {
-		return fmt.Errorf("error auto-migrating services: %v", err)
+		a.logError.Println("error creating session report:", err)
+		return
 	}
+	sr.ID = &id
 
-	a.services = services
-
-	return nil
+	a.logInfo.Printf("page %s ended livestream %s: saved session report %d", name, livestream, id)
+	sr.Report = nil
+	runtime.EventsEmit(a.wails, "SessionReportCreated", sr)
 }
 
-func (a *App) verifyAccounts() (int, error) {
-	accounts, err := a.services.AccountS.All()
+// pageLiveChanged starts the chatbots linked to a page when it goes live
+// and stops them when it goes offline.
+func (a *App) pageLiveChanged(name string, live bool) {
+	chatbots, err := a.services.ChatbotS.ByPage(name)
 	if err != nil {
-		return -1, fmt.Errorf("error querying all accounts: %v", err)
+		a.logError.Println("error getting chatbots by page:", err)
+		return
 	}
 
-	a.clientsMu.Lock()
-	defer a.clientsMu.Unlock()
-	for _, account := range accounts {
-		if account.Cookies != nil {
-			var cookies []*http.Cookie
-			err = json.Unmarshal([]byte(*account.Cookies), &cookies)
-			if err != nil {
-				return -1, fmt.Errorf("error un-marshaling cookie string: %v", err)
-			}
-			client, err := rumblelivestreamlib.NewClient(rumblelivestreamlib.NewClientOptions{Cookies: cookies})
+	for _, cb := range chatbots {
+		if cb.ID == nil || cb.Name == nil {
+			continue
+		}
+
+		if live {
+			a.logInfo.Printf("page %s went live: starting chatbot %s on %s", name, *cb.Name, a.chatbotUrl(&cb))
+			err = a.RunChatbotRules(cb.ID)
 			if err != nil {
-				return -1, fmt.Errorf("error creating new client: %v", err)
-			}
-			if account.Username == nil {
-				return -1, fmt.Errorf("account username is nil")
+				a.logError.Printf("error starting chatbot %s after page %s went live: %v", *cb.Name, name, err)
 			}
-			loggedIn, err := client.LoggedIn()
+		} else {
+			a.logInfo.Printf("page %s went offline: stopping chatbot %s", name, *cb.Name)
+			err = a.StopChatbotRules(cb.ID)
 			if err != nil {
-				return -1, fmt.Errorf("error checking if account is logged in: %v", err)
-			}
-			if loggedIn.User.LoggedIn {
-				a.clients[*account.Username] = client
-			} else {
-				account.Cookies = nil
-				err = a.services.AccountS.Update(&account)
-				if err != nil {
-					return -1, fmt.Errorf("error updating account: %v", err)
-				}
+				a.logError.Printf("error stopping chatbot %s after page %s went offline: %v", *cb.Name, name, err)
 			}
 		}
-	}
 
-	return len(accounts), nil
+		runtime.EventsEmit(a.wails, fmt.Sprintf("ChatbotArmed-%d", *cb.ID), a.chatbotArmed(&cb))
+	}
 }
 
-func (a *App) AddPage(apiKey string) error {
-	client := rumblelivestreamlib.Client{ApiKey: apiKey}
-	resp, err := client.Request()
+func (a *App) emitChatbotsArmed(name string) {
+	chatbots, err := a.services.ChatbotS.ByPage(name)
 	if err != nil {
-		a.logError.Println("error executing api request:", err)
-		return fmt.Errorf("Error querying API. Verify key and try again.")
+		a.logError.Println("error getting chatbots by page:", err)
+		return
 	}
 
-	userKey := apiKey
-	channelKey := ""
-	if resp.Type == "channel" {
-		userKey = ""
-		channelKey = apiKey
+	for _, cb := range chatbots {
+		if cb.ID != nil {
+			runtime.EventsEmit(a.wails, fmt.Sprintf("ChatbotArmed-%d", *cb.ID), a.chatbotArmed(&cb))
+		}
 	}
+}
 
-	err = a.addAccountNotExist(resp.UserID, resp.Username, userKey)
+// pageLivestreamChanged re-points the running rules of chatbots linked to a page
+// when the page switches to a new livestream without going offline.
+func (a *App) pageLivestreamChanged(name string, prevUrl string) {
+	chatbots, err := a.services.ChatbotS.ByPage(name)
 	if err != nil {
-		a.logError.Println("error adding account if not exist:", err)
-		return fmt.Errorf("Error adding channel. Try again.")
+		a.logError.Println("error getting chatbots by page:", err)
+		return
 	}
 
-	if resp.Type == "channel" {
-		err = a.addChannelNotExist(resp.Username, fmt.Sprint(resp.ChannelID), resp.ChannelName, channelKey)
+	for _, cb := range chatbots {
+		if cb.ID == nil || cb.Name == nil {
+			continue
+		}
+
+		rules, err := a.chatbotRules(*cb.ID)
 		if err != nil {
-			a.logError.Println("error adding channel if not exist:", err)
-			return fmt.Errorf("Error adding channel. Try again.")
+			a.logError.Println("error getting chatbot rules:", err)
+			continue
 		}
-	}
 
-	list, err := a.accountList()
-	if err != nil {
-		a.logError.Println("error getting account list:", err)
-		return fmt.Errorf("Error logging in. Try again.")
+		a.logInfo.Printf("page %s changed livestream: moving chatbot %s from %s to %s", name, *cb.Name, prevUrl, a.chatbotUrl(&cb))
+		for _, rule := range rules {
+			if !rule.Running {
+				continue
+			}
+			err = a.RunChatbotRule(&rule)
+			if err != nil {
+				a.logError.Printf("error re-pointing chatbot rule %d to new livestream: %v", *rule.ID, err)
+			}
+		}
 	}
-	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
 
-	return nil
+	a.chatbot.RemoveLivestream(prevUrl)
+	if a.producers.ChatP.Active(prevUrl) {
+		err = a.producers.ChatP.Stop(prevUrl)
+		if err != nil {
+			a.logError.Println("error stopping chat producer for previous livestream:", err)
+		}
+	}
 }
 
-func (a *App) addAccountNotExist(uid string, username string, apiKey string) error {
-	acct, err := a.services.AccountS.ByUsername(username)
-	if err != nil {
-		return fmt.Errorf("error querying account by username: %v", err)
-	}
-	if acct == nil {
-		_, err = a.services.AccountS.Create(&models.Account{
-			UID:      &uid,
-			Username: &username,
-			ApiKey:   &apiKey,
-		})
-		if err != nil {
-			return fmt.Errorf("error creating account: %v", err)
+// chatbotUrl returns the livestream url the chatbot's rules should chat in.
+// Chatbots linked to a page follow the page's current livestream.
+func (a *App) chatbotUrl(cb *models.Chatbot) string {
+	if cb.Page != nil && *cb.Page != "" {
+		a.pagesMu.Lock()
+		page, exists := a.pages[*cb.Page]
+		if !exists {
+			page = &Page{
+				apiSt: &ApiState{},
+				name:  *cb.Page,
+			}
+			a.pages[*cb.Page] = page
 		}
+		a.pagesMu.Unlock()
+
+		return page.liveStreamUrl()
 	}
-	return nil
+
+	if cb.Url != nil {
+		return *cb.Url
+	}
+
+	return ""
 }
 
-func (a *App) addChannelNotExist(username string, cid string, name string, apiKey string) error {
-	channel, err := a.services.ChannelS.ByName(name)
-	if err != nil {
-		return fmt.Errorf("error querying channel by name: %v", err)
+// chatbotArmed reports whether the chatbot is waiting for its linked page to go live.
+func (a *App) chatbotArmed(cb *models.Chatbot) bool {
+	if cb == nil || cb.Page == nil || *cb.Page == "" {
+		return false
 	}
-	if channel == nil {
-		acct, err := a.services.AccountS.ByUsername(username)
-		if err != nil {
-			return fmt.Errorf("error querying account by username: %v", err)
-		}
-		if acct == nil {
-			return fmt.Errorf("account does not exist with username: %s", username)
-		}
 
-		err = a.services.ChannelS.Create(&models.Channel{
-			AccountID: acct.ID,
-			CID:       &cid,
-			Name:      &name,
-			ApiKey:    &apiKey,
-		})
-		if err != nil {
-			return fmt.Errorf("error creating channel: %v", err)
-		}
+	a.pagesMu.Lock()
+	page, exists := a.pages[*cb.Page]
+	a.pagesMu.Unlock()
+	if !exists {
+		return false
 	}
 
-	return nil
+	page.apiSt.activeMu.Lock()
+	active := page.apiSt.active
+	page.apiSt.activeMu.Unlock()
+
+	page.liveMu.Lock()
+	live := page.live
+	page.liveMu.Unlock()
+
+	return active && !live
 }
 
-func (a *App) Login(username string, password string) error {
-	var err error
-	a.clientsMu.Lock()
-	defer a.clientsMu.Unlock()
-	client, exists := a.clients[username]
-	if exists && client != nil {
-		err = client.Logout()
-		if err != nil {
-			a.logError.Println("error logging out:", err)
-			return fmt.Errorf("Error logging in. Try again.")
-		}
-	} else {
-		client, err = rumblelivestreamlib.NewClient(rumblelivestreamlib.NewClientOptions{})
+// armChatbot starts the API for the chatbot's linked page so live transitions can be detected.
+func (a *App) armChatbot(cb *models.Chatbot) error {
+	if cb == nil || cb.Page == nil || *cb.Page == "" {
+		return nil
 	}
 
-	cookies, err := client.Login(username, password)
+	pi, err := a.pageInfo(*cb.Page)
 	if err != nil {
-		a.logError.Println("error logging in:", err)
-		return fmt.Errorf("Error logging in. Try again.")
+		return fmt.Errorf("error getting page info: %v", err)
+	}
+	if pi == nil {
+		return fmt.Errorf("page does not exist: %s", *cb.Page)
 	}
-	a.clients[username] = client
 
-	cookiesB, err := json.Marshal(cookies)
+	err = a.startPageApi(pi)
 	if err != nil {
-		a.logError.Println("error marshaling cookies into json:", err)
-		return fmt.Errorf("Error logging in. Try again.")
+		return fmt.Errorf("error starting page api: %v", err)
 	}
-	cookiesS := string(cookiesB)
 
-	acct, err := a.services.AccountS.ByUsername(username)
+	return nil
+}
+
+func (a *App) armChatbots() error {
+	chatbots, err := a.services.ChatbotS.All()
 	if err != nil {
-		a.logError.Println("error getting account by username:", err)
-		return fmt.Errorf("Error logging in. Try again.")
+		return fmt.Errorf("error querying all chatbots: %v", err)
 	}
-	if acct == nil {
-		loggedIn, err := client.LoggedIn()
-		if err != nil {
-			a.logError.Println("error checking if account is logged in:", err)
-			return fmt.Errorf("Error logging in. Try again.")
-		}
-
-		uid, found := strings.CutPrefix(loggedIn.User.ID, "_u")
-		if !found {
-			a.logError.Println("did not find uid prefix '_u' in response after checking if accounts is logged in")
-			return fmt.Errorf("Error logging in. Try again.")
-		}
-		rumbleUsername := loggedIn.Data.Username
-		if rumbleUsername == "" {
-			a.logError.Println("username is empty in response after checking if accounts is logged in")
-			return fmt.Errorf("Error logging in. Try again.")
-		}
 
-		acct = &models.Account{nil, &uid, &rumbleUsername, &cookiesS, nil, nil}
-		id, err := a.services.AccountS.Create(acct)
+	for _, cb := range chatbots {
+		err = a.armChatbot(&cb)
 		if err != nil {
-			a.logError.Println("error creating account:", err)
-			return fmt.Errorf("Error logging in. Try again.")
-		}
-		acct.ID = &id
-	} else {
-		acct.Cookies = &cookiesS
-		err = a.services.AccountS.Update(acct)
-		if err != nil {
-			a.logError.Println("error updating account:", err)
-			return fmt.Errorf("Error logging in. Try again.")
+			a.logError.Println("error arming chatbot:", err)
 		}
 	}
 
-	name := acct.String()
-	if name == nil {
-		a.logError.Println("account name is nil")
-		return fmt.Errorf("Error logging in. Try again.")
-	}
-	runtime.EventsEmit(a.wails, "LoggedIn-"+*name, true)
+	return nil
+}
 
-	list, err := a.accountList()
+func (a *App) ChatbotArmed(chatbotID int64) (bool, error) {
+	cb, err := a.services.ChatbotS.ByID(chatbotID)
 	if err != nil {
-		a.logError.Println("error getting account list:", err)
-		return fmt.Errorf("Error logging in. Try again.")
+		a.logError.Println("error getting chatbot by ID:", err)
+		return false, fmt.Errorf("Error getting chatbot. Try again.")
 	}
-	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
-
-	err = a.openDetails(acct)
-	if err != nil {
-		a.logError.Println("error opening account details:", err)
-		return fmt.Errorf("Error logging in. Try again.")
+	if cb == nil {
+		return false, fmt.Errorf("Chatbot does not exist.")
 	}
 
-	return nil
+	return a.chatbotArmed(cb), nil
 }
 
-func (a *App) Logout(id int64) error {
-	acct, err := a.services.AccountS.ByID(id)
-	if err != nil {
-		a.logError.Println("error querying account by ID:", err)
-		return fmt.Errorf("Error logging out. Try again.")
-	}
-	if acct == nil {
-		return fmt.Errorf("Did not find account. Try again.")
+type chatProcessor func(event events.Chat)
+
+func (a *App) runChatProcessors(event events.Chat, procs ...chatProcessor) {
+	for _, proc := range procs {
+		proc(event)
 	}
+}
 
-	if acct.Username == nil {
-		a.logError.Println("account username is nil")
-		return fmt.Errorf("Error logging out. Try again.")
+func (a *App) processChat(event events.Chat) {
+	if event.Stop {
+		runtime.EventsEmit(a.wails, "ChatStreamActive-"+event.Url, false)
+		return
 	}
 
-	a.clientsMu.Lock()
-	defer a.clientsMu.Unlock()
-	client, exists := a.clients[*acct.Username]
-	if exists {
-		err = client.Logout()
-		if err != nil {
-			a.logError.Println("error logging out:", err)
-			return fmt.Errorf("Error logging out. Try again.")
-		}
-		delete(a.clients, *acct.Username)
+	a.runChatProcessors(
+		event,
+		a.chatbotChatProcessor,
+		a.chatLogChatProcessor,
+		a.ledgerChatProcessor,
+		a.overlayChatProcessor,
+		a.textFileChatProcessor,
+		a.webhookChatProcessor,
+		a.discordChatProcessor,
+	)
+}
+
+func (a *App) discordChatProcessor(event events.Chat) {
+	if a.discord == nil || event.Message.Type != rumblelivestreamlib.ChatTypeMessages {
+		return
 	}
 
-	if acct.Cookies != nil {
-		if !exists {
-			var cookies []*http.Cookie
-			err = json.Unmarshal([]byte(*acct.Cookies), &cookies)
-			if err != nil {
-				a.logError.Println("error un-marshaling cookie string:", err)
-				return fmt.Errorf("Error logging out. Try again.")
-			}
-			client, err = rumblelivestreamlib.NewClient(rumblelivestreamlib.NewClientOptions{Cookies: cookies})
-			err = client.Logout()
-			if err != nil {
-				a.logError.Println("error logging out:", err)
-				return fmt.Errorf("Error logging out. Try again.")
-			}
-		}
-		acct.Cookies = nil
-		err = a.services.AccountS.Update(acct)
-		if err != nil {
-			a.logError.Println("error updating account:", err)
-			return fmt.Errorf("Error logging out. Try again.")
-		}
+	livestream := a.resolveLivestreamUrl(event.Livestream)
+	a.discord.Chat(a.livestreamPage(livestream), livestream, event.Message)
+}
+
+// webhookChatProcessor sends chat messages to webhooks, along with rant, raid and sub events for the messages that are.
+func (a *App) webhookChatProcessor(event events.Chat) {
+	if event.Message.Type != rumblelivestreamlib.ChatTypeMessages {
+		return
 	}
 
-	name := acct.String()
-	if name == nil {
-		a.logError.Println("account name is nil")
-		return fmt.Errorf("Error logging out. Try again.")
+	livestream := a.resolveLivestreamUrl(event.Livestream)
+	page := a.livestreamPage(livestream)
+	chat := webhook.NewChat(livestream, event.Message)
+	a.publishWebhook(webhook.KindChatMessage, page, chat)
+	switch {
+	case event.Message.Rant > 0:
+		a.publishWebhook(webhook.KindRant, page, chat)
+	case event.Message.Raid:
+		a.publishWebhook(webhook.KindRaid, page, chat)
+	case event.Message.Sub:
+		a.publishWebhook(webhook.KindSub, page, chat)
 	}
-	runtime.EventsEmit(a.wails, "LoggedIn-"+*name, false)
+}
 
-	list, err := a.accountList()
-	if err != nil {
-		a.logError.Println("error getting account list:", err)
-		return fmt.Errorf("Error logging out. Try again.")
+// publishWebhook queues the event for the webhooks subscribed to kind.
+func (a *App) publishWebhook(kind string, page string, data any) {
+	if a.webhooks == nil {
+		return
 	}
-	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
 
-	err = a.openDetails(acct)
+	err := a.webhooks.Publish(kind, page, data)
 	if err != nil {
-		a.logError.Println("error opening account details:", err)
-		return fmt.Errorf("Error logging out. Try again.")
+		a.logError.Println("error publishing webhook event:", err)
 	}
-
-	return nil
 }
 
-func (a *App) SignedIn() (bool, error) {
-	accounts, err := a.services.AccountS.All()
-	if err != nil {
-		a.logError.Println("error getting all accounts:", err)
-		return false, fmt.Errorf("Error retrieving accounts. Try restarting.")
+// textFileChatProcessor must run after ledgerChatProcessor so that the session's top ranter includes the rant.
+func (a *App) textFileChatProcessor(event events.Chat) {
+	if a.textFiles == nil {
+		return
 	}
 
-	return len(accounts) > 0, nil
+	livestream := a.resolveLivestreamUrl(event.Livestream)
+	a.textFiles.HandleChat(a.livestreamPage(livestream), livestream, event.Message)
 }
 
-type Account struct {
-	Account  models.Account   `json:"account"`
-	Channels []models.Channel `json:"channels"`
-}
+func (a *App) overlayChatProcessor(event events.Chat) {
+	if event.Message.Type != rumblelivestreamlib.ChatTypeMessages {
+		return
+	}
 
-func (a *App) AccountList() (map[string]*Account, error) {
-	list, err := a.accountList()
+	err := a.overlay.Chat(event.Message)
 	if err != nil {
-		a.logError.Println("error getting account list:", err)
-		return nil, fmt.Errorf("Error retrieving accounts and channels. Try restarting.")
+		a.logError.Println("error sending chat message to overlay:", err)
 	}
 
-	return list, nil
-}
+	err = nil
+	switch {
+	case event.Message.Rant > 0:
+		err = a.overlay.Alert(overlay.KindRant, event.Message.Username, int64(event.Message.Rant), event.Message.Text)
+	case event.Message.Raid:
+		err = a.overlay.Alert(overlay.KindRaid, event.Message.Username, 0, event.Message.Text)
+	case event.Message.Sub:
+		err = a.overlay.Alert(overlay.KindSub, event.Message.Username, 0, event.Message.Text)
+	}
+	if err != nil {
+		a.logError.Println("error sending chat alert to overlay:", err)
+	}
+}
+
+func (a *App) ledgerChatProcessor(event events.Chat) {
+	if event.Message.Rant <= 0 {
+		return
+	}
+
+	err := a.ledger.Record(ledger.Entry{
+		Livestream:  a.resolveLivestreamUrl(event.Livestream),
+		Username:    event.Message.Username,
+		AmountCents: int64(event.Message.Rant),
+		Text:        event.Message.Text,
+		Time:        event.Message.Time,
+	}, ledger.SourceChat)
+	if err != nil {
+		a.logError.Println("error recording rant from chat:", err)
+	}
+}
+
+// livestreamPage returns the name of the page the livestream url belongs to, or an empty string if none.
+func (a *App) livestreamPage(url string) string {
+	a.pagesMu.Lock()
+	defer a.pagesMu.Unlock()
+
+	for _, page := range a.pages {
+		if page.staticLiveStreamUrl() == url || page.liveStreamUrl() == url {
+			return page.name
+		}
+	}
+
+	return ""
+}
+
+// resolveLivestreamUrl returns the url of the current livestream if url is the static live url of a live page.
+// Otherwise url is returned unchanged.
+func (a *App) resolveLivestreamUrl(url string) string {
+	a.pagesMu.Lock()
+	defer a.pagesMu.Unlock()
+
+	for _, page := range a.pages {
+		if page.staticLiveStreamUrl() == url {
+			return page.liveStreamUrl()
+		}
+	}
+
+	return url
+}
+
+// TODO: implement this
+func (a *App) chatbotApiProcessor(event events.Api) {
+	a.chatbot.HandleApi(event)
+}
+
+func (a *App) chatbotChatProcessor(event events.Chat) {
+	if event.Message.Type == rumblelivestreamlib.ChatTypeInit {
+		return
+	}
+
+	a.chatbot.HandleChat(event)
+}
+
+func (a *App) chatLogChatProcessor(event events.Chat) {
+	a.chatLog.Write(a.resolveLivestreamUrl(event.Livestream), event)
+}
+
+func (a *App) shutdown(ctx context.Context) {
+	err := a.producers.Shutdown()
+	if err != nil {
+		a.logError.Println("error closing event producers:", err)
+	}
+
+	a.cancelProc()
+
+	if a.obs != nil {
+		a.obs.Stop()
+	}
+
+	if a.webhooks != nil {
+		a.webhooks.Stop()
+	}
+
+	if a.discord != nil {
+		a.discord.Stop()
+	}
+
+	if a.overlay != nil {
+		err := a.overlay.Stop()
+		if err != nil {
+			a.logError.Println("error stopping overlay server:", err)
+		}
+	}
+
+	if a.chatLog != nil {
+		a.chatLog.Close()
+	}
+
+	if a.services != nil {
+		err := a.services.Close()
+		if err != nil {
+			a.logError.Println("error closing services:", err)
+		}
+	}
+
+	a.logFileMu.Lock()
+	if a.logFile != nil {
+		err := a.logFile.Close()
+		if err != nil {
+			log.Println("error closing log file:", err)
+		}
+	}
+	a.logFileMu.Unlock()
+}
+
+func (a *App) Start() (bool, error) {
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing database...")
+	err := a.initServices()
+	if err != nil {
+		a.logError.Println("error initializing services:", err)
+		return false, fmt.Errorf("Error starting Rum Goggles. Try restarting.")
+	}
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing database complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Verifying account sessions...")
+	count, err := a.verifyAccounts()
+	if err != nil {
+		a.logError.Println("error verifying accounts:", err)
+		return false, fmt.Errorf("Error starting Rum Goggles. Try restarting.")
+	}
+	runtime.EventsEmit(a.wails, "StartupMessage", "Verifying account sessions complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing event producers...")
+	err = a.initProducers()
+	if err != nil {
+		a.logError.Println("error initializing producers:", err)
+		return false, fmt.Errorf("Error starting Rum Goggles. Try restarting.")
+	}
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing event producers complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Connecting to OBS...")
+	a.initObs()
+	runtime.EventsEmit(a.wails, "StartupMessage", "Connecting to OBS complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing chat bot...")
+	err = a.initChatbot()
+	if err != nil {
+		a.logError.Println("error initializing chat bot:", err)
+		return false, fmt.Errorf("Error starting Rum Goggles. Try restarting.")
+	}
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing chat bot complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing chat log...")
+	a.initChatLog()
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing chat log complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing rant ledger...")
+	a.initLedger()
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing rant ledger complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing follower history...")
+	a.initAudience()
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing follower history complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing goals...")
+	a.initGoals()
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing goals complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing text files...")
+	err = a.initTextFiles()
+	if err != nil {
+		a.logError.Println("error initializing text files:", err)
+	}
+	runtime.EventsEmit(a.wails, "StartupMessage", "Initializing text files complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Starting overlay server...")
+	err = a.initOverlay()
+	if err != nil {
+		a.logError.Println("error starting overlay server:", err)
+	}
+	runtime.EventsEmit(a.wails, "StartupMessage", "Starting overlay server complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Starting webhooks...")
+	a.initWebhooks()
+	runtime.EventsEmit(a.wails, "StartupMessage", "Starting webhooks complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Starting Discord notifications...")
+	a.initDiscord()
+	runtime.EventsEmit(a.wails, "StartupMessage", "Starting Discord notifications complete.")
+
+	// TODO: check for update - if available, pop up window
+	// runtime.EventsEmit(a.ctx, "StartupMessage", "Checking for updates...")
+	// update, err = a.checkForUpdate()
+	// runtime.EventsEmit(a.ctx, "StartupMessage", "Checking for updates complete.")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancelProc = cancel
+	go a.process(ctx)
+	go a.downsampleStreamStats(ctx)
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Restoring chatbot rules...")
+	err = a.restoreChatbotRules()
+	if err != nil {
+		a.logError.Println("error restoring chatbot rules:", err)
+	}
+	runtime.EventsEmit(a.wails, "StartupMessage", "Restoring chatbot rules complete.")
+
+	runtime.EventsEmit(a.wails, "StartupMessage", "Arming chatbots...")
+	err = a.armChatbots()
+	if err != nil {
+		a.logError.Println("error arming chatbots:", err)
+	}
+	runtime.EventsEmit(a.wails, "StartupMessage", "Arming chatbots complete.")
+
+	signin := true
+	if count > 0 {
+		signin = false
+	}
+
+	return signin, nil
+}
+
+func (a *App) initChatbot() error {
+	cb := chatbot.New(a.services.AccountS, a.services.ChatbotS, a.services.ChatbotSupervisorS, a.services.FollowerMarkS, a.obs, a.logError, a.chatbotRuleError, a.wails)
+	a.chatbot = cb
+
+	return nil
+}
+
+// chatbotRuleError sends a chatbot rule's error to webhooks.
+func (a *App) chatbotRuleError(ruleErr chatbot.RuleError) {
+	a.publishWebhook(webhook.KindRuleError, "", ruleErr)
+}
+
+func (a *App) initAudience() {
+	a.audience = audience.NewTracker(a.services.FollowerS, a.services.SubscriberS)
+}
+
+func (a *App) initGoals() {
+	a.goals = goals.NewTracker(a.services.GoalS)
+}
+
+// initObs loads the OBS settings and starts connecting to OBS in the background if enabled.
+func (a *App) initObs() {
+	settings := obs.DefaultSettings()
+	path, err := config.ObsSettings()
+	if err == nil {
+		settings, err = obs.LoadSettings(path)
+	}
+	if err != nil {
+		a.logError.Println("error loading OBS settings, using defaults:", err)
+		settings = obs.DefaultSettings()
+	}
+
+	a.obs = obs.NewClient(settings, a.logError, func(status obs.Status) {
+		runtime.EventsEmit(a.wails, "ObsStatus", status)
+	})
+	a.obs.Start()
+}
+
+// initTextFiles loads the text file settings and creates the writer.
+// Text files are left disabled if their directory cannot be created.
+func (a *App) initTextFiles() error {
+	dir, err := config.TextFileDir()
+	if err != nil {
+		return fmt.Errorf("error getting text file directory: %v", err)
+	}
+
+	settings := textfile.DefaultSettings()
+	path, err := config.TextFileSettings()
+	if err == nil {
+		settings, err = textfile.LoadSettings(path)
+	}
+	if err != nil {
+		a.logError.Println("error loading text file settings, using defaults:", err)
+		settings = textfile.DefaultSettings()
+	}
+
+	a.textFiles = textfile.NewWriter(dir, settings, a.services.RantS, a.logError)
+
+	return nil
+}
+
+// initOverlay loads the overlay settings and starts the overlay server.
+// The server is created even if it fails to start so that its settings can still be changed.
+func (a *App) initOverlay() error {
+	settings := overlay.DefaultSettings()
+	path, err := config.OverlaySettings()
+	if err == nil {
+		settings, err = overlay.LoadSettings(path)
+	}
+	if err != nil {
+		a.logError.Println("error loading overlay settings, using defaults:", err)
+		settings = overlay.DefaultSettings()
+	}
+
+	a.overlay = overlay.NewServer(settings, a.logError)
+
+	return a.overlay.Start()
+}
+
+// initWebhooks starts delivering webhook events, including the ones still pending from before the app was closed.
+func (a *App) initWebhooks() {
+	a.webhooks = webhook.NewDispatcher(a.services.WebhookS, a.services.WebhookDeliveryS, nil, a.logError, func(delivery models.WebhookDelivery) {
+		runtime.EventsEmit(a.wails, "WebhookDelivery", delivery)
+	})
+	a.webhooks.Start()
+}
+
+// initDiscord loads the Discord settings and starts posting notifications.
+func (a *App) initDiscord() {
+	settings := discord.DefaultSettings()
+	path, err := config.DiscordSettings()
+	if err == nil {
+		settings, err = discord.LoadSettings(path)
+	}
+	if err != nil {
+		a.logError.Println("error loading Discord settings, using defaults:", err)
+		settings = discord.DefaultSettings()
+	}
+
+	a.discord = discord.NewNotifier(settings, nil, a.logError)
+	a.discord.Start()
+}
+
+func (a *App) initLedger() {
+	a.ledger = ledger.New(a.services.RantS)
+}
+
+func (a *App) initChatLog() {
+	a.chatLog = chatlog.NewWriter(a.services.ChatMessageS, a.services.ViewerS, a.logError)
+}
+
+func (a *App) initProducers() error {
+	producers, err := events.NewProducers(
+		events.WithLoggers(a.logError, a.logInfo),
+		events.WithApiProducer(),
+		events.WithChatProducer(),
+	)
+	if err != nil {
+		return fmt.Errorf("error initializing producers: %v", err)
+	}
+
+	err = producers.Startup()
+	if err != nil {
+		return fmt.Errorf("error starting producers: %v", err)
+	}
+
+	a.producers = producers
+
+	return nil
+}
+
+func (a *App) initServices() error {
+	db, err := config.Database()
+	if err != nil {
+		return fmt.Errorf("error getting database config: %v", err)
+	}
+
+	services, err := models.NewServices(
+		models.WithDatabase(db),
+		models.WithAccountService(),
+		models.WithChannelService(),
+		models.WithAccountChannelService(),
+		models.WithChatbotService(),
+		models.WithChatbotRuleService(),
+		models.WithChatbotSupervisorService(),
+		models.WithChatMessageService(),
+		models.WithViewerService(),
+		models.WithStreamStatService(),
+		models.WithSessionReportService(),
+		models.WithRantService(),
+		models.WithFollowerService(),
+		models.WithSubscriberService(),
+		models.WithFollowerMarkService(),
+		models.WithGoalService(),
+		models.WithStreamChangeService(),
+		models.WithWebhookService(),
+		models.WithWebhookDeliveryService(),
+	)
+	if err != nil {
+		return fmt.Errorf("error initializing services: %v", err)
+	}
+
+	err = services.AutoMigrate()
+	if err != nil {
+		return fmt.Errorf("error auto-migrating services: %v", err)
+	}
+
+	a.services = services
+
+	return nil
+}
+
+func (a *App) verifyAccounts() (int, error) {
+	accounts, err := a.services.AccountS.All()
+	if err != nil {
+		return -1, fmt.Errorf("error querying all accounts: %v", err)
+	}
+
+	a.clientsMu.Lock()
+	defer a.clientsMu.Unlock()
+	for _, account := range accounts {
+		if account.Cookies != nil {
+			var cookies []*http.Cookie
+			err = json.Unmarshal([]byte(*account.Cookies), &cookies)
+			if err != nil {
+				return -1, fmt.Errorf("error un-marshaling cookie string: %v", err)
+			}
+			client, err := rumblelivestreamlib.NewClient(rumblelivestreamlib.NewClientOptions{Cookies: cookies})
+			if err != nil {
+				return -1, fmt.Errorf("error creating new client: %v", err)
+			}
+			if account.Username == nil {
+				return -1, fmt.Errorf("account username is nil")
+			}
+			loggedIn, err := client.LoggedIn()
+			if err != nil {
+				return -1, fmt.Errorf("error checking if account is logged in: %v", err)
+			}
+			if loggedIn.User.LoggedIn {
+				a.clients[*account.Username] = client
+			} else {
+				account.Cookies = nil
+				err = a.services.AccountS.Update(&account)
+				if err != nil {
+					return -1, fmt.Errorf("error updating account: %v", err)
+				}
+			}
+		}
+	}
+
+	return len(accounts), nil
+}
+
+func (a *App) AddPage(apiKey string) error {
+	client := rumblelivestreamlib.Client{ApiKey: apiKey}
+	resp, err := client.Request()
+	if err != nil {
+		a.logError.Println("error executing api request:", err)
+		return fmt.Errorf("Error querying API. Verify key and try again.")
+	}
+
+	userKey := apiKey
+	channelKey := ""
+	if resp.Type == "channel" {
+		userKey = ""
+		channelKey = apiKey
+	}
+
+	err = a.addAccountNotExist(resp.UserID, resp.Username, userKey)
+	if err != nil {
+		a.logError.Println("error adding account if not exist:", err)
+		return fmt.Errorf("Error adding channel. Try again.")
+	}
+
+	if resp.Type == "channel" {
+		err = a.addChannelNotExist(resp.Username, fmt.Sprint(resp.ChannelID), resp.ChannelName, channelKey)
+		if err != nil {
+			a.logError.Println("error adding channel if not exist:", err)
+			return fmt.Errorf("Error adding channel. Try again.")
+		}
+	}
+
+	list, err := a.accountList()
+	if err != nil {
+		a.logError.Println("error getting account list:", err)
+		return fmt.Errorf("Error logging in. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
+
+	return nil
+}
+
+func (a *App) addAccountNotExist(uid string, username string, apiKey string) error {
+	acct, err := a.services.AccountS.ByUsername(username)
+	if err != nil {
+		return fmt.Errorf("error querying account by username: %v", err)
+	}
+	if acct == nil {
+		_, err = a.services.AccountS.Create(&models.Account{
+			UID:      &uid,
+			Username: &username,
+			ApiKey:   &apiKey,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating account: %v", err)
+		}
+	}
+	return nil
+}
+
+func (a *App) addChannelNotExist(username string, cid string, name string, apiKey string) error {
+	channel, err := a.services.ChannelS.ByName(name)
+	if err != nil {
+		return fmt.Errorf("error querying channel by name: %v", err)
+	}
+	if channel == nil {
+		acct, err := a.services.AccountS.ByUsername(username)
+		if err != nil {
+			return fmt.Errorf("error querying account by username: %v", err)
+		}
+		if acct == nil {
+			return fmt.Errorf("account does not exist with username: %s", username)
+		}
+
+		err = a.services.ChannelS.Create(&models.Channel{
+			AccountID: acct.ID,
+			CID:       &cid,
+			Name:      &name,
+			ApiKey:    &apiKey,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating channel: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (a *App) Login(username string, password string) error {
+	var err error
+	a.clientsMu.Lock()
+	defer a.clientsMu.Unlock()
+	client, exists := a.clients[username]
+	if exists && client != nil {
+		err = client.Logout()
+		if err != nil {
+			a.logError.Println("error logging out:", err)
+			return fmt.Errorf("Error logging in. Try again.")
+		}
+	} else {
+		client, err = rumblelivestreamlib.NewClient(rumblelivestreamlib.NewClientOptions{})
+	}
+
+	cookies, err := client.Login(username, password)
+	if err != nil {
+		a.logError.Println("error logging in:", err)
+		return fmt.Errorf("Error logging in. Try again.")
+	}
+	a.clients[username] = client
+
+	cookiesB, err := json.Marshal(cookies)
+	if err != nil {
+		a.logError.Println("error marshaling cookies into json:", err)
+		return fmt.Errorf("Error logging in. Try again.")
+	}
+	cookiesS := string(cookiesB)
+
+	acct, err := a.services.AccountS.ByUsername(username)
+	if err != nil {
+		a.logError.Println("error getting account by username:", err)
+		return fmt.Errorf("Error logging in. Try again.")
+	}
+	if acct == nil {
+		loggedIn, err := client.LoggedIn()
+		if err != nil {
+			a.logError.Println("error checking if account is logged in:", err)
+			return fmt.Errorf("Error logging in. Try again.")
+		}
+
+		uid, found := strings.CutPrefix(loggedIn.User.ID, "_u")
+		if !found {
+			a.logError.Println("did not find uid prefix '_u' in response after checking if accounts is logged in")
+			return fmt.Errorf("Error logging in. Try again.")
+		}
+		rumbleUsername := loggedIn.Data.Username
+		if rumbleUsername == "" {
+			a.logError.Println("username is empty in response after checking if accounts is logged in")
+			return fmt.Errorf("Error logging in. Try again.")
+		}
+
+		acct = &models.Account{nil, &uid, &rumbleUsername, &cookiesS, nil, nil}
+		id, err := a.services.AccountS.Create(acct)
+		if err != nil {
+			a.logError.Println("error creating account:", err)
+			return fmt.Errorf("Error logging in. Try again.")
+		}
+		acct.ID = &id
+	} else {
+		acct.Cookies = &cookiesS
+		err = a.services.AccountS.Update(acct)
+		if err != nil {
+			a.logError.Println("error updating account:", err)
+			return fmt.Errorf("Error logging in. Try again.")
+		}
+	}
+
+	name := acct.String()
+	if name == nil {
+		a.logError.Println("account name is nil")
+		return fmt.Errorf("Error logging in. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "LoggedIn-"+*name, true)
+
+	list, err := a.accountList()
+	if err != nil {
+		a.logError.Println("error getting account list:", err)
+		return fmt.Errorf("Error logging in. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
+
+	err = a.openDetails(acct)
+	if err != nil {
+		a.logError.Println("error opening account details:", err)
+		return fmt.Errorf("Error logging in. Try again.")
+	}
+
+	return nil
+}
+
+func (a *App) Logout(id int64) error {
+	acct, err := a.services.AccountS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying account by ID:", err)
+		return fmt.Errorf("Error logging out. Try again.")
+	}
+	if acct == nil {
+		return fmt.Errorf("Did not find account. Try again.")
+	}
+
+	if acct.Username == nil {
+		a.logError.Println("account username is nil")
+		return fmt.Errorf("Error logging out. Try again.")
+	}
+
+	a.clientsMu.Lock()
+	defer a.clientsMu.Unlock()
+	client, exists := a.clients[*acct.Username]
+	if exists {
+		err = client.Logout()
+		if err != nil {
+			a.logError.Println("error logging out:", err)
+			return fmt.Errorf("Error logging out. Try again.")
+		}
+		delete(a.clients, *acct.Username)
+	}
+
+	if acct.Cookies != nil {
+		if !exists {
+			var cookies []*http.Cookie
+			err = json.Unmarshal([]byte(*acct.Cookies), &cookies)
+			if err != nil {
+				a.logError.Println("error un-marshaling cookie string:", err)
+				return fmt.Errorf("Error logging out. Try again.")
+			}
+			client, err = rumblelivestreamlib.NewClient(rumblelivestreamlib.NewClientOptions{Cookies: cookies})
+			err = client.Logout()
+			if err != nil {
+				a.logError.Println("error logging out:", err)
+				return fmt.Errorf("Error logging out. Try again.")
+			}
+		}
+		acct.Cookies = nil
+		err = a.services.AccountS.Update(acct)
+		if err != nil {
+			a.logError.Println("error updating account:", err)
+			return fmt.Errorf("Error logging out. Try again.")
+		}
+	}
+
+	name := acct.String()
+	if name == nil {
+		a.logError.Println("account name is nil")
+		return fmt.Errorf("Error logging out. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "LoggedIn-"+*name, false)
+
+	list, err := a.accountList()
+	if err != nil {
+		a.logError.Println("error getting account list:", err)
+		return fmt.Errorf("Error logging out. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
+
+	err = a.openDetails(acct)
+	if err != nil {
+		a.logError.Println("error opening account details:", err)
+		return fmt.Errorf("Error logging out. Try again.")
+	}
+
+	return nil
+}
+
+func (a *App) SignedIn() (bool, error) {
+	accounts, err := a.services.AccountS.All()
+	if err != nil {
+		a.logError.Println("error getting all accounts:", err)
+		return false, fmt.Errorf("Error retrieving accounts. Try restarting.")
+	}
+
+	return len(accounts) > 0, nil
+}
+
+type Account struct {
+	Account  models.Account   `json:"account"`
+	Channels []models.Channel `json:"channels"`
+}
+
+func (a *App) AccountList() (map[string]*Account, error) {
+	list, err := a.accountList()
+	if err != nil {
+		a.logError.Println("error getting account list:", err)
+		return nil, fmt.Errorf("Error retrieving accounts and channels. Try restarting.")
+	}
+
+	return list, nil
+}
+
+func (a *App) accountList() (map[string]*Account, error) {
+	list := map[string]*Account{}
+
+	accountChannels, err := a.services.AccountChannelS.All()
+	if err != nil {
+		return nil, fmt.Errorf("error querying all account channels: %v", err)
+	}
+
+	for _, ac := range accountChannels {
+		if ac.Account.Username == nil {
+			return nil, fmt.Errorf("account-channel contains nil account username")
+		}
+
+		act, exists := list[*ac.Account.Username]
+		if !exists || act == nil {
+			act = &Account{ac.Account, []models.Channel{}}
+			list[*ac.Account.Username] = act
+		}
+
+		if ac.Channel.AccountID != nil {
+			act.Channels = append(act.Channels, ac.Channel)
+		}
+	}
+
+	return list, nil
+}
+
+func (a *App) OpenAccount(id int64) error {
+	acct, err := a.services.AccountS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying account by ID:", err)
+		return fmt.Errorf("Error opening account. Try again.")
+	}
+	if acct == nil {
+		return fmt.Errorf("Did not find account. Try again.")
+	}
+
+	err = a.openDetails(acct)
+	if err != nil {
+		a.logError.Println("error opening account details:", err)
+		return fmt.Errorf("Error opening account. Try again.")
+	}
+
+	return nil
+}
+
+func (a *App) OpenChannel(id int64) error {
+	channel, err := a.services.ChannelS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying channel by ID:", err)
+		return fmt.Errorf("Error opening channel. Try again.")
+	}
+	if channel == nil {
+		return fmt.Errorf("Did not find channel. Try again.")
+	}
+
+	err = a.openDetails(channel)
+	if err != nil {
+		a.logError.Println("error opening channel details:", err)
+		return fmt.Errorf("Error opening channel. Try again.")
+	}
+
+	return nil
+}
+
+type PageInfo interface {
+	Id() *int64
+	KeyUrl() *string
+	LoggedIn() bool
+	String() *string
+	Title() *string
+	Type() string
+}
+
+// pageInfo returns the account or channel with the given page name, or nil if none exists.
+func (a *App) pageInfo(name string) (PageInfo, error) {
+	accountChannels, err := a.services.AccountChannelS.All()
+	if err != nil {
+		return nil, fmt.Errorf("error querying all account channels: %v", err)
+	}
+
+	for _, ac := range accountChannels {
+		acct := ac.Account
+		if s := acct.String(); s != nil && *s == name {
+			return &acct, nil
+		}
+		channel := ac.Channel
+		if s := channel.String(); s != nil && *s == name {
+			return &channel, nil
+		}
+	}
+
+	return nil, nil
+}
+
+type PageDetails struct {
+	ID       int64  `json:"id"`
+	HasApi   bool   `json:"has_api"`
+	LoggedIn bool   `json:"logged_in"`
+	Title    string `json:"title"`
+	Type     string `json:"type"`
+}
+
+func (a *App) openDetails(pi PageInfo) error {
+	id := pi.Id()
+	if id == nil {
+		return fmt.Errorf("page id is nil")
+	}
+
+	hasApi := true
+	key := pi.KeyUrl()
+	if key == nil || *key == "" {
+		hasApi = false
+	}
+
+	name := pi.String()
+	if name == nil {
+		return fmt.Errorf("page name is nil")
+	}
+
+	title := pi.Title()
+	if title == nil {
+		return fmt.Errorf("page title is nil")
+	}
+
+	runtime.EventsEmit(a.wails, "PageDetails", PageDetails{
+		ID:       *id,
+		HasApi:   hasApi,
+		LoggedIn: pi.LoggedIn(),
+		Title:    *title,
+		Type:     pi.Type(),
+	})
+
+	err := a.display(*name)
+	if err != nil {
+		return fmt.Errorf("error displaying page: %v", err)
+	}
+
+	return nil
+}
+
+func (a *App) ActivateAccount(id int64) error {
+	acct, err := a.services.AccountS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying account by ID:", err)
+		return fmt.Errorf("Error activating account. Try again.")
+	}
+	if acct == nil {
+		return fmt.Errorf("Did not find account. Try again.")
+	}
+
+	err = a.activatePage(acct)
+	if err != nil {
+		a.logError.Println("error activating account:", err)
+		return fmt.Errorf("Error activating account. Try again.")
+	}
+
+	return nil
+}
+
+// ActivateChannel activates an inactivate page and deactivates an active page.
+func (a *App) ActivateChannel(id int64) error {
+	channel, err := a.services.ChannelS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying channel by ID:", err)
+		return fmt.Errorf("Error activating channel. Try again.")
+	}
+	if channel == nil {
+		return fmt.Errorf("Did not find channel. Try again.")
+	}
+
+	err = a.activatePage(channel)
+	if err != nil {
+		a.logError.Println("error activating channel:", err)
+		return fmt.Errorf("Error activating channel. Try again.")
+	}
+
+	return nil
+}
+
+func (a *App) startPageApi(pi PageInfo) error {
+	name := pi.String()
+	if name == nil {
+		return fmt.Errorf("page name is nil")
+	}
+	url := pi.KeyUrl()
+	if url == nil {
+		return fmt.Errorf("page key url is nil")
+	}
+
+	if !a.producers.ApiP.Active(*name) {
+		err := a.producers.ApiP.Start(*name, *url, 10*time.Second)
+		if err != nil {
+			return fmt.Errorf("error starting api: %v", err)
+		}
+		runtime.EventsEmit(a.wails, "ApiActive-"+*name, true)
+	}
+
+	return nil
+}
+
+// If page is inactivate, activate.
+// If page is active, deactivate.
+func (a *App) activatePage(pi PageInfo) error {
+	name := pi.String()
+	if name == nil {
+		return fmt.Errorf("page name is nil")
+	}
+
+	a.pagesMu.Lock()
+	page, exists := a.pages[*name]
+	if !exists {
+		page = &Page{
+			active: false,
+			apiSt:  &ApiState{},
+			name:   *name,
+		}
+		a.pages[*name] = page
+	}
+	a.pagesMu.Unlock()
+
+	page.activeMu.Lock()
+	defer page.activeMu.Unlock()
+	if page.active {
+		if a.producers.ApiP.Active(*name) {
+			err := a.producers.ApiP.Stop(*name)
+			if err != nil {
+				return fmt.Errorf("error stopping api: %v", err)
+			}
+		}
+
+		page.displayingMu.Lock()
+		if page.displaying {
+			runtime.EventsEmit(a.wails, "PageActive", false)
+		}
+		page.displayingMu.Unlock()
+
+		page.active = false
+		return nil
+	}
+	page.active = true
+
+	url := pi.KeyUrl()
+	if url == nil {
+		return fmt.Errorf("page key url is nil")
+	}
+
+	err := a.producers.ApiP.Start(*name, *url, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("error starting api: %v", err)
+	}
+	runtime.EventsEmit(a.wails, "ApiActive-"+*name, true)
+
+	err = a.display(*name)
+	if err != nil {
+		return fmt.Errorf("error displaying page: %v", err)
+	}
+	runtime.EventsEmit(a.wails, "PageActive", true)
+
+	return nil
+}
+
+func (a *App) deletePage(pi PageInfo) error {
+	name := pi.String()
+	if name == nil {
+		return fmt.Errorf("page name is nil")
+	}
+
+	a.pagesMu.Lock()
+	defer a.pagesMu.Unlock()
+	page, exists := a.pages[*name]
+	if !exists {
+		return nil
+	}
+
+	page.activeMu.Lock()
+	defer page.activeMu.Unlock()
+	if page.active {
+		if a.producers.ApiP.Active(*name) {
+			err := a.producers.ApiP.Stop(*name)
+			if err != nil {
+				return fmt.Errorf("error stopping api: %v", err)
+			}
+		}
+
+		page.displayingMu.Lock()
+		if page.displaying {
+			runtime.EventsEmit(a.wails, "PageActive", false)
+		}
+		page.displayingMu.Unlock()
+
+		page.active = false
+	}
+
+	delete(a.pages, *name)
+	a.activity.Remove(*name)
+
+	return nil
+}
+
+func (a *App) DeleteAccount(id int64) error {
+	acct, err := a.services.AccountS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying account by ID:", err)
+		return fmt.Errorf("Error deleting account. Try again.")
+	}
+	if acct == nil {
+		return fmt.Errorf("Did not find account. Try again.")
+	}
+
+	channels, err := a.services.ChannelS.ByAccount(acct)
+	if err != nil {
+		a.logError.Println("error querying channels by account:", err)
+		return fmt.Errorf("Error deleting account. Try again.")
+	}
+	if len(channels) != 0 {
+		return fmt.Errorf("You must delete all channels associated with the account before it can be deleted.")
+	}
+
+	name := acct.String()
+	if name == nil {
+		a.logError.Println("account name is nil")
+		return fmt.Errorf("Error deleting account. Try again.")
+	}
+
+	err = a.deletePage(acct)
+	if err != nil {
+		a.logError.Println("error deleting page:", err)
+		return fmt.Errorf("Error deleting account. Try again.")
+	}
+
+	err = a.services.AccountS.Delete(acct)
+	if err != nil {
+		a.logError.Println("error deleting account:", err)
+		return fmt.Errorf("Error deleting account. Try again.")
+	}
+
+	runtime.EventsEmit(a.wails, "PageDetails", nil)
+
+	list, err := a.accountList()
+	if err != nil {
+		a.logError.Println("error getting account list:", err)
+		return fmt.Errorf("Error deleting account. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
+
+	return nil
+}
+
+func (a *App) DeleteChannel(id int64) error {
+	channel, err := a.services.ChannelS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying channel by ID:", err)
+		return fmt.Errorf("Error deleting channel. Try again.")
+	}
+	if channel == nil {
+		return fmt.Errorf("Did not find channel. Try again.")
+	}
+
+	name := channel.String()
+	if name == nil {
+		a.logError.Println("channel name is nil")
+		return fmt.Errorf("Error deleting channel. Try again.")
+	}
+
+	err = a.deletePage(channel)
+	if err != nil {
+		a.logError.Println("error deleting page:", err)
+		return fmt.Errorf("Error deleting channel. Try again.")
+	}
+
+	err = a.services.ChannelS.Delete(channel)
+	if err != nil {
+		a.logError.Println("error deleting channel:", err)
+		return fmt.Errorf("Error deleting channel. Try again.")
+	}
+
+	runtime.EventsEmit(a.wails, "PageDetails", nil)
+
+	list, err := a.accountList()
+	if err != nil {
+		a.logError.Println("error getting account list:", err)
+		return fmt.Errorf("Error deleting channel. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
+
+	return nil
+}
+
+func (a *App) display(name string) error {
+	a.displayingMu.Lock()
+	defer a.displayingMu.Unlock()
+
+	if name == a.displaying {
+		return nil
+	}
+
+	a.pagesMu.Lock()
+	defer a.pagesMu.Unlock()
+
+	if a.displaying != "" {
+		prev := a.displaying
+		displaying, exists := a.pages[prev]
+		a.displaying = ""
+		if !exists {
+			return fmt.Errorf("displaying page does not exist: %s", prev)
+		}
+		displaying.displayingMu.Lock()
+		displaying.displaying = false
+		displaying.displayingMu.Unlock()
+	}
+
+	page, exists := a.pages[name]
+	if !exists {
+		page = &Page{
+			active: false,
+			apiSt:  &ApiState{},
+			name:   name,
+		}
+		a.pages[name] = page
+	}
+	page.displayingMu.Lock()
+	page.displaying = true
+	page.displayingMu.Unlock()
+
+	a.displaying = name
+
+	err := a.updatePage(page)
+	if err != nil {
+		return fmt.Errorf("error updating page: %v", err)
+	}
+
+	return nil
+}
+
+func (a *App) updatePage(p *Page) error {
+	if p == nil {
+		return fmt.Errorf("page is nil")
+	}
+
+	//TODO check p.api == nil
+
+	p.apiSt.respMu.Lock()
+	defer p.apiSt.respMu.Unlock()
+
+	p.displayingMu.Lock()
+	if p.displaying {
+		runtime.EventsEmit(a.wails, "PageActivity", p.apiSt.resp)
+		p.activeMu.Lock()
+		runtime.EventsEmit(a.wails, "PageActive", p.active)
+		p.activeMu.Unlock()
+	}
+	p.displayingMu.Unlock()
+
+	if p.apiSt.resp != nil {
+		isLive := len(p.apiSt.resp.Livestreams) > 0
+		runtime.EventsEmit(a.wails, "PageLive-"+p.name, isLive)
+	}
+
+	return nil
+}
+
+func (a *App) PageStatus(name string) {
+	active := false
+	isLive := false
+
+	a.pagesMu.Lock()
+	defer a.pagesMu.Unlock()
+	page, exists := a.pages[name]
+	if exists && page.apiSt != nil {
+		page.apiSt.activeMu.Lock()
+		active = page.apiSt.active
+		page.apiSt.activeMu.Unlock()
+
+		page.apiSt.respMu.Lock()
+		if page.apiSt.resp != nil {
+			isLive = len(page.apiSt.resp.Livestreams) > 0
+		}
+		page.apiSt.respMu.Unlock()
+	}
+
+	runtime.EventsEmit(a.wails, "ApiActive-"+name, active)
+	runtime.EventsEmit(a.wails, "PageLive-"+name, isLive)
+}
+
+func (a *App) UpdateAccountApi(id int64, apiKey string) error {
+	acct, err := a.services.AccountS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying account by ID:", err)
+		return fmt.Errorf("Error updating account. Try again.")
+	}
+	if acct == nil {
+		return fmt.Errorf("Did not find account. Try again.")
+	}
+
+	name := acct.String()
+	if name == nil {
+		a.logError.Println("account name is nil")
+		return fmt.Errorf("Error updating account. Try again.")
+	}
+
+	if a.producers.ApiP.Active(*name) {
+		err := a.producers.ApiP.Stop(*name)
+		if err != nil {
+			a.logError.Println("error stopping api:", err)
+			return fmt.Errorf("Error updating account. Try again.")
+		}
+	}
+
+	client := rumblelivestreamlib.Client{ApiKey: apiKey}
+	resp, err := client.Request()
+	if err != nil {
+		a.logError.Println("error executing api request:", err)
+		return fmt.Errorf("Error querying API. Verify key and try again.")
+	}
+
+	if resp.ChannelName != "" || resp.Username != *acct.Username {
+		return fmt.Errorf("API key does not belong to account. Verify key and try again.")
+	}
+
+	acct.ApiKey = &apiKey
+	err = a.services.AccountS.Update(acct)
+	if err != nil {
+		a.logError.Println("error updating account:", err)
+		return fmt.Errorf("Error updating account. Try again.")
+	}
+
+	return nil
+}
+
+func (a *App) UpdateChannelApi(id int64, apiKey string) error {
+	channel, err := a.services.ChannelS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying channel by ID:", err)
+		return fmt.Errorf("Error updating channel. Try again.")
+	}
+	if channel == nil {
+		return fmt.Errorf("Did not find channel. Try again.")
+	}
+
+	name := channel.String()
+	if name == nil {
+		a.logError.Println("channel name is nil")
+		return fmt.Errorf("Error updating channel. Try again.")
+	}
+
+	if a.producers.ApiP.Active(*name) {
+		err := a.producers.ApiP.Stop(*name)
+		if err != nil {
+			a.logError.Println("error stopping api:", err)
+			return fmt.Errorf("Error updating channel. Try again.")
+		}
+	}
+
+	client := rumblelivestreamlib.Client{ApiKey: apiKey}
+	resp, err := client.Request()
+	if err != nil {
+		a.logError.Println("error executing api request:", err)
+		return fmt.Errorf("Error querying API. Verify key and try again.")
+	}
+
+	if resp.ChannelName != *channel.Name {
+		return fmt.Errorf("API key does not belong to channel. Verify key and try again.")
+	}
+
+	*channel.ApiKey = apiKey
+	err = a.services.ChannelS.Update(channel)
+	if err != nil {
+		a.logError.Println("error updating channel:", err)
+		return fmt.Errorf("Error updating channel. Try again.")
+	}
+
+	return nil
+}
+
+func (a *App) DeleteChatbot(chatbot *models.Chatbot) error {
+	if chatbot == nil || chatbot.ID == nil {
+		return fmt.Errorf("Invalid chatbot. Try again.")
+	}
+
+	err := a.StopChatbotRules(chatbot.ID)
+	if err != nil {
+		a.logError.Println("error stopping chatbot rules before deleting chatbot")
+		return fmt.Errorf("Error deleting chatbot. Could not stop running rules. Try Again.")
+	}
+
+	cb, err := a.services.ChatbotS.ByID(*chatbot.ID)
+	if err != nil {
+		a.logError.Println("error getting chatbot by ID:", err)
+		return fmt.Errorf("Error deleting chatbot. Try again.")
+	}
+	if cb == nil {
+		return fmt.Errorf("Chatbot does not exist.")
+	}
+
+	rules, err := a.services.ChatbotRuleS.ByChatbotID(*chatbot.ID)
+	if err != nil {
+		a.logError.Println("error getting chatbot rules by chatbot ID:", err)
+		return fmt.Errorf("Error deleting chatbot. Try again.")
+	}
+
+	for _, rule := range rules {
+		err = a.services.ChatbotRuleS.Delete(&rule)
+		if err != nil {
+			a.logError.Println("error deleting chatbot rule:", err)
+			return fmt.Errorf("Error deleting chatbot. Try again.")
+		}
+	}
+
+	supervisor, err := a.services.ChatbotSupervisorS.ByChatbotID(*chatbot.ID)
+	if err != nil {
+		a.logError.Println("error getting chatbot supervisor by chatbot ID:", err)
+		return fmt.Errorf("Error deleting chatbot. Try again.")
+	}
+	if supervisor != nil {
+		err = a.services.ChatbotSupervisorS.Delete(supervisor)
+		if err != nil {
+			a.logError.Println("error deleting chatbot supervisor:", err)
+			return fmt.Errorf("Error deleting chatbot. Try again.")
+		}
+	}
+
+	err = a.services.ChatbotS.Delete(chatbot)
+	if err != nil {
+		a.logError.Println("error deleting chatbot:", err)
+		return fmt.Errorf("Error deleting chatbot. Try again.")
+	}
+
+	list, err := a.chatbotList()
+	if err != nil {
+		a.logError.Println("error getting chatbot list:", err)
+		return fmt.Errorf("Error deleting chatbot. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "ChatbotList", list)
+
+	return nil
+}
+
+func (a *App) NewChatbot(chatbot *models.Chatbot) error {
+	if chatbot == nil || chatbot.Name == nil {
+		return fmt.Errorf("Invalid chatbot. Try again.")
+	}
+
+	cb, err := a.services.ChatbotS.ByName(*chatbot.Name)
+	if err != nil {
+		a.logError.Println("error getting chatbot by name:", err)
+		return fmt.Errorf("Error creating chatbot. Try again.")
+	}
+	if cb != nil {
+		return fmt.Errorf("Chatbot name already exists.")
+	}
+
+	_, err = a.services.ChatbotS.Create(chatbot)
+	if err != nil {
+		a.logError.Println("error creating chatbot:", err)
+		return fmt.Errorf("Error creating chatbot. Try again.")
+	}
+
+	err = a.armChatbot(chatbot)
+	if err != nil {
+		a.logError.Println("error arming chatbot:", err)
+		return fmt.Errorf("Chatbot created, but the linked page could not be monitored. Check the page's API key.")
+	}
+
+	list, err := a.chatbotList()
+	if err != nil {
+		a.logError.Println("error getting chatbot list:", err)
+		return fmt.Errorf("Error creating chatbot. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "ChatbotList", list)
+
+	return nil
+}
+
+func (a *App) UpdateChatbot(chatbot *models.Chatbot) error {
+	if chatbot == nil || chatbot.ID == nil || chatbot.Name == nil {
+		return fmt.Errorf("Invalid chatbot. Try again.")
+	}
+
+	cb, err := a.services.ChatbotS.ByID(*chatbot.ID)
+	if err != nil {
+		a.logError.Println("error getting chatbot by ID:", err)
+		return fmt.Errorf("Error updating chatbot. Try again.")
+	}
+	if cb == nil {
+		return fmt.Errorf("Chatbot does not exist.")
+	}
+
+	cbByName, err := a.services.ChatbotS.ByName(*chatbot.Name)
+	if err != nil {
+		a.logError.Println("error getting chatbot by Name:", err)
+		return fmt.Errorf("Error updating chatbot. Try again.")
+	}
+	if cbByName != nil && *cbByName.ID != *cb.ID {
+		return fmt.Errorf("Chatbot name already exists.")
+	}
+
+	err = a.services.ChatbotS.Update(chatbot)
+	if err != nil {
+		a.logError.Println("error updating chatbot:", err)
+		return fmt.Errorf("Error updating chatbot. Try again.")
+	}
+
+	err = a.armChatbot(chatbot)
+	if err != nil {
+		a.logError.Println("error arming chatbot:", err)
+		return fmt.Errorf("Chatbot updated, but the linked page could not be monitored. Check the page's API key.")
+	}
+	runtime.EventsEmit(a.wails, fmt.Sprintf("ChatbotArmed-%d", *chatbot.ID), a.chatbotArmed(chatbot))
+
+	// list, err := a.chatbotList()
+	// if err != nil {
+	// 	a.logError.Println("error getting chatbot list:", err)
+	// 	return fmt.Errorf("Error updating chatbot. Try again.")
+	// }
+	runtime.EventsEmit(a.wails, "ChatbotInfo", chatbot)
+	// runtime.EventsEmit(a.wails, "ChatbotList", list)
+
+	return nil
+}
+
+func (a *App) ChatbotSupervisor(chatbotID int64) (*models.ChatbotSupervisor, error) {
+	supervisor, err := a.services.ChatbotSupervisorS.ByChatbotID(chatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot supervisor by chatbot ID:", err)
+		return nil, fmt.Errorf("Error getting chatbot supervisor. Try again.")
+	}
+	if supervisor == nil {
+		enabled := false
+		supervisor = &models.ChatbotSupervisor{ChatbotID: &chatbotID, Enabled: &enabled}
+	}
+
+	return supervisor, nil
+}
+
+// UpdateChatbotSupervisor saves the chatbot's supervisor settings.
+// Rules that are already running pick up the new settings the next time they are run.
+func (a *App) UpdateChatbotSupervisor(supervisor *models.ChatbotSupervisor) error {
+	if supervisor == nil || supervisor.ChatbotID == nil || supervisor.Enabled == nil {
+		return fmt.Errorf("Invalid chatbot supervisor. Try again.")
+	}
+
+	cb, err := a.services.ChatbotS.ByID(*supervisor.ChatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot by ID:", err)
+		return fmt.Errorf("Error updating chatbot supervisor. Try again.")
+	}
+	if cb == nil {
+		return fmt.Errorf("Chatbot does not exist.")
+	}
+
+	existing, err := a.services.ChatbotSupervisorS.ByChatbotID(*supervisor.ChatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot supervisor by chatbot ID:", err)
+		return fmt.Errorf("Error updating chatbot supervisor. Try again.")
+	}
+	if existing == nil {
+		id, err := a.services.ChatbotSupervisorS.Create(supervisor)
+		if err != nil {
+			a.logError.Println("error creating chatbot supervisor:", err)
+			return fmt.Errorf("Error updating chatbot supervisor. Try again.")
+		}
+		supervisor.ID = &id
+	} else {
+		supervisor.ID = existing.ID
+		err = a.services.ChatbotSupervisorS.Update(supervisor)
+		if err != nil {
+			a.logError.Println("error updating chatbot supervisor:", err)
+			return fmt.Errorf("Error updating chatbot supervisor. Try again.")
+		}
+	}
+
+	runtime.EventsEmit(a.wails, fmt.Sprintf("ChatbotSupervisor-%d", *supervisor.ChatbotID), supervisor)
+
+	return nil
+}
+
+func (a *App) ChatbotList() ([]models.Chatbot, error) {
+	list, err := a.chatbotList()
+	if err != nil {
+		a.logError.Println("error getting chatbot list:", err)
+		return nil, fmt.Errorf("Error retrieving chatbots. Try restarting.")
+	}
+
+	return list, nil
+}
+
+func (a *App) chatbotList() ([]models.Chatbot, error) {
+	list, err := a.services.ChatbotS.All()
+	if err != nil {
+		return nil, fmt.Errorf("error querying all chatbots: %v", err)
+	}
+
+	return list, err
+}
+
+func (a *App) ChatbotRules(chatbot *models.Chatbot) ([]chatbot.Rule, error) {
+	if chatbot == nil || chatbot.ID == nil {
+		return nil, fmt.Errorf("Invalid chatbot. Try again.")
+	}
+
+	rules, err := a.chatbotRules(*chatbot.ID)
+	if err != nil {
+		a.logError.Println("error getting chatbot rules:", err)
+		return nil, fmt.Errorf("Error getting chatbot rules. Try again.")
+	}
+
+	return rules, nil
+}
+
+func (a *App) chatbotRules(chatbotID int64) ([]chatbot.Rule, error) {
+	modelsRules, err := a.services.ChatbotRuleS.ByChatbotID(chatbotID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying chatbot rules: %v", err)
+	}
 
-func (a *App) accountList() (map[string]*Account, error) {
-	list := map[string]*Account{}
+	rules := []chatbot.Rule{}
+	for _, modelsRule := range modelsRules {
+		rule, err := a.chatbotRule(modelsRule)
+		if err != nil {
+			return nil, fmt.Errorf("error converting chatbot rule: %v", err)
+		}
 
-	accountChannels, err := a.services.AccountChannelS.All()
+		rules = append(rules, *rule)
+	}
+
+	chatbot.SortRules(rules)
+
+	return rules, err
+}
+
+func (a *App) chatbotRule(modelsRule models.ChatbotRule) (*chatbot.Rule, error) {
+	rule := chatbot.Rule{
+		ID:        modelsRule.ID,
+		ChatbotID: modelsRule.ChatbotID,
+	}
+
+	if modelsRule.Parameters != nil {
+		var params chatbot.RuleParameters
+		err := json.Unmarshal([]byte(*modelsRule.Parameters), &params)
+		if err != nil {
+			return nil, fmt.Errorf("error un-marshaling chatbot rule parameters from json: %v", err)
+		}
+
+		rule.Parameters = &params
+	}
+
+	rule.Running = a.chatbot.Running(*rule.ChatbotID, *rule.ID)
+
+	switch {
+	case rule.Parameters.Message != nil && rule.Parameters.Message.FromFile != nil:
+		rule.Display = filepath.Base(rule.Parameters.Message.FromFile.Filepath)
+	case rule.Parameters.Message != nil:
+		rule.Display = rule.Parameters.Message.FromText
+	case rule.Parameters.Obs != nil:
+		rule.Display = rule.Parameters.Obs.String()
+	}
+
+	return &rule, nil
+}
+
+func (a *App) DeleteChatbotRule(rule *chatbot.Rule) error {
+	if rule == nil || rule.ID == nil || rule.ChatbotID == nil {
+		return fmt.Errorf("Invalid chatbot rule. Try again.")
+	}
+
+	mRule, err := rule.ToModelsChatbotRule()
 	if err != nil {
-		return nil, fmt.Errorf("error querying all account channels: %v", err)
+		a.logError.Println("error converting chatbot.Rule into models.ChatbotRule:", err)
+		return fmt.Errorf("Error deleting chatbot rule. Try again.")
 	}
 
-	for _, ac := range accountChannels {
-		if ac.Account.Username == nil {
-			return nil, fmt.Errorf("account-channel contains nil account username")
+	err = a.chatbot.Stop(rule)
+	if err != nil {
+		a.logError.Println("error stopping chatbot rule:", err)
+		return fmt.Errorf("Error deleting chatbot rule. Try again.")
+	}
+
+	err = a.services.ChatbotRuleS.Delete(mRule)
+	if err != nil {
+		a.logError.Println("error deleting chatbot rule:", err)
+		return fmt.Errorf("Error deleting chatbot rule. Try again.")
+	}
+
+	rules, err := a.chatbotRules(*rule.ChatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot rules:", err)
+		return fmt.Errorf("Error deleting chatbot rule. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "ChatbotRules", rules)
+
+	return nil
+}
+
+func (a *App) NewChatbotRule(rule *chatbot.Rule) error {
+	if rule == nil || rule.ChatbotID == nil || rule.Parameters == nil {
+		return fmt.Errorf("Invalid chatbot rule. Try again.")
+	}
+
+	mRule, err := rule.ToModelsChatbotRule()
+	if err != nil {
+		a.logError.Println("error converting chatbot.Rule into models.ChatbotRule:", err)
+		return fmt.Errorf("Error creating chatbot rule. Try again.")
+	}
+
+	_, err = a.services.ChatbotRuleS.Create(mRule)
+	if err != nil {
+		a.logError.Println("error creating chatbot rule:", err)
+		return fmt.Errorf("Error creating chatbot rule. Try again.")
+	}
+
+	rules, err := a.chatbotRules(*rule.ChatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot rules:", err)
+		return fmt.Errorf("Error creating chatbot rule. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "ChatbotRules", rules)
+
+	return nil
+}
+
+func (a *App) RunChatbotRule(rule *chatbot.Rule) error {
+	if rule == nil || rule.ChatbotID == nil {
+		return fmt.Errorf("Invalid chatbot rule. Try again.")
+	}
+
+	mChatbot, err := a.services.ChatbotS.ByID(*rule.ChatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot by ID:", err)
+		return fmt.Errorf("Error running chatbot rule. Try again.")
+	}
+	if mChatbot == nil {
+		return fmt.Errorf("Chatbot does not exist. Try again.")
+	}
+	url := a.chatbotUrl(mChatbot)
+	if url == "" {
+		a.logError.Println("chatbot url and page are not set")
+		return fmt.Errorf("Chatbot url is not set. Update url and try again.")
+	}
+
+	_, err = a.producers.ChatP.Start(url)
+	if err != nil {
+		a.logError.Println("error starting chat producer:", err)
+		// TODO: send error to UI that chatbot URL could not be started
+		//runtime.EventsEmit("Ch")
+		return fmt.Errorf("Error connecting to chat. Try again.")
+	}
+
+	page := rule.Page()
+	if page != nil {
+		switch page.Prefix {
+		case chatbot.PrefixAccount:
+			acct, err := a.services.AccountS.ByUsername(page.Name)
+			if err != nil {
+				a.logError.Println("error getting account by username:", err)
+				return fmt.Errorf("Error getting account to monitor. Check rule and try again.")
+			}
+			if acct == nil {
+				return fmt.Errorf("Account to monitor does not exist. Check rule and try again.")
+			}
+			err = a.startPageApi(acct)
+			if err != nil {
+				a.logError.Println("error starting page api:", err)
+				return fmt.Errorf("Error starting API for account in rule. Try again.")
+			}
+		case chatbot.PrefixChannel:
+			channel, err := a.services.ChannelS.ByName(page.Name)
+			if err != nil {
+				a.logError.Println("error getting channel by name:", err)
+				return fmt.Errorf("Error getting channel to monitor. Check rule and try again.")
+			}
+			if channel == nil {
+				return fmt.Errorf("Channel to monitor does not exist. Check rule and try again.")
+			}
+			err = a.startPageApi(channel)
+			if err != nil {
+				a.logError.Println("error starting page api:", err)
+				return fmt.Errorf("Error starting API for channel in rule. Try again.")
+			}
 		}
+	}
 
-		act, exists := list[*ac.Account.Username]
-		if !exists || act == nil {
-			act = &Account{ac.Account, []models.Channel{}}
-			list[*ac.Account.Username] = act
+	err = a.chatbot.Run(rule, url)
+	if err != nil {
+		a.logError.Println("error running chat bot rule:", err)
+		return fmt.Errorf("Error running chatbot rule. Try again.")
+	}
+
+	if rule.ID != nil {
+		err = a.services.ChatbotRuleS.SetRunning(*rule.ID, true)
+		if err != nil {
+			a.logError.Println("error saving chatbot rule running state:", err)
+		}
+	}
+
+	return nil
+}
+
+func (a *App) StopChatbotRule(rule *chatbot.Rule) error {
+	err := a.chatbot.Stop(rule)
+	if err != nil {
+		a.logError.Println("error stopping chat bot rule:", err)
+		return fmt.Errorf("Error stopping chatbot rule. Try again.")
+	}
+
+	err = a.services.ChatbotRuleS.SetRunning(*rule.ID, false)
+	if err != nil {
+		a.logError.Println("error saving chatbot rule running state:", err)
+	}
+
+	return nil
+}
+
+// restoreChatbotRules runs the rules that were left running when the app last closed.
+func (a *App) restoreChatbotRules() error {
+	modelsRules, err := a.services.ChatbotRuleS.Running()
+	if err != nil {
+		return fmt.Errorf("error querying running chatbot rules: %v", err)
+	}
+
+	for _, modelsRule := range modelsRules {
+		rule, err := a.chatbotRule(modelsRule)
+		if err != nil {
+			a.logError.Println("error converting chatbot rule to restore:", err)
+			continue
+		}
+
+		runtime.EventsEmit(a.wails, "StartupMessage", fmt.Sprintf("Restoring chatbot rule: %s...", rule.Display))
+		err = a.RunChatbotRule(rule)
+		if err != nil {
+			runtime.EventsEmit(a.wails, "StartupMessage", fmt.Sprintf("Restoring chatbot rule failed: %s", rule.Display))
+			continue
+		}
+		runtime.EventsEmit(a.wails, "StartupMessage", fmt.Sprintf("Restoring chatbot rule complete: %s", rule.Display))
+	}
+
+	return nil
+}
+
+func (a *App) ChatbotRuleStates() ([]chatbot.RunnerStatus, error) {
+	if a.chatbot == nil {
+		return nil, fmt.Errorf("Chatbot is not initialized. Try restarting.")
+	}
+
+	return a.chatbot.States(), nil
+}
+
+func (a *App) UpdateChatbotRule(rule *chatbot.Rule) error {
+	if rule == nil || rule.ID == nil || rule.ChatbotID == nil {
+		return fmt.Errorf("Invalid chatbot rule. Try again.")
+	}
+
+	mRule, err := rule.ToModelsChatbotRule()
+	if err != nil {
+		a.logError.Println("error converting chatbot.Rule into models.ChatbotRule:", err)
+		return fmt.Errorf("Error updating chatbot rule. Try again.")
+	}
+
+	err = a.StopChatbotRule(rule)
+	if err != nil {
+		return fmt.Errorf("Error updating chatbot rule. Try again.")
+	}
+
+	err = a.services.ChatbotRuleS.Update(mRule)
+	if err != nil {
+		a.logError.Println("error updating chatbot rule:", err)
+		return fmt.Errorf("Error updating chatbot rule. Try again.")
+	}
+
+	rules, err := a.chatbotRules(*rule.ChatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot rules:", err)
+		return fmt.Errorf("Error updating chatbot rule. Try again.")
+	}
+	runtime.EventsEmit(a.wails, "ChatbotRules", rules)
+
+	return nil
+}
+
+func (a *App) RunChatbotRules(chatbotID *int64) error {
+	if chatbotID == nil {
+		return fmt.Errorf("Invalid chatbot. Try again.")
+	}
+
+	rules, err := a.chatbotRules(*chatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot rules:", err)
+		return fmt.Errorf("Error running chatbot rules. Try again.")
+	}
+
+	var errored bool
+	for _, rule := range rules {
+		if err = a.RunChatbotRule(&rule); err != nil {
+			errored = true
 		}
+	}
+	if errored {
+		return fmt.Errorf("An error occurred while running rules. Check error log for details.")
+	}
+
+	return nil
+}
+
+func (a *App) StopChatbotRules(chatbotID *int64) error {
+	if chatbotID == nil {
+		return fmt.Errorf("Invalid chatbot. Try again.")
+	}
+
+	rules, err := a.chatbotRules(*chatbotID)
+	if err != nil {
+		a.logError.Println("error getting chatbot rules:", err)
+		return fmt.Errorf("Error stopping chatbot rules. Try again.")
+	}
 
-		if ac.Channel.AccountID != nil {
-			act.Channels = append(act.Channels, ac.Channel)
+	var errored bool
+	for _, rule := range rules {
+		if err = a.StopChatbotRule(&rule); err != nil {
+			errored = true
 		}
 	}
+	if errored {
+		return fmt.Errorf("An error occurred while stopping rules. Check error log for details.")
+	}
 
-	return list, nil
+	return nil
 }
 
-func (a *App) OpenAccount(id int64) error {
-	acct, err := a.services.AccountS.ByID(id)
-	if err != nil {
-		a.logError.Println("error querying account by ID:", err)
-		return fmt.Errorf("Error opening account. Try again.")
+type ChatHistory struct {
+	Livestream string               `json:"livestream"`
+	Messages   []models.ChatMessage `json:"messages"`
+	Page       int                  `json:"page"`
+	PageSize   int                  `json:"page_size"`
+	Total      int64                `json:"total"`
+}
+
+// ChatHistory returns one page of a livestream's stored chat, oldest first.
+// Pages start at zero.
+func (a *App) ChatHistory(livestream string, page int, pageSize int) (*ChatHistory, error) {
+	if livestream == "" {
+		return nil, fmt.Errorf("Invalid livestream. Try again.")
 	}
-	if acct == nil {
-		return fmt.Errorf("Did not find account. Try again.")
+	if page < 0 {
+		page = 0
+	}
+	if pageSize < 1 {
+		pageSize = 100
 	}
 
-	err = a.openDetails(acct)
+	total, err := a.services.ChatMessageS.CountByLivestream(livestream)
 	if err != nil {
-		a.logError.Println("error opening account details:", err)
-		return fmt.Errorf("Error opening account. Try again.")
+		a.logError.Println("error counting chat messages:", err)
+		return nil, fmt.Errorf("Error getting chat history. Try again.")
 	}
 
-	return nil
-}
-
-func (a *App) OpenChannel(id int64) error {
-	channel, err := a.services.ChannelS.ByID(id)
+	messages, err := a.services.ChatMessageS.ByLivestream(livestream, page*pageSize, pageSize)
 	if err != nil {
-		a.logError.Println("error querying channel by ID:", err)
-		return fmt.Errorf("Error opening channel. Try again.")
-	}
-	if channel == nil {
-		return fmt.Errorf("Did not find channel. Try again.")
+		a.logError.Println("error getting chat messages by livestream:", err)
+		return nil, fmt.Errorf("Error getting chat history. Try again.")
 	}
 
-	err = a.openDetails(channel)
+	return &ChatHistory{
+		Livestream: livestream,
+		Messages:   messages,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+	}, nil
+}
+
+// ChatHistoryLivestreams returns every livestream with stored chat, most recent first.
+func (a *App) ChatHistoryLivestreams() ([]string, error) {
+	livestreams, err := a.services.ChatMessageS.Livestreams()
 	if err != nil {
-		a.logError.Println("error opening channel details:", err)
-		return fmt.Errorf("Error opening channel. Try again.")
+		a.logError.Println("error getting chat history livestreams:", err)
+		return nil, fmt.Errorf("Error getting chat history. Try again.")
 	}
 
-	return nil
+	return livestreams, nil
 }
 
-type PageInfo interface {
-	Id() *int64
-	KeyUrl() *string
-	LoggedIn() bool
-	String() *string
-	Title() *string
-	Type() string
+type ChatSearch struct {
+	Page     int                        `json:"page"`
+	PageSize int                        `json:"page_size"`
+	Results  []models.ChatMessageResult `json:"results"`
+	Total    int64                      `json:"total"`
 }
 
-type PageDetails struct {
-	ID       int64  `json:"id"`
-	HasApi   bool   `json:"has_api"`
-	LoggedIn bool   `json:"logged_in"`
-	Title    string `json:"title"`
-	Type     string `json:"type"`
-}
+// SearchChat returns one page of the stored chat messages matching search, best matches first.
+// Pages start at zero.
+func (a *App) SearchChat(search models.ChatMessageSearch, page int, pageSize int) (*ChatSearch, error) {
+	if page < 0 {
+		page = 0
+	}
+	if pageSize < 1 {
+		pageSize = 100
+	}
+	search.Offset = page * pageSize
+	search.Limit = pageSize
 
-func (a *App) openDetails(pi PageInfo) error {
-	id := pi.Id()
-	if id == nil {
-		return fmt.Errorf("page id is nil")
+	results, total, err := a.services.ChatMessageS.Search(search)
+	if err != nil {
+		a.logError.Println("error searching chat messages:", err)
+		return nil, fmt.Errorf("Error searching chat. Try again.")
 	}
 
-	hasApi := true
-	key := pi.KeyUrl()
-	if key == nil || *key == "" {
-		hasApi = false
+	return &ChatSearch{
+		Page:     page,
+		PageSize: pageSize,
+		Results:  results,
+		Total:    total,
+	}, nil
+}
+
+// ExportChat saves a livestream's stored chat in the given format to a file chosen with a save dialog.
+// Subtitle formats are timed against the start of the livestream.
+// It returns the saved file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportChat(livestream string, format string) (string, error) {
+	exportFormat := chatexport.Format(format)
+	if !exportFormat.Valid() {
+		return "", fmt.Errorf("Invalid export format. Try again.")
 	}
 
-	name := pi.String()
-	if name == nil {
-		return fmt.Errorf("page name is nil")
+	messages, err := a.services.ChatMessageS.ByLivestream(livestream, 0, -1)
+	if err != nil {
+		a.logError.Println("error getting chat messages by livestream:", err)
+		return "", fmt.Errorf("Error exporting chat. Try again.")
+	}
+	if len(messages) == 0 {
+		return "", fmt.Errorf("No chat to export.")
 	}
 
-	title := pi.Title()
-	if title == nil {
-		return fmt.Errorf("page title is nil")
+	home, err := os.UserHomeDir()
+	if err != nil {
+		a.logError.Println("error getting home directory:", err)
+		return "", fmt.Errorf("Error opening file explorer. Try again.")
 	}
 
-	runtime.EventsEmit(a.wails, "PageDetails", PageDetails{
-		ID:       *id,
-		HasApi:   hasApi,
-		LoggedIn: pi.LoggedIn(),
-		Title:    *title,
-		Type:     pi.Type(),
+	filename := "chat-" + filepath.Base(strings.TrimSuffix(livestream, "/")) + exportFormat.Extension()
+	savePath, err := runtime.SaveFileDialog(a.wails, runtime.SaveDialogOptions{
+		DefaultDirectory: home,
+		DefaultFilename:  filename,
+		Filters: []runtime.FileFilter{
+			{DisplayName: exportFormat.Name(), Pattern: "*" + exportFormat.Extension()},
+		},
 	})
-
-	err := a.display(*name)
 	if err != nil {
-		return fmt.Errorf("error displaying page: %v", err)
+		a.logError.Println("error opening save file dialog:", err)
+		return "", fmt.Errorf("Error opening file explorer. Try again.")
+	}
+	if savePath == "" {
+		return "", nil
 	}
 
-	return nil
-}
+	f, err := os.Create(savePath)
+	if err != nil {
+		a.logError.Println("error creating export file:", err)
+		return "", fmt.Errorf("Error exporting chat. Try again.")
+	}
+	defer f.Close()
 
-func (a *App) ActivateAccount(id int64) error {
-	acct, err := a.services.AccountS.ByID(id)
+	changes, err := a.services.StreamChangeS.ByLivestream(livestream)
 	if err != nil {
-		a.logError.Println("error querying account by ID:", err)
-		return fmt.Errorf("Error activating account. Try again.")
+		a.logError.Println("error querying stream changes by livestream:", err)
+		return "", fmt.Errorf("Error exporting chat. Try again.")
 	}
-	if acct == nil {
-		return fmt.Errorf("Did not find account. Try again.")
+
+	records := chatexport.Records(messages, changes, a.livestreamStart(livestream, messages))
+	err = chatexport.Write(f, exportFormat, records)
+	if err != nil {
+		a.logError.Println("error writing chat export:", err)
+		return "", fmt.Errorf("Error exporting chat. Try again.")
 	}
 
-	err = a.activatePage(acct)
+	err = f.Close()
 	if err != nil {
-		a.logError.Println("error activating account:", err)
-		return fmt.Errorf("Error activating account. Try again.")
+		a.logError.Println("error closing export file:", err)
+		return "", fmt.Errorf("Error exporting chat. Try again.")
 	}
 
-	return nil
+	return savePath, nil
 }
 
-// ActivateChannel activates an inactivate page and deactivates an active page.
-func (a *App) ActivateChannel(id int64) error {
-	channel, err := a.services.ChannelS.ByID(id)
-	if err != nil {
-		a.logError.Println("error querying channel by ID:", err)
-		return fmt.Errorf("Error activating channel. Try again.")
-	}
-	if channel == nil {
-		return fmt.Errorf("Did not find channel. Try again.")
+// livestreamStart returns when the livestream started, as reported by the API of an open page.
+// If no page knows the livestream, the start saved in its session report or stream statistics is used,
+// and otherwise the time of the first chat message.
+func (a *App) livestreamStart(livestream string, messages []models.ChatMessage) time.Time {
+	start, ok := a.pageLivestreamStart(livestream)
+	if ok {
+		return start
 	}
 
-	err = a.activatePage(channel)
+	report, err := a.services.SessionReportS.ByLivestream(livestream)
 	if err != nil {
-		a.logError.Println("error activating channel:", err)
-		return fmt.Errorf("Error activating channel. Try again.")
+		a.logError.Println("error querying session report by livestream:", err)
+	}
+	if report != nil && report.Start != nil {
+		return *report.Start
 	}
 
-	return nil
-}
-
-func (a *App) startPageApi(pi PageInfo) error {
-	name := pi.String()
-	if name == nil {
-		return fmt.Errorf("page name is nil")
+	stats, err := a.services.StreamStatS.Summary(livestream)
+	if err != nil {
+		a.logError.Println("error summarizing stream stats:", err)
 	}
-	url := pi.KeyUrl()
-	if url == nil {
-		return fmt.Errorf("page key url is nil")
+	if stats != nil {
+		return stats.Start
 	}
 
-	if !a.producers.ApiP.Active(*name) {
-		err := a.producers.ApiP.Start(*name, *url, 10*time.Second)
-		if err != nil {
-			return fmt.Errorf("error starting api: %v", err)
+	for _, message := range messages {
+		if message.Time != nil {
+			return *message.Time
 		}
-		runtime.EventsEmit(a.wails, "ApiActive-"+*name, true)
 	}
 
-	return nil
+	return time.Time{}
 }
 
-// If page is inactivate, activate.
-// If page is active, deactivate.
-func (a *App) activatePage(pi PageInfo) error {
-	name := pi.String()
-	if name == nil {
-		return fmt.Errorf("page name is nil")
-	}
-
+// pageLivestreamStart returns when the livestream started, if it is in an open page's latest API response.
+func (a *App) pageLivestreamStart(livestream string) (time.Time, bool) {
 	a.pagesMu.Lock()
-	page, exists := a.pages[*name]
-	if !exists {
-		page = &Page{
-			active: false,
-			apiSt:  &ApiState{},
-			name:   *name,
+	defer a.pagesMu.Unlock()
+
+	for _, page := range a.pages {
+		page.apiSt.respMu.Lock()
+		resp := page.apiSt.resp
+		page.apiSt.respMu.Unlock()
+		if resp == nil {
+			continue
 		}
-		a.pages[*name] = page
-	}
-	a.pagesMu.Unlock()
 
-	page.activeMu.Lock()
-	defer page.activeMu.Unlock()
-	if page.active {
-		if a.producers.ApiP.Active(*name) {
-			err := a.producers.ApiP.Stop(*name)
+		for i, ls := range resp.Livestreams {
+			if livestreamUrl(ls) != livestream && !(i == 0 && page.staticLiveStreamUrl() == livestream) {
+				continue
+			}
+
+			createdOn, err := events.ParseTime(ls.CreatedOn)
 			if err != nil {
-				return fmt.Errorf("error stopping api: %v", err)
+				a.logError.Println("error parsing livestream created on:", err)
+				break
 			}
-		}
 
-		page.displayingMu.Lock()
-		if page.displaying {
-			runtime.EventsEmit(a.wails, "PageActive", false)
+			return createdOn, true
 		}
-		page.displayingMu.Unlock()
-
-		page.active = false
-		return nil
 	}
-	page.active = true
 
-	url := pi.KeyUrl()
-	if url == nil {
-		return fmt.Errorf("page key url is nil")
-	}
+	return time.Time{}, false
+}
 
-	err := a.producers.ApiP.Start(*name, *url, 10*time.Second)
-	if err != nil {
-		return fmt.Errorf("error starting api: %v", err)
-	}
-	runtime.EventsEmit(a.wails, "ApiActive-"+*name, true)
+type ViewerList struct {
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+	Total    int64           `json:"total"`
+	Viewers  []models.Viewer `json:"viewers"`
+}
 
-	err = a.display(*name)
+// Viewer returns the viewer with the username, or nil if the viewer has not been seen in chat.
+func (a *App) Viewer(username string) (*models.Viewer, error) {
+	viewer, err := a.services.ViewerS.ByUsername(username)
 	if err != nil {
-		return fmt.Errorf("error displaying page: %v", err)
+		a.logError.Println("error getting viewer by username:", err)
+		return nil, fmt.Errorf("Error getting viewer. Try again.")
 	}
-	runtime.EventsEmit(a.wails, "PageActive", true)
 
-	return nil
+	return viewer, nil
 }
 
-func (a *App) deletePage(pi PageInfo) error {
-	name := pi.String()
-	if name == nil {
-		return fmt.Errorf("page name is nil")
+// Viewers returns one page of the viewers matching query.
+// Pages start at zero.
+func (a *App) Viewers(query models.ViewerQuery, page int, pageSize int) (*ViewerList, error) {
+	if page < 0 {
+		page = 0
 	}
-
-	a.pagesMu.Lock()
-	defer a.pagesMu.Unlock()
-	page, exists := a.pages[*name]
-	if !exists {
-		return nil
+	if pageSize < 1 {
+		pageSize = 100
 	}
+	query.Offset = page * pageSize
+	query.Limit = pageSize
 
-	page.activeMu.Lock()
-	defer page.activeMu.Unlock()
-	if page.active {
-		if a.producers.ApiP.Active(*name) {
-			err := a.producers.ApiP.Stop(*name)
-			if err != nil {
-				return fmt.Errorf("error stopping api: %v", err)
-			}
-		}
-
-		page.displayingMu.Lock()
-		if page.displaying {
-			runtime.EventsEmit(a.wails, "PageActive", false)
-		}
-		page.displayingMu.Unlock()
-
-		page.active = false
+	viewers, total, err := a.services.ViewerS.List(query)
+	if err != nil {
+		a.logError.Println("error listing viewers:", err)
+		return nil, fmt.Errorf("Error getting viewers. Try again.")
 	}
 
-	delete(a.pages, *name)
-
-	return nil
+	return &ViewerList{
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+		Viewers:  viewers,
+	}, nil
 }
 
-func (a *App) DeleteAccount(id int64) error {
-	acct, err := a.services.AccountS.ByID(id)
+// StreamStats returns a livestream's statistics over time, oldest first.
+func (a *App) StreamStats(livestream string) ([]models.StreamStat, error) {
+	stats, err := a.services.StreamStatS.ByLivestream(livestream)
 	if err != nil {
-		a.logError.Println("error querying account by ID:", err)
-		return fmt.Errorf("Error deleting account. Try again.")
-	}
-	if acct == nil {
-		return fmt.Errorf("Did not find account. Try again.")
+		a.logError.Println("error getting stream stats by livestream:", err)
+		return nil, fmt.Errorf("Error getting stream statistics. Try again.")
 	}
 
-	channels, err := a.services.ChannelS.ByAccount(acct)
+	return stats, nil
+}
+
+// StreamStatsLivestreams returns every livestream with statistics, most recent first.
+func (a *App) StreamStatsLivestreams() ([]string, error) {
+	livestreams, err := a.services.StreamStatS.Livestreams()
 	if err != nil {
-		a.logError.Println("error querying channels by account:", err)
-		return fmt.Errorf("Error deleting account. Try again.")
-	}
-	if len(channels) != 0 {
-		return fmt.Errorf("You must delete all channels associated with the account before it can be deleted.")
+		a.logError.Println("error getting stream stats livestreams:", err)
+		return nil, fmt.Errorf("Error getting stream statistics. Try again.")
 	}
 
-	name := acct.String()
-	if name == nil {
-		a.logError.Println("account name is nil")
-		return fmt.Errorf("Error deleting account. Try again.")
-	}
+	return livestreams, nil
+}
 
-	err = a.deletePage(acct)
+// StreamStatsSummary returns a livestream's peak and average viewers, like ratio and follower growth.
+func (a *App) StreamStatsSummary(livestream string) (*models.StreamStatSummary, error) {
+	summary, err := a.services.StreamStatS.Summary(livestream)
 	if err != nil {
-		a.logError.Println("error deleting page:", err)
-		return fmt.Errorf("Error deleting account. Try again.")
+		a.logError.Println("error getting stream stats summary:", err)
+		return nil, fmt.Errorf("Error getting stream statistics. Try again.")
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("No statistics for livestream.")
 	}
 
-	err = a.services.AccountS.Delete(acct)
+	return summary, nil
+}
+
+// StreamChanges returns the livestream's title and category changes, oldest first.
+func (a *App) StreamChanges(livestream string) ([]models.StreamChange, error) {
+	changes, err := a.services.StreamChangeS.ByLivestream(livestream)
 	if err != nil {
-		a.logError.Println("error deleting account:", err)
-		return fmt.Errorf("Error deleting account. Try again.")
+		a.logError.Println("error getting stream changes by livestream:", err)
+		return nil, fmt.Errorf("Error getting stream changes. Try again.")
 	}
 
-	runtime.EventsEmit(a.wails, "PageDetails", nil)
+	return changes, nil
+}
 
-	list, err := a.accountList()
+// SessionReports returns every saved session report without its body, most recent first.
+func (a *App) SessionReports() ([]models.SessionReport, error) {
+	reports, err := a.services.SessionReportS.All()
 	if err != nil {
-		a.logError.Println("error getting account list:", err)
-		return fmt.Errorf("Error deleting account. Try again.")
+		a.logError.Println("error getting all session reports:", err)
+		return nil, fmt.Errorf("Error getting session reports. Try again.")
 	}
-	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
 
-	return nil
+	return reports, nil
 }
 
-func (a *App) DeleteChannel(id int64) error {
-	channel, err := a.services.ChannelS.ByID(id)
+// SessionReport returns the saved session report.
+func (a *App) SessionReport(id int64) (*report.Report, error) {
+	r, err := a.sessionReport(id)
 	if err != nil {
-		a.logError.Println("error querying channel by ID:", err)
-		return fmt.Errorf("Error deleting channel. Try again.")
-	}
-	if channel == nil {
-		return fmt.Errorf("Did not find channel. Try again.")
+		a.logError.Println("error getting session report:", err)
+		return nil, fmt.Errorf("Error getting session report. Try again.")
 	}
 
-	name := channel.String()
-	if name == nil {
-		a.logError.Println("channel name is nil")
-		return fmt.Errorf("Error deleting channel. Try again.")
-	}
+	return r, nil
+}
 
-	err = a.deletePage(channel)
+func (a *App) sessionReport(id int64) (*report.Report, error) {
+	sr, err := a.services.SessionReportS.ByID(id)
 	if err != nil {
-		a.logError.Println("error deleting page:", err)
-		return fmt.Errorf("Error deleting channel. Try again.")
+		return nil, fmt.Errorf("error querying session report by ID: %v", err)
+	}
+	if sr == nil || sr.Report == nil {
+		return nil, fmt.Errorf("session report does not exist: %d", id)
 	}
 
-	err = a.services.ChannelS.Delete(channel)
+	var r report.Report
+	err = json.Unmarshal([]byte(*sr.Report), &r)
 	if err != nil {
-		a.logError.Println("error deleting channel:", err)
-		return fmt.Errorf("Error deleting channel. Try again.")
+		return nil, fmt.Errorf("error decoding session report: %v", err)
 	}
 
-	runtime.EventsEmit(a.wails, "PageDetails", nil)
+	return &r, nil
+}
 
-	list, err := a.accountList()
+func (a *App) DeleteSessionReport(id int64) error {
+	err := a.services.SessionReportS.Delete(&models.SessionReport{ID: &id})
 	if err != nil {
-		a.logError.Println("error getting account list:", err)
-		return fmt.Errorf("Error deleting channel. Try again.")
+		a.logError.Println("error deleting session report:", err)
+		return fmt.Errorf("Error deleting session report. Try again.")
 	}
-	runtime.EventsEmit(a.wails, "PageSideBarAccounts", list)
 
 	return nil
 }
 
-func (a *App) display(name string) error {
-	a.displayingMu.Lock()
-	defer a.displayingMu.Unlock()
+// ExportSessionReport saves the session report as Markdown or HTML to a file chosen with a save dialog.
+// It returns the saved file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportSessionReport(id int64, format string) (string, error) {
+	reportFormat := report.Format(format)
+	if !reportFormat.Valid() {
+		return "", fmt.Errorf("Invalid export format. Try again.")
+	}
 
-	if name == a.displaying {
-		return nil
+	r, err := a.sessionReport(id)
+	if err != nil {
+		a.logError.Println("error getting session report:", err)
+		return "", fmt.Errorf("Error exporting session report. Try again.")
 	}
 
-	a.pagesMu.Lock()
-	defer a.pagesMu.Unlock()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		a.logError.Println("error getting home directory:", err)
+		return "", fmt.Errorf("Error opening file explorer. Try again.")
+	}
 
-	if a.displaying != "" {
-		displaying, exists := a.pages[a.displaying]
-		a.displaying = ""
-		if !exists {
-			return fmt.Errorf("displaying page does not exist: %s", a.display)
-		}
-		displaying.displayingMu.Lock()
-		displaying.displaying = false
-		displaying.displayingMu.Unlock()
+	filename := "session-" + filepath.Base(strings.TrimSuffix(r.Livestream, "/")) + reportFormat.Extension()
+	savePath, err := runtime.SaveFileDialog(a.wails, runtime.SaveDialogOptions{
+		DefaultDirectory: home,
+		DefaultFilename:  filename,
+		Filters: []runtime.FileFilter{
+			{DisplayName: reportFormat.Name(), Pattern: "*" + reportFormat.Extension()},
+		},
+	})
+	if err != nil {
+		a.logError.Println("error opening save file dialog:", err)
+		return "", fmt.Errorf("Error opening file explorer. Try again.")
+	}
+	if savePath == "" {
+		return "", nil
 	}
 
-	page, exists := a.pages[name]
-	if !exists {
-		page = &Page{
-			active: false,
-			apiSt:  &ApiState{},
-			name:   name,
-		}
-		a.pages[name] = page
+	f, err := os.Create(savePath)
+	if err != nil {
+		a.logError.Println("error creating export file:", err)
+		return "", fmt.Errorf("Error exporting session report. Try again.")
 	}
-	page.displayingMu.Lock()
-	page.displaying = true
-	page.displayingMu.Unlock()
+	defer f.Close()
 
-	a.displaying = name
+	err = report.Write(f, reportFormat, r)
+	if err != nil {
+		a.logError.Println("error writing session report export:", err)
+		return "", fmt.Errorf("Error exporting session report. Try again.")
+	}
 
-	err := a.updatePage(page)
+	err = f.Close()
 	if err != nil {
-		return fmt.Errorf("error updating page: %v", err)
+		a.logError.Println("error closing export file:", err)
+		return "", fmt.Errorf("Error exporting session report. Try again.")
 	}
 
-	return nil
+	return savePath, nil
 }
 
-func (a *App) updatePage(p *Page) error {
-	if p == nil {
-		return fmt.Errorf("page is nil")
+// Rants returns the rants sent between from and to, oldest first.
+// A nil bound is unbounded.
+func (a *App) Rants(from *time.Time, to *time.Time) ([]models.Rant, error) {
+	rants, err := a.services.RantS.Between(from, to)
+	if err != nil {
+		a.logError.Println("error getting rants between times:", err)
+		return nil, fmt.Errorf("Error getting rants. Try again.")
 	}
 
-	//TODO check p.api == nil
-
-	p.apiSt.respMu.Lock()
-	defer p.apiSt.respMu.Unlock()
+	return rants, nil
+}
 
-	p.displayingMu.Lock()
-	if p.displaying {
-		runtime.EventsEmit(a.wails, "PageActivity", p.apiSt.resp)
-		p.activeMu.Lock()
-		runtime.EventsEmit(a.wails, "PageActive", p.active)
-		p.activeMu.Unlock()
+// RantTotals returns the rants sent between from and to, totaled per livestream, day, month or supporter.
+func (a *App) RantTotals(by string, from *time.Time, to *time.Time) ([]ledger.Total, error) {
+	rants, err := a.services.RantS.Between(from, to)
+	if err != nil {
+		a.logError.Println("error getting rants between times:", err)
+		return nil, fmt.Errorf("Error getting rant totals. Try again.")
 	}
-	p.displayingMu.Unlock()
 
-	if p.apiSt.resp != nil {
-		isLive := len(p.apiSt.resp.Livestreams) > 0
-		runtime.EventsEmit(a.wails, "PageLive-"+p.name, isLive)
+	totals, err := ledger.Totals(rants, ledger.Period(by))
+	if err != nil {
+		a.logError.Println("error totaling rants:", err)
+		return nil, fmt.Errorf("Error getting rant totals. Try again.")
 	}
 
-	return nil
+	return totals, nil
 }
 
-func (a *App) PageStatus(name string) {
-	active := false
-	isLive := false
-
-	a.pagesMu.Lock()
-	defer a.pagesMu.Unlock()
-	page, exists := a.pages[name]
-	if exists && page.apiSt != nil {
-		page.apiSt.activeMu.Lock()
-		active = page.apiSt.active
-		page.apiSt.activeMu.Unlock()
-
-		page.apiSt.respMu.Lock()
-		if page.apiSt.resp != nil {
-			isLive = len(page.apiSt.resp.Livestreams) > 0
-		}
-		page.apiSt.respMu.Unlock()
+// ExportRants saves the rants sent between from and to as CSV to a file chosen with a save dialog.
+// It returns the saved file path, or an empty string if the dialog was cancelled.
+func (a *App) ExportRants(from *time.Time, to *time.Time) (string, error) {
+	rants, err := a.services.RantS.Between(from, to)
+	if err != nil {
+		a.logError.Println("error getting rants between times:", err)
+		return "", fmt.Errorf("Error exporting rants. Try again.")
 	}
 
-	runtime.EventsEmit(a.wails, "ApiActive-"+name, active)
-	runtime.EventsEmit(a.wails, "PageLive-"+name, isLive)
-}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		a.logError.Println("error getting home directory:", err)
+		return "", fmt.Errorf("Error opening file explorer. Try again.")
+	}
 
-func (a *App) UpdateAccountApi(id int64, apiKey string) error {
-	acct, err := a.services.AccountS.ByID(id)
+	savePath, err := runtime.SaveFileDialog(a.wails, runtime.SaveDialogOptions{
+		DefaultDirectory: home,
+		DefaultFilename:  "rants.csv",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV", Pattern: "*.csv"},
+		},
+	})
 	if err != nil {
-		a.logError.Println("error querying account by ID:", err)
-		return fmt.Errorf("Error updating account. Try again.")
+		a.logError.Println("error opening save file dialog:", err)
+		return "", fmt.Errorf("Error opening file explorer. Try again.")
 	}
-	if acct == nil {
-		return fmt.Errorf("Did not find account. Try again.")
+	if savePath == "" {
+		return "", nil
 	}
 
-	name := acct.String()
-	if name == nil {
-		a.logError.Println("account name is nil")
-		return fmt.Errorf("Error updating account. Try again.")
+	f, err := os.Create(savePath)
+	if err != nil {
+		a.logError.Println("error creating export file:", err)
+		return "", fmt.Errorf("Error exporting rants. Try again.")
 	}
+	defer f.Close()
 
-	if a.producers.ApiP.Active(*name) {
-		err := a.producers.ApiP.Stop(*name)
-		if err != nil {
-			a.logError.Println("error stopping api:", err)
-			return fmt.Errorf("Error updating account. Try again.")
-		}
+	err = ledger.WriteCSV(f, rants)
+	if err != nil {
+		a.logError.Println("error writing rants export:", err)
+		return "", fmt.Errorf("Error exporting rants. Try again.")
 	}
 
-	client := rumblelivestreamlib.Client{ApiKey: apiKey}
-	resp, err := client.Request()
+	err = f.Close()
 	if err != nil {
-		a.logError.Println("error executing api request:", err)
-		return fmt.Errorf("Error querying API. Verify key and try again.")
+		a.logError.Println("error closing export file:", err)
+		return "", fmt.Errorf("Error exporting rants. Try again.")
 	}
 
-	if resp.ChannelName != "" || resp.Username != *acct.Username {
-		return fmt.Errorf("API key does not belong to account. Verify key and try again.")
-	}
+	return savePath, nil
+}
 
-	acct.ApiKey = &apiKey
-	err = a.services.AccountS.Update(acct)
+// ActivityFeed returns the page's recent activity, most recent first.
+func (a *App) ActivityFeed(page string) []activity.Event {
+	return a.activity.Events(page)
+}
+
+// Followers returns the page's follower history, most recent follow first.
+func (a *App) Followers(page string) ([]models.Follower, error) {
+	followers, err := a.services.FollowerS.ByPage(page)
 	if err != nil {
-		a.logError.Println("error updating account:", err)
-		return fmt.Errorf("Error updating account. Try again.")
+		a.logError.Println("error getting followers by page:", err)
+		return nil, fmt.Errorf("Error getting followers. Try again.")
 	}
 
-	return nil
+	return followers, nil
 }
 
-func (a *App) UpdateChannelApi(id int64, apiKey string) error {
-	channel, err := a.services.ChannelS.ByID(id)
+// Subscribers returns the page's subscriber history, most recent subscription first.
+func (a *App) Subscribers(page string) ([]models.Subscriber, error) {
+	subscribers, err := a.services.SubscriberS.ByPage(page)
 	if err != nil {
-		a.logError.Println("error querying channel by ID:", err)
-		return fmt.Errorf("Error updating channel. Try again.")
-	}
-	if channel == nil {
-		return fmt.Errorf("Did not find channel. Try again.")
+		a.logError.Println("error getting subscribers by page:", err)
+		return nil, fmt.Errorf("Error getting subscribers. Try again.")
 	}
 
-	name := channel.String()
-	if name == nil {
-		a.logError.Println("channel name is nil")
-		return fmt.Errorf("Error updating channel. Try again.")
-	}
+	return subscribers, nil
+}
 
-	if a.producers.ApiP.Active(*name) {
-		err := a.producers.ApiP.Stop(*name)
-		if err != nil {
-			a.logError.Println("error stopping api:", err)
-			return fmt.Errorf("Error updating channel. Try again.")
-		}
-	}
+type FollowerCounts struct {
+	Counts    []models.FollowerCount `json:"counts"`
+	Unfollows int64                  `json:"unfollows"`
+}
 
-	client := rumblelivestreamlib.Client{ApiKey: apiKey}
-	resp, err := client.Request()
+// FollowerCounts returns the page's follower counts recorded between from and to,
+// with the estimated number of unfollows over that time.
+func (a *App) FollowerCounts(page string, from time.Time, to time.Time) (*FollowerCounts, error) {
+	counts, err := a.services.FollowerS.Counts(page, from, to)
 	if err != nil {
-		a.logError.Println("error executing api request:", err)
-		return fmt.Errorf("Error querying API. Verify key and try again.")
+		a.logError.Println("error getting follower counts:", err)
+		return nil, fmt.Errorf("Error getting follower counts. Try again.")
 	}
 
-	if resp.ChannelName != *channel.Name {
-		return fmt.Errorf("API key does not belong to channel. Verify key and try again.")
+	return &FollowerCounts{
+		Counts:    counts,
+		Unfollows: audience.EstimateUnfollows(counts),
+	}, nil
+}
+
+func (a *App) OpenFileDialog() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		a.logError.Println("error getting home directory:", err)
+		return "", fmt.Errorf("Error opening file explorer. Try again.")
 	}
 
-	*channel.ApiKey = apiKey
-	err = a.services.ChannelS.Update(channel)
+	filepath, err := runtime.OpenFileDialog(a.wails, runtime.OpenDialogOptions{DefaultDirectory: home})
 	if err != nil {
-		a.logError.Println("error updating channel:", err)
-		return fmt.Errorf("Error updating channel. Try again.")
+		a.logError.Println("error opening file dialog:", err)
+		return "", fmt.Errorf("Error opening file explorer. Try again.")
 	}
 
-	return nil
+	return filepath, err
 }
 
-func (a *App) DeleteChatbot(chatbot *models.Chatbot) error {
-	if chatbot == nil || chatbot.ID == nil {
-		return fmt.Errorf("Invalid chatbot. Try again.")
-	}
+func (a *App) OverlaySettings() (*overlay.Settings, error) {
+	settings := a.overlay.Settings()
+	return &settings, nil
+}
 
-	err := a.StopChatbotRules(chatbot.ID)
+// UpdateOverlaySettings saves the overlay settings and restarts the overlay server if the port changed.
+func (a *App) UpdateOverlaySettings(settings overlay.Settings) error {
+	err := settings.Validate()
 	if err != nil {
-		a.logError.Println("error stopping chatbot rules before deleting chatbot")
-		return fmt.Errorf("Error deleting chatbot. Could not stop running rules. Try Again.")
+		a.logError.Println("error validating overlay settings:", err)
+		return fmt.Errorf("Invalid overlay settings: %v", err)
 	}
 
-	cb, err := a.services.ChatbotS.ByID(*chatbot.ID)
+	path, err := config.OverlaySettings()
 	if err != nil {
-		a.logError.Println("error getting chatbot by ID:", err)
-		return fmt.Errorf("Error deleting chatbot. Try again.")
+		a.logError.Println("error getting overlay settings path:", err)
+		return fmt.Errorf("Error saving overlay settings. Try again.")
 	}
-	if cb == nil {
-		return fmt.Errorf("Chatbot does not exist.")
+
+	err = overlay.SaveSettings(path, settings)
+	if err != nil {
+		a.logError.Println("error saving overlay settings:", err)
+		return fmt.Errorf("Error saving overlay settings. Try again.")
 	}
 
-	rules, err := a.services.ChatbotRuleS.ByChatbotID(*chatbot.ID)
+	restart := a.overlay.Settings().Port != settings.Port
+	err = a.overlay.SetSettings(settings)
 	if err != nil {
-		a.logError.Println("error getting chatbot rules by chatbot ID:", err)
-		return fmt.Errorf("Error deleting chatbot. Try again.")
+		a.logError.Println("error setting overlay settings:", err)
+		return fmt.Errorf("Error saving overlay settings. Try again.")
 	}
 
-	for _, rule := range rules {
-		err = a.services.ChatbotRuleS.Delete(&rule)
+	if restart {
+		err = a.overlay.Stop()
 		if err != nil {
-			a.logError.Println("error deleting chatbot rule:", err)
-			return fmt.Errorf("Error deleting chatbot. Try again.")
+			a.logError.Println("error stopping overlay server:", err)
+		}
+		err = a.overlay.Start()
+		if err != nil {
+			a.logError.Println("error starting overlay server:", err)
+			return fmt.Errorf("Error starting overlay server on port %d. Try another port.", settings.Port)
 		}
 	}
 
-	err = a.services.ChatbotS.Delete(chatbot)
-	if err != nil {
-		a.logError.Println("error deleting chatbot:", err)
-		return fmt.Errorf("Error deleting chatbot. Try again.")
-	}
+	return nil
+}
 
-	list, err := a.chatbotList()
-	if err != nil {
-		a.logError.Println("error getting chatbot list:", err)
-		return fmt.Errorf("Error deleting chatbot. Try again.")
-	}
-	runtime.EventsEmit(a.wails, "ChatbotList", list)
+// OverlayUrl returns the url of the alerts overlay, for adding as a browser source in OBS.
+func (a *App) OverlayUrl() string {
+	return a.overlay.Url() + "/alerts"
+}
 
-	return nil
+// ChatOverlayUrl returns the url of the chat overlay, for adding as a browser source in OBS.
+func (a *App) ChatOverlayUrl() string {
+	return a.overlay.Url() + "/chat"
 }
 
-func (a *App) NewChatbot(chatbot *models.Chatbot) error {
-	if chatbot == nil || chatbot.Name == nil {
-		return fmt.Errorf("Invalid chatbot. Try again.")
+// TestOverlayAlert shows a sample alert of the kind on the alerts overlay.
+func (a *App) TestOverlayAlert(kind string) error {
+	if !overlay.Kind(kind).Valid() {
+		return fmt.Errorf("Invalid alert kind: %s", kind)
 	}
 
-	cb, err := a.services.ChatbotS.ByName(*chatbot.Name)
-	if err != nil {
-		a.logError.Println("error getting chatbot by name:", err)
-		return fmt.Errorf("Error creating chatbot. Try again.")
-	}
-	if cb != nil {
-		return fmt.Errorf("Chatbot name already exists.")
+	amountCents := int64(0)
+	text := ""
+	if overlay.Kind(kind) == overlay.KindRant {
+		amountCents = 500
+		text = "This is a test rant."
 	}
 
-	_, err = a.services.ChatbotS.Create(chatbot)
+	err := a.overlay.Alert(overlay.Kind(kind), "RumGoggles", amountCents, text)
 	if err != nil {
-		a.logError.Println("error creating chatbot:", err)
-		return fmt.Errorf("Error creating chatbot. Try again.")
+		a.logError.Println("error sending test overlay alert:", err)
+		return fmt.Errorf("Error sending test alert. Try again.")
 	}
 
-	list, err := a.chatbotList()
-	if err != nil {
-		a.logError.Println("error getting chatbot list:", err)
-		return fmt.Errorf("Error creating chatbot. Try again.")
+	return nil
+}
+
+func (a *App) ObsSettings() (*obs.Settings, error) {
+	if a.obs == nil {
+		return nil, fmt.Errorf("OBS is unavailable. Try restarting.")
 	}
-	runtime.EventsEmit(a.wails, "ChatbotList", list)
 
-	return nil
+	settings := a.obs.Settings()
+	return &settings, nil
 }
 
-func (a *App) UpdateChatbot(chatbot *models.Chatbot) error {
-	if chatbot == nil || chatbot.ID == nil || chatbot.Name == nil {
-		return fmt.Errorf("Invalid chatbot. Try again.")
+// UpdateObsSettings saves the OBS settings and reconnects to OBS with them.
+func (a *App) UpdateObsSettings(settings obs.Settings) error {
+	if a.obs == nil {
+		return fmt.Errorf("OBS is unavailable. Try restarting.")
 	}
 
-	cb, err := a.services.ChatbotS.ByID(*chatbot.ID)
+	err := settings.Validate()
 	if err != nil {
-		a.logError.Println("error getting chatbot by ID:", err)
-		return fmt.Errorf("Error updating chatbot. Try again.")
-	}
-	if cb == nil {
-		return fmt.Errorf("Chatbot does not exist.")
+		a.logError.Println("error validating OBS settings:", err)
+		return fmt.Errorf("Invalid OBS settings: %v", err)
 	}
 
-	cbByName, err := a.services.ChatbotS.ByName(*chatbot.Name)
+	path, err := config.ObsSettings()
 	if err != nil {
-		a.logError.Println("error getting chatbot by Name:", err)
-		return fmt.Errorf("Error updating chatbot. Try again.")
-	}
-	if cbByName != nil && *cbByName.ID != *cb.ID {
-		return fmt.Errorf("Chatbot name already exists.")
+		a.logError.Println("error getting OBS settings path:", err)
+		return fmt.Errorf("Error saving OBS settings. Try again.")
 	}
 
-	err = a.services.ChatbotS.Update(chatbot)
+	err = obs.SaveSettings(path, settings)
 	if err != nil {
-		a.logError.Println("error updating chatbot:", err)
-		return fmt.Errorf("Error updating chatbot. Try again.")
+		a.logError.Println("error saving OBS settings:", err)
+		return fmt.Errorf("Error saving OBS settings. Try again.")
 	}
 
-	// list, err := a.chatbotList()
-	// if err != nil {
-	// 	a.logError.Println("error getting chatbot list:", err)
-	// 	return fmt.Errorf("Error updating chatbot. Try again.")
-	// }
-	runtime.EventsEmit(a.wails, "ChatbotInfo", chatbot)
-	// runtime.EventsEmit(a.wails, "ChatbotList", list)
+	err = a.obs.SetSettings(settings)
+	if err != nil {
+		a.logError.Println("error setting OBS settings:", err)
+		return fmt.Errorf("Error saving OBS settings. Try again.")
+	}
 
 	return nil
 }
 
-func (a *App) ChatbotList() ([]models.Chatbot, error) {
-	list, err := a.chatbotList()
-	if err != nil {
-		a.logError.Println("error getting chatbot list:", err)
-		return nil, fmt.Errorf("Error retrieving chatbots. Try restarting.")
+// ObsStatus returns the OBS connection status, which is disconnected if OBS is unavailable.
+func (a *App) ObsStatus() obs.Status {
+	if a.obs == nil {
+		return obs.Status{}
 	}
 
-	return list, nil
+	return a.obs.Status()
 }
 
-func (a *App) chatbotList() ([]models.Chatbot, error) {
-	list, err := a.services.ChatbotS.All()
-	if err != nil {
-		return nil, fmt.Errorf("error querying all chatbots: %v", err)
+func (a *App) TextFileSettings() (*textfile.Settings, error) {
+	if a.textFiles == nil {
+		return nil, fmt.Errorf("Text files are unavailable. Try restarting.")
 	}
 
-	return list, err
+	settings := a.textFiles.Settings()
+	return &settings, nil
 }
 
-func (a *App) ChatbotRules(chatbot *models.Chatbot) ([]chatbot.Rule, error) {
-	if chatbot == nil || chatbot.ID == nil {
-		return nil, fmt.Errorf("Invalid chatbot. Try again.")
+func (a *App) DiscordSettings() (*discord.Settings, error) {
+	if a.discord == nil {
+		return nil, fmt.Errorf("Discord notifications are unavailable. Try restarting.")
 	}
 
-	rules, err := a.chatbotRules(*chatbot.ID)
-	if err != nil {
-		a.logError.Println("error getting chatbot rules:", err)
-		return nil, fmt.Errorf("Error getting chatbot rules. Try again.")
-	}
+	settings := a.discord.Settings()
+	return &settings, nil
+}
 
-	return rules, nil
+// DefaultDiscordSink returns the settings of a new Discord sink.
+func (a *App) DefaultDiscordSink() discord.Sink {
+	return discord.DefaultSink()
 }
 
-func (a *App) chatbotRules(chatbotID int64) ([]chatbot.Rule, error) {
-	modelsRules, err := a.services.ChatbotRuleS.ByChatbotID(chatbotID)
-	if err != nil {
-		return nil, fmt.Errorf("error querying chatbot rules: %v", err)
+func (a *App) UpdateDiscordSettings(settings discord.Settings) error {
+	if a.discord == nil {
+		return fmt.Errorf("Discord notifications are unavailable. Try restarting.")
 	}
 
-	rules := []chatbot.Rule{}
-	for _, modelsRule := range modelsRules {
-		rule := chatbot.Rule{
-			ID:        modelsRule.ID,
-			ChatbotID: modelsRule.ChatbotID,
-		}
+	err := settings.Validate()
+	if err != nil {
+		a.logError.Println("error validating Discord settings:", err)
+		return fmt.Errorf("Invalid Discord settings: %v", err)
+	}
 
-		if modelsRule.Parameters != nil {
-			var params chatbot.RuleParameters
-			err = json.Unmarshal([]byte(*modelsRule.Parameters), &params)
-			if err != nil {
-				return nil, fmt.Errorf("error un-marshaling chatbot rule parameters from json: %v", err)
-			}
+	path, err := config.DiscordSettings()
+	if err != nil {
+		a.logError.Println("error getting Discord settings path:", err)
+		return fmt.Errorf("Error saving Discord settings. Try again.")
+	}
 
-			rule.Parameters = &params
-		}
+	err = discord.SaveSettings(path, settings)
+	if err != nil {
+		a.logError.Println("error saving Discord settings:", err)
+		return fmt.Errorf("Error saving Discord settings. Try again.")
+	}
 
-		rule.Running = a.chatbot.Running(*rule.ChatbotID, *rule.ID)
+	err = a.discord.SetSettings(settings)
+	if err != nil {
+		a.logError.Println("error setting Discord settings:", err)
+		return fmt.Errorf("Error saving Discord settings. Try again.")
+	}
 
-		rule.Display = rule.Parameters.Message.FromText
-		if rule.Parameters.Message.FromFile != nil {
-			rule.Display = filepath.Base(rule.Parameters.Message.FromFile.Filepath)
-		}
+	return nil
+}
 
-		rules = append(rules, rule)
+// TestDiscordSink posts a sample go-live notification to the sink.
+func (a *App) TestDiscordSink(sink discord.Sink) error {
+	if a.discord == nil {
+		return fmt.Errorf("Discord notifications are unavailable. Try restarting.")
 	}
 
-	chatbot.SortRules(rules)
+	err := a.discord.Test(sink)
+	if err != nil {
+		a.logError.Println("error testing Discord sink:", err)
+		return fmt.Errorf("Error posting to Discord: %v", err)
+	}
 
-	return rules, err
+	return nil
 }
 
-func (a *App) DeleteChatbotRule(rule *chatbot.Rule) error {
-	if rule == nil || rule.ID == nil || rule.ChatbotID == nil {
-		return fmt.Errorf("Invalid chatbot rule. Try again.")
+// UpdateTextFileSettings saves the text file settings and rewrites the files with them.
+func (a *App) UpdateTextFileSettings(settings textfile.Settings) error {
+	if a.textFiles == nil {
+		return fmt.Errorf("Text files are unavailable. Try restarting.")
 	}
 
-	mRule, err := rule.ToModelsChatbotRule()
+	err := settings.Validate()
 	if err != nil {
-		a.logError.Println("error converting chatbot.Rule into models.ChatbotRule:", err)
-		return fmt.Errorf("Error deleting chatbot rule. Try again.")
+		a.logError.Println("error validating text file settings:", err)
+		return fmt.Errorf("Invalid text file settings: %v", err)
 	}
 
-	err = a.chatbot.Stop(rule)
+	path, err := config.TextFileSettings()
 	if err != nil {
-		a.logError.Println("error stopping chatbot rule:", err)
-		return fmt.Errorf("Error deleting chatbot rule. Try again.")
+		a.logError.Println("error getting text file settings path:", err)
+		return fmt.Errorf("Error saving text file settings. Try again.")
 	}
 
-	err = a.services.ChatbotRuleS.Delete(mRule)
+	err = textfile.SaveSettings(path, settings)
 	if err != nil {
-		a.logError.Println("error deleting chatbot rule:", err)
-		return fmt.Errorf("Error deleting chatbot rule. Try again.")
+		a.logError.Println("error saving text file settings:", err)
+		return fmt.Errorf("Error saving text file settings. Try again.")
 	}
 
-	rules, err := a.chatbotRules(*rule.ChatbotID)
+	err = a.textFiles.SetSettings(settings)
 	if err != nil {
-		a.logError.Println("error getting chatbot rules:", err)
-		return fmt.Errorf("Error deleting chatbot rule. Try again.")
+		a.logError.Println("error setting text file settings:", err)
+		return fmt.Errorf("Error saving text file settings. Try again.")
 	}
-	runtime.EventsEmit(a.wails, "ChatbotRules", rules)
 
 	return nil
 }
 
-func (a *App) NewChatbotRule(rule *chatbot.Rule) error {
-	if rule == nil || rule.ChatbotID == nil || rule.Parameters == nil {
-		return fmt.Errorf("Invalid chatbot rule. Try again.")
+// TextFileDir returns the directory the text files are written to, for pointing streaming software at.
+func (a *App) TextFileDir() (string, error) {
+	if a.textFiles == nil {
+		return "", fmt.Errorf("Text files are unavailable. Try restarting.")
 	}
 
-	mRule, err := rule.ToModelsChatbotRule()
-	if err != nil {
-		a.logError.Println("error converting chatbot.Rule into models.ChatbotRule:", err)
-		return fmt.Errorf("Error creating chatbot rule. Try again.")
+	return a.textFiles.Dir(), nil
+}
+
+func (a *App) Goals(page string) ([]goals.Progress, error) {
+	if a.goals == nil {
+		return nil, fmt.Errorf("Goals are unavailable. Try restarting.")
 	}
 
-	_, err = a.services.ChatbotRuleS.Create(mRule)
+	progress, err := a.goals.Goals(page)
 	if err != nil {
-		a.logError.Println("error creating chatbot rule:", err)
-		return fmt.Errorf("Error creating chatbot rule. Try again.")
+		a.logError.Println("error getting goals:", err)
+		return nil, fmt.Errorf("Error getting goals. Try again.")
 	}
 
-	rules, err := a.chatbotRules(*rule.ChatbotID)
+	return progress, nil
+}
+
+// NewGoal creates a goal for the page that starts with no progress.
+// Milestones default to 25, 50, 75 and 100 percent of the target.
+func (a *App) NewGoal(goal *models.Goal) error {
+	if a.goals == nil {
+		return fmt.Errorf("Goals are unavailable. Try restarting.")
+	}
+	if goal == nil || goal.Page == nil {
+		return fmt.Errorf("Invalid goal. Try again.")
+	}
+
+	_, err := a.goals.Create(goal, time.Now())
 	if err != nil {
-		a.logError.Println("error getting chatbot rules:", err)
-		return fmt.Errorf("Error creating chatbot rule. Try again.")
+		a.logError.Println("error creating goal:", err)
+		return fmt.Errorf("Error creating goal. Check the goal and try again.")
 	}
-	runtime.EventsEmit(a.wails, "ChatbotRules", rules)
+
+	a.refreshGoals(*goal.Page)
 
 	return nil
 }
 
-func (a *App) RunChatbotRule(rule *chatbot.Rule) error {
-	if rule == nil || rule.ChatbotID == nil {
-		return fmt.Errorf("Invalid chatbot rule. Try again.")
+// UpdateGoal saves the goal's title, kind, target, reset and milestones.
+// Changing the kind or reset starts the goal over.
+func (a *App) UpdateGoal(goal *models.Goal) error {
+	if a.goals == nil {
+		return fmt.Errorf("Goals are unavailable. Try restarting.")
+	}
+	if goal == nil || goal.ID == nil {
+		return fmt.Errorf("Invalid goal. Try again.")
 	}
 
-	mChatbot, err := a.services.ChatbotS.ByID(*rule.ChatbotID)
+	err := a.goals.Edit(goal, time.Now())
 	if err != nil {
-		a.logError.Println("error getting chatbot by ID:", err)
-		return fmt.Errorf("Error running chatbot rule. Try again.")
-	}
-	if mChatbot == nil {
-		return fmt.Errorf("Chatbot does not exist. Try again.")
+		a.logError.Println("error updating goal:", err)
+		return fmt.Errorf("Error updating goal. Check the goal and try again.")
 	}
-	if mChatbot.Url == nil {
-		a.logError.Println("chatbot url is nil")
-		return fmt.Errorf("Chatbot url is not set. Update url and try again.")
+
+	a.refreshGoalByID(*goal.ID)
+
+	return nil
+}
+
+func (a *App) DeleteGoal(id int64) error {
+	if a.goals == nil {
+		return fmt.Errorf("Goals are unavailable. Try restarting.")
 	}
 
-	_, err = a.producers.ChatP.Start(*mChatbot.Url)
+	goal, err := a.services.GoalS.ByID(id)
 	if err != nil {
-		a.logError.Println("error starting chat producer:", err)
-		// TODO: send error to UI that chatbot URL could not be started
-		//runtime.EventsEmit("Ch")
-		return fmt.Errorf("Error connecting to chat. Try again.")
+		a.logError.Println("error querying goal by ID:", err)
+		return fmt.Errorf("Error deleting goal. Try again.")
 	}
-
-	page := rule.Page()
-	if page != nil {
-		switch page.Prefix {
-		case chatbot.PrefixAccount:
-			acct, err := a.services.AccountS.ByUsername(page.Name)
-			if err != nil {
-				a.logError.Println("error getting account by username:", err)
-				return fmt.Errorf("Error getting account to monitor. Check rule and try again.")
-			}
-			if acct == nil {
-				return fmt.Errorf("Account to monitor does not exist. Check rule and try again.")
-			}
-			err = a.startPageApi(acct)
-			if err != nil {
-				a.logError.Println("error starting page api:", err)
-				return fmt.Errorf("Error starting API for account in rule. Try again.")
-			}
-		case chatbot.PrefixChannel:
-			channel, err := a.services.ChannelS.ByName(page.Name)
-			if err != nil {
-				a.logError.Println("error getting channel by name:", err)
-				return fmt.Errorf("Error getting channel to monitor. Check rule and try again.")
-			}
-			if channel == nil {
-				return fmt.Errorf("Channel to monitor does not exist. Check rule and try again.")
-			}
-			err = a.startPageApi(channel)
-			if err != nil {
-				a.logError.Println("error starting page api:", err)
-				return fmt.Errorf("Error starting API for channel in rule. Try again.")
-			}
-		}
+	if goal == nil {
+		return fmt.Errorf("Did not find goal. Try again.")
 	}
 
-	err = a.chatbot.Run(rule, *mChatbot.Url)
+	err = a.goals.Delete(id)
 	if err != nil {
-		a.logError.Println("error running chat bot rule:", err)
-		return fmt.Errorf("Error running chatbot rule. Try again.")
+		a.logError.Println("error deleting goal:", err)
+		return fmt.Errorf("Error deleting goal. Try again.")
+	}
+
+	if goal.Page != nil {
+		a.refreshGoals(*goal.Page)
 	}
 
 	return nil
 }
 
-func (a *App) StopChatbotRule(rule *chatbot.Rule) error {
-	err := a.chatbot.Stop(rule)
+// ResetGoal starts the goal over from zero.
+func (a *App) ResetGoal(id int64) error {
+	if a.goals == nil {
+		return fmt.Errorf("Goals are unavailable. Try restarting.")
+	}
+
+	err := a.goals.Reset(id, time.Now())
 	if err != nil {
-		a.logError.Println("error stopping chat bot rule:", err)
-		return fmt.Errorf("Error stopping chatbot rule. Try again.")
+		a.logError.Println("error resetting goal:", err)
+		return fmt.Errorf("Error resetting goal. Try again.")
 	}
 
+	a.refreshGoalByID(id)
+
 	return nil
 }
 
-func (a *App) UpdateChatbotRule(rule *chatbot.Rule) error {
-	if rule == nil || rule.ID == nil || rule.ChatbotID == nil {
-		return fmt.Errorf("Invalid chatbot rule. Try again.")
+func (a *App) refreshGoalByID(id int64) {
+	goal, err := a.services.GoalS.ByID(id)
+	if err != nil {
+		a.logError.Println("error querying goal by ID:", err)
+		return
+	}
+	if goal == nil || goal.Page == nil {
+		return
 	}
 
-	mRule, err := rule.ToModelsChatbotRule()
+	a.refreshGoals(*goal.Page)
+}
+
+// refreshGoals sends the page's goals to the UI and overlays after they were changed by the user.
+func (a *App) refreshGoals(page string) {
+	progress, err := a.goals.Goals(page)
 	if err != nil {
-		a.logError.Println("error converting chatbot.Rule into models.ChatbotRule:", err)
-		return fmt.Errorf("Error updating chatbot rule. Try again.")
+		a.logError.Println("error getting goals:", err)
+		return
 	}
 
-	err = a.chatbot.Stop(rule)
+	a.emitGoals(page, progress)
+}
+
+func (a *App) Webhooks() ([]models.Webhook, error) {
+	webhooks, err := a.services.WebhookS.All()
 	if err != nil {
-		a.logError.Println("error stopping chatbot rule:", err)
-		return fmt.Errorf("Error updating chatbot rule. Try again.")
+		a.logError.Println("error querying webhooks:", err)
+		return nil, fmt.Errorf("Error getting webhooks. Try again.")
 	}
 
-	err = a.services.ChatbotRuleS.Update(mRule)
+	return webhooks, nil
+}
+
+// NewWebhook saves the webhook, generating its secret if it has none.
+func (a *App) NewWebhook(w *models.Webhook) error {
+	if w == nil {
+		return fmt.Errorf("Invalid webhook. Try again.")
+	}
+
+	err := webhook.Normalize(w)
 	if err != nil {
-		a.logError.Println("error updating chatbot rule:", err)
-		return fmt.Errorf("Error updating chatbot rule. Try again.")
+		a.logError.Println("error normalizing webhook:", err)
+		return fmt.Errorf("Invalid webhook. Check the url and events and try again.")
+	}
+	if w.Enabled == nil {
+		enabled := true
+		w.Enabled = &enabled
 	}
+	now := time.Now()
+	w.CreatedAt = &now
 
-	rules, err := a.chatbotRules(*rule.ChatbotID)
+	_, err = a.services.WebhookS.Create(w)
 	if err != nil {
-		a.logError.Println("error getting chatbot rules:", err)
-		return fmt.Errorf("Error updating chatbot rule. Try again.")
+		a.logError.Println("error creating webhook:", err)
+		return fmt.Errorf("Error creating webhook. Check the webhook and try again.")
 	}
-	runtime.EventsEmit(a.wails, "ChatbotRules", rules)
 
 	return nil
 }
 
-func (a *App) RunChatbotRules(chatbotID *int64) error {
-	if chatbotID == nil {
-		return fmt.Errorf("Invalid chatbot. Try again.")
+// UpdateWebhook saves the webhook's name, url, events and enabled state.
+// The secret is kept if the webhook has none.
+func (a *App) UpdateWebhook(w *models.Webhook) error {
+	if w == nil || w.ID == nil {
+		return fmt.Errorf("Invalid webhook. Try again.")
 	}
 
-	rules, err := a.chatbotRules(*chatbotID)
+	existing, err := a.services.WebhookS.ByID(*w.ID)
 	if err != nil {
-		a.logError.Println("error getting chatbot rules:", err)
-		return fmt.Errorf("Error running chatbot rules. Try again.")
+		a.logError.Println("error querying webhook by ID:", err)
+		return fmt.Errorf("Error updating webhook. Try again.")
+	}
+	if existing == nil {
+		return fmt.Errorf("Did not find webhook. Try again.")
 	}
 
-	var errored bool
-	for _, rule := range rules {
-		if err = a.RunChatbotRule(&rule); err != nil {
-			errored = true
-		}
+	if w.Secret == nil || *w.Secret == "" {
+		w.Secret = existing.Secret
 	}
-	if errored {
-		return fmt.Errorf("An error occurred while running rules. Check error log for details.")
+	err = webhook.Normalize(w)
+	if err != nil {
+		a.logError.Println("error normalizing webhook:", err)
+		return fmt.Errorf("Invalid webhook. Check the url and events and try again.")
+	}
+	existing.Name = w.Name
+	existing.Url = w.Url
+	existing.Secret = w.Secret
+	existing.Events = w.Events
+	if w.Enabled != nil {
+		existing.Enabled = w.Enabled
+	}
+
+	err = a.services.WebhookS.Update(existing)
+	if err != nil {
+		a.logError.Println("error updating webhook:", err)
+		return fmt.Errorf("Error updating webhook. Check the webhook and try again.")
 	}
 
 	return nil
 }
 
-func (a *App) StopChatbotRules(chatbotID *int64) error {
-	if chatbotID == nil {
-		return fmt.Errorf("Invalid chatbot. Try again.")
+// DeleteWebhook deletes the webhook and its delivery log.
+func (a *App) DeleteWebhook(id int64) error {
+	err := a.services.WebhookS.Delete(&models.Webhook{ID: &id})
+	if err != nil {
+		a.logError.Println("error deleting webhook:", err)
+		return fmt.Errorf("Error deleting webhook. Try again.")
 	}
 
-	rules, err := a.chatbotRules(*chatbotID)
+	err = a.services.WebhookDeliveryS.DeleteByWebhook(id)
 	if err != nil {
-		a.logError.Println("error getting chatbot rules:", err)
-		return fmt.Errorf("Error stopping chatbot rules. Try again.")
+		a.logError.Println("error deleting webhook deliveries:", err)
 	}
 
-	var errored bool
-	for _, rule := range rules {
-		if err = a.StopChatbotRule(&rule); err != nil {
-			errored = true
-		}
+	return nil
+}
+
+// TestWebhook sends a test event to the webhook.
+// The result shows up in the webhook's deliveries.
+func (a *App) TestWebhook(id int64) error {
+	if a.webhooks == nil {
+		return fmt.Errorf("Webhooks are unavailable. Try restarting.")
 	}
-	if errored {
-		return fmt.Errorf("An error occurred while stopping rules. Check error log for details.")
+
+	err := a.webhooks.Test(id)
+	if err != nil {
+		a.logError.Println("error testing webhook:", err)
+		return fmt.Errorf("Error testing webhook. Try again.")
 	}
 
 	return nil
 }
 
-func (a *App) OpenFileDialog() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		a.logError.Println("error getting home directory:", err)
-		return "", fmt.Errorf("Error opening file explorer. Try again.")
-	}
+// webhookDeliveriesLimit is how many of a webhook's most recent deliveries are shown.
+const webhookDeliveriesLimit = 100
 
-	filepath, err := runtime.OpenFileDialog(a.wails, runtime.OpenDialogOptions{DefaultDirectory: home})
+// WebhookDeliveries returns the webhook's most recent deliveries, most recent first.
+func (a *App) WebhookDeliveries(id int64) ([]models.WebhookDelivery, error) {
+	deliveries, err := a.services.WebhookDeliveryS.ByWebhook(id, webhookDeliveriesLimit)
 	if err != nil {
-		a.logError.Println("error opening file dialog:", err)
-		return "", fmt.Errorf("Error opening file explorer. Try again.")
+		a.logError.Println("error querying webhook deliveries:", err)
+		return nil, fmt.Errorf("Error getting webhook deliveries. Try again.")
 	}
 
-	return filepath, err
+	return deliveries, nil
 }
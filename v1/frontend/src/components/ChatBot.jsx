@@ -2,6 +2,7 @@ import { useEffect, useState } from 'react';
 import { Modal, SmallModal } from './Modal';
 import {
     AccountList,
+    ChatbotArmed,
     ChatbotList,
     ChatbotRules,
     DeleteChatbot,
@@ -50,6 +51,7 @@ function ChatBot(props) {
             if (openChatbot !== null && openChatbot.id === event.id) {
                 openChatbot.name = event.name;
                 openChatbot.url = event.url;
+                openChatbot.page = event.page;
                 setRefresh(!refresh);
             }
         });
@@ -234,6 +236,7 @@ function ChatBot(props) {
                                 />
                             </div>
                             <span className='chatbot-header-title'>{openChatbot.name}</span>
+                            <ChatbotArmedBadge chatbot={openChatbot} />
                             <div className='chatbot-header-right'>
                                 <button
                                     className='chatbot-header-button'
@@ -291,11 +294,49 @@ function ChatbotListItem(props) {
                 onClick={() => props.onClick(props.chatbot)}
             >
                 <span className='chatbot-list-item-name'>{props.chatbot.name}</span>
+                <ChatbotArmedBadge chatbot={props.chatbot} />
             </button>
         </div>
     );
 }
 
+// ChatbotArmedBadge shows when the chatbot is waiting for its linked page to go live.
+function ChatbotArmedBadge(props) {
+    const [armed, setArmed] = useState(false);
+
+    useEffect(() => {
+        const event = 'ChatbotArmed-' + props.chatbot.id;
+        EventsOn(event, (armed) => {
+            setArmed(armed);
+        });
+
+        ChatbotArmed(props.chatbot.id)
+            .then((response) => {
+                setArmed(response);
+            })
+            .catch((error) => {
+                console.log('Error getting chatbot armed state:', error);
+            });
+
+        return () => {
+            EventsOff(event);
+        };
+    }, [props.chatbot.id]);
+
+    if (!armed) {
+        return null;
+    }
+
+    return (
+        <span
+            className='chatbot-armed'
+            title={'Waiting for ' + props.chatbot.page + ' to go live'}
+        >
+            Armed
+        </span>
+    );
+}
+
 function ChatbotRule(props) {
     const [ruleActive, setRuleActive] = useState(props.rule.running);
     const updateRuleActive = (active) => {
@@ -489,11 +530,20 @@ function ModalChatbot(props) {
         }
         setUrl(event.target.value);
     };
+    const [page, setPage] = useState(
+        props.chatbot === undefined || !props.chatbot.page ? '' : props.chatbot.page
+    );
+    const updatePage = (event) => {
+        if (loading) {
+            return;
+        }
+        setPage(event.target.value);
+    };
 
     useEffect(() => {
         if (loading) {
             props
-                .submit({ id: id, name: name, url: url })
+                .submit({ id: id, name: name, url: url, page: page === '' ? null : page })
                 .then(() => {
                     reset();
                     props.onClose();
@@ -550,7 +600,7 @@ function ModalChatbot(props) {
                 deleteButton={props.deleteButton}
                 onDelete={props.onDelete}
                 show={props.show}
-                style={{ minWidth: '400px', maxWidth: '400px', maxHeight: '400px' }}
+                style={{ minWidth: '400px', maxWidth: '400px', maxHeight: '460px' }}
                 submitButton={loading ? props.submittingButton : props.submitButton}
                 onSubmit={submit}
                 title={props.title}
@@ -578,6 +628,14 @@ function ModalChatbot(props) {
                         type={'text'}
                         value={url}
                     ></input>
+                    <label className='chatbot-modal-label'>Linked Page</label>
+                    <input
+                        className='chatbot-modal-input'
+                        onChange={updatePage}
+                        placeholder={'/c/ChannelName or /user/Username'}
+                        type={'text'}
+                        value={page}
+                    ></input>
                 </div>
             </Modal>
         </>
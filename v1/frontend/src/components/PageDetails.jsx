@@ -25,6 +25,8 @@ import {
     UpdateChannelApi,
 } from '../../wailsjs/go/main/App';
 import { Modal, SmallModal } from './Modal';
+import { ModalSessionReports } from './SessionReports';
+import { ModalWebhooks } from './Webhooks';
 
 function countString(value) {
     switch (true) {
@@ -85,6 +87,8 @@ function PageDetails(props) {
     const [loginPasswordValid, setLoginPasswordValid] = useState(true);
     const [openLogout, setOpenLogout] = useState(false);
     const [loggingOut, setLoggingOut] = useState(false);
+    const [openReports, setOpenReports] = useState(false);
+    const [openWebhooks, setOpenWebhooks] = useState(false);
     const [settings, setSettings] = useState(false);
     const triggerSettings = () => setSettings(!settings);
 
@@ -384,6 +388,12 @@ function PageDetails(props) {
                     </div>
                 </Modal>
             )}
+            {openReports && (
+                <ModalSessionReports onClose={() => setOpenReports(false)} show={openReports} />
+            )}
+            {openWebhooks && (
+                <ModalWebhooks onClose={() => setOpenWebhooks(false)} show={openWebhooks} />
+            )}
             {openApi && (
                 <Modal
                     backgroundClose={true}
@@ -467,6 +477,24 @@ function PageDetails(props) {
                                     >
                                         Edit API key
                                     </button>
+                                    <button
+                                        className='page-details-settings-button'
+                                        onClick={() => {
+                                            triggerSettings();
+                                            setOpenWebhooks(true);
+                                        }}
+                                    >
+                                        Webhooks
+                                    </button>
+                                    <button
+                                        className='page-details-settings-button'
+                                        onClick={() => {
+                                            triggerSettings();
+                                            setOpenReports(true);
+                                        }}
+                                    >
+                                        Session reports
+                                    </button>
                                     <button
                                         className='page-details-settings-button'
                                         onClick={() => {
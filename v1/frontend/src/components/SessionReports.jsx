@@ -0,0 +1,330 @@
+import { useEffect, useState } from 'react';
+import { Modal, SmallModal } from './Modal';
+import {
+    DeleteSessionReport,
+    ExportSessionReport,
+    SessionReport,
+    SessionReports,
+} from '../../wailsjs/go/main/App';
+import { EventsOff, EventsOn } from '../../wailsjs/runtime/runtime';
+import './SessionReports.css';
+
+function dateString(d) {
+    if (d === null || d === undefined || isNaN(Date.parse(d))) {
+        return '';
+    }
+
+    return new Date(d).toLocaleString();
+}
+
+function dollars(cents) {
+    return '$' + (cents / 100).toFixed(2);
+}
+
+function durationString(seconds) {
+    let hours = Math.floor(seconds / 3600);
+    let minutes = Math.floor((seconds % 3600) / 60);
+    if (hours > 0) {
+        return hours + 'h ' + minutes + 'm';
+    }
+
+    return minutes + 'm';
+}
+
+export function ModalSessionReports(props) {
+    const [error, setError] = useState('');
+    const [openReport, setOpenReport] = useState(null);
+    const [reports, setReports] = useState([]);
+
+    useEffect(() => {
+        refresh();
+
+        EventsOn('SessionReportCreated', () => {
+            refresh();
+        });
+
+        return () => {
+            EventsOff('SessionReportCreated');
+        };
+    }, []);
+
+    const refresh = () => {
+        SessionReports()
+            .then((response) => {
+                setReports(response === null ? [] : response);
+            })
+            .catch((error) => {
+                setError(error);
+            });
+    };
+
+    return (
+        <>
+            <Modal
+                cancelButton={'Close'}
+                onCancel={props.onClose}
+                onClose={props.onClose}
+                show={props.show}
+                style={{ minWidth: '500px', maxWidth: '500px', maxHeight: '500px' }}
+                title={'Session Reports'}
+            >
+                <div className='session-reports'>
+                    {reports.length === 0 && (
+                        <span className='session-reports-empty'>
+                            A report is saved every time one of your livestreams ends.
+                        </span>
+                    )}
+                    {reports.map((report) => (
+                        <button
+                            className='session-reports-item'
+                            key={report.id}
+                            onClick={() => setOpenReport(report)}
+                        >
+                            <span className='session-reports-item-title'>{report.title}</span>
+                            <div className='session-reports-item-details'>
+                                <span className='session-reports-item-page'>{report.page}</span>
+                                <span className='session-reports-item-date'>
+                                    {dateString(report.start === null ? report.end : report.start)}
+                                </span>
+                            </div>
+                        </button>
+                    ))}
+                </div>
+            </Modal>
+            {openReport !== null && (
+                <ModalSessionReport
+                    onClose={() => setOpenReport(null)}
+                    onDeleted={() => {
+                        setOpenReport(null);
+                        refresh();
+                    }}
+                    show={openReport !== null}
+                    sessionReport={openReport}
+                />
+            )}
+            {error !== '' && (
+                <SmallModal
+                    onClose={() => setError('')}
+                    show={error !== ''}
+                    style={{ minWidth: '300px', maxWidth: '200px', maxHeight: '200px' }}
+                    title={'Error'}
+                    message={error}
+                    submitButton={'OK'}
+                    onSubmit={() => setError('')}
+                />
+            )}
+        </>
+    );
+}
+
+function ModalSessionReport(props) {
+    const [deleting, setDeleting] = useState(false);
+    const [error, setError] = useState('');
+    const [exported, setExported] = useState('');
+    const [report, setReport] = useState(null);
+
+    useEffect(() => {
+        SessionReport(props.sessionReport.id)
+            .then((response) => {
+                setReport(response);
+            })
+            .catch((error) => {
+                setError(error);
+            });
+    }, [props.sessionReport.id]);
+
+    const confirmDelete = () => {
+        DeleteSessionReport(props.sessionReport.id)
+            .then(() => {
+                setDeleting(false);
+                props.onDeleted();
+            })
+            .catch((err) => {
+                setDeleting(false);
+                setError(err);
+            });
+    };
+
+    const exportReport = (format) => {
+        ExportSessionReport(props.sessionReport.id, format)
+            .then((path) => {
+                if (path !== '') {
+                    setExported(path);
+                }
+            })
+            .catch((error) => {
+                setError(error);
+            });
+    };
+
+    return (
+        <>
+            <Modal
+                cancelButton={'Close'}
+                onCancel={props.onClose}
+                onClose={props.onClose}
+                deleteActive={true}
+                deleteButton={'Delete'}
+                onDelete={() => setDeleting(true)}
+                show={props.show}
+                style={{ minWidth: '600px', maxWidth: '600px', maxHeight: '600px' }}
+                title={'Session Report'}
+            >
+                {report !== null && (
+                    <div className='session-report'>
+                        <div className='session-report-header'>
+                            <div className='session-report-header-left'>
+                                <span className='session-report-title'>{report.title}</span>
+                                <span className='session-report-subtitle'>
+                                    {dateString(report.start)} - {dateString(report.end)}
+                                </span>
+                            </div>
+                            <div className='session-report-header-right'>
+                                <button
+                                    className='session-report-button'
+                                    onClick={() => exportReport('md')}
+                                >
+                                    Markdown
+                                </button>
+                                <button
+                                    className='session-report-button'
+                                    onClick={() => exportReport('html')}
+                                >
+                                    HTML
+                                </button>
+                            </div>
+                        </div>
+                        <div className='session-report-stats'>
+                            <ReportStat
+                                title={'Duration'}
+                                value={durationString(report.duration)}
+                            />
+                            <ReportStat title={'Peak viewers'} value={report.peak_viewers} />
+                            <ReportStat
+                                title={'Average viewers'}
+                                value={Math.round(report.average_viewers)}
+                            />
+                            <ReportStat title={'New followers'} value={report.new_followers} />
+                            <ReportStat title={'New subscribers'} value={report.new_subscribers} />
+                            <ReportStat title={'Rants'} value={dollars(report.rant_total)} />
+                            <ReportStat title={'Chat messages'} value={report.chat_messages} />
+                            <ReportStat title={'Unique chatters'} value={report.unique_chatters} />
+                        </div>
+                        <ReportSection title={'Rants'} items={report.rants}>
+                            {(rant) => (
+                                <>
+                                    <span className='session-report-item-name'>
+                                        {rant.username} {dollars(rant.amount)}
+                                    </span>
+                                    <span className='session-report-item-text'>{rant.text}</span>
+                                </>
+                            )}
+                        </ReportSection>
+                        <ReportSection title={'Raids'} items={report.raids}>
+                            {(raid) => (
+                                <>
+                                    <span className='session-report-item-name'>
+                                        {raid.username}
+                                    </span>
+                                    <span className='session-report-item-text'>
+                                        {dateString(raid.time)}
+                                    </span>
+                                </>
+                            )}
+                        </ReportSection>
+                        <ReportSection title={'Top chatters'} items={report.top_chatters}>
+                            {(count) => (
+                                <>
+                                    <span className='session-report-item-name'>{count.name}</span>
+                                    <span className='session-report-item-text'>{count.count}</span>
+                                </>
+                            )}
+                        </ReportSection>
+                        <ReportSection title={'Top commands'} items={report.top_commands}>
+                            {(count) => (
+                                <>
+                                    <span className='session-report-item-name'>{count.name}</span>
+                                    <span className='session-report-item-text'>{count.count}</span>
+                                </>
+                            )}
+                        </ReportSection>
+                        <ReportSection title={'Stream changes'} items={report.changes}>
+                            {(change) => (
+                                <>
+                                    <span className='session-report-item-name'>
+                                        {change.kind === 'title' ? 'Title' : 'Category'}:{' '}
+                                        {change.value}
+                                    </span>
+                                    <span className='session-report-item-text'>
+                                        {dateString(change.time)}
+                                    </span>
+                                </>
+                            )}
+                        </ReportSection>
+                    </div>
+                )}
+            </Modal>
+            {deleting && (
+                <SmallModal
+                    cancelButton={'Cancel'}
+                    onCancel={() => setDeleting(false)}
+                    onClose={() => setDeleting(false)}
+                    show={deleting}
+                    style={{ minWidth: '300px', maxWidth: '200px', maxHeight: '200px' }}
+                    title={'Delete Session Report'}
+                    message={'Are you sure you want to delete the session report?'}
+                    submitButton={'OK'}
+                    onSubmit={confirmDelete}
+                />
+            )}
+            {exported !== '' && (
+                <SmallModal
+                    onClose={() => setExported('')}
+                    show={exported !== ''}
+                    style={{ minWidth: '300px', maxWidth: '200px', maxHeight: '200px' }}
+                    title={'Exported'}
+                    message={'Saved the session report to ' + exported}
+                    submitButton={'OK'}
+                    onSubmit={() => setExported('')}
+                />
+            )}
+            {error !== '' && (
+                <SmallModal
+                    onClose={() => setError('')}
+                    show={error !== ''}
+                    style={{ minWidth: '300px', maxWidth: '200px', maxHeight: '200px' }}
+                    title={'Error'}
+                    message={error}
+                    submitButton={'OK'}
+                    onSubmit={() => setError('')}
+                />
+            )}
+        </>
+    );
+}
+
+function ReportStat(props) {
+    return (
+        <div className='session-report-stat'>
+            <span className='session-report-stat-title'>{props.title}</span>
+            <span className='session-report-stat-text'>{props.value}</span>
+        </div>
+    );
+}
+
+function ReportSection(props) {
+    if (props.items === null || props.items.length === 0) {
+        return null;
+    }
+
+    return (
+        <div className='session-report-section'>
+            <span className='session-report-section-title'>{props.title}</span>
+            {props.items.map((item, index) => (
+                <div className='session-report-item' key={index}>
+                    {props.children(item)}
+                </div>
+            ))}
+        </div>
+    );
+}
@@ -0,0 +1,503 @@
+import { useEffect, useState } from 'react';
+import { Modal, SmallModal } from './Modal';
+import {
+    DeleteWebhook,
+    NewWebhook,
+    TestWebhook,
+    UpdateWebhook,
+    WebhookDeliveries,
+    Webhooks,
+} from '../../wailsjs/go/main/App';
+import { EventsOff, EventsOn } from '../../wailsjs/runtime/runtime';
+import './Webhooks.css';
+
+const webhookEvents = [
+    { kind: 'chat_message', name: 'Chat messages' },
+    { kind: 'follow', name: 'Follows' },
+    { kind: 'live_start', name: 'Stream started' },
+    { kind: 'live_end', name: 'Stream ended' },
+    { kind: 'rant', name: 'Rants' },
+    { kind: 'raid', name: 'Raids' },
+    { kind: 'sub', name: 'Subscriptions' },
+    { kind: 'rule_error', name: 'Chatbot rule errors' },
+];
+
+function dateString(d) {
+    if (d === null || d === undefined || isNaN(Date.parse(d))) {
+        return '';
+    }
+
+    return new Date(d).toLocaleString();
+}
+
+export function ModalWebhooks(props) {
+    const [deliveries, setDeliveries] = useState(null);
+    const [editWebhook, setEditWebhook] = useState(null);
+    const [error, setError] = useState('');
+    const [openNew, setOpenNew] = useState(false);
+    const [webhooks, setWebhooks] = useState([]);
+
+    useEffect(() => {
+        refresh();
+    }, []);
+
+    const refresh = () => {
+        Webhooks()
+            .then((response) => {
+                setWebhooks(response === null ? [] : response);
+            })
+            .catch((error) => {
+                setError(error);
+            });
+    };
+
+    const test = (webhook) => {
+        TestWebhook(webhook.id)
+            .then(() => {
+                setDeliveries(webhook);
+            })
+            .catch((error) => {
+                setError(error);
+            });
+    };
+
+    const sortWebhooks = () => {
+        let sorted = [...webhooks].sort((a, b) =>
+            a.name.toLowerCase() > b.name.toLowerCase() ? 1 : -1
+        );
+
+        return sorted;
+    };
+
+    return (
+        <>
+            <Modal
+                cancelButton={'Close'}
+                onCancel={props.onClose}
+                onClose={props.onClose}
+                show={props.show}
+                style={{ minWidth: '500px', maxWidth: '500px', maxHeight: '500px' }}
+                submitButton={'New Webhook'}
+                onSubmit={() => setOpenNew(true)}
+                title={'Webhooks'}
+            >
+                <div className='webhooks'>
+                    {webhooks.length === 0 && (
+                        <span className='webhooks-empty'>
+                            Add a webhook to post events to another app.
+                        </span>
+                    )}
+                    {sortWebhooks().map((webhook) => (
+                        <WebhookItem
+                            key={webhook.id}
+                            onDeliveries={() => setDeliveries(webhook)}
+                            onEdit={() => setEditWebhook(webhook)}
+                            onTest={() => test(webhook)}
+                            webhook={webhook}
+                        />
+                    ))}
+                </div>
+            </Modal>
+            {openNew && (
+                <ModalWebhook
+                    cancelButton={'Cancel'}
+                    onClose={() => setOpenNew(false)}
+                    onSaved={refresh}
+                    show={openNew}
+                    submit={NewWebhook}
+                    submitButton={'Create'}
+                    submittingButton={'Creating...'}
+                    title={'New Webhook'}
+                />
+            )}
+            {editWebhook !== null && (
+                <ModalWebhook
+                    deleteButton={'Delete'}
+                    onClose={() => setEditWebhook(null)}
+                    onSaved={refresh}
+                    show={editWebhook !== null}
+                    submit={UpdateWebhook}
+                    submitButton={'Update'}
+                    submittingButton={'Updating...'}
+                    title={'Edit Webhook'}
+                    webhook={editWebhook}
+                />
+            )}
+            {deliveries !== null && (
+                <ModalWebhookDeliveries
+                    onClose={() => setDeliveries(null)}
+                    show={deliveries !== null}
+                    webhook={deliveries}
+                />
+            )}
+            {error !== '' && (
+                <SmallModal
+                    onClose={() => setError('')}
+                    show={error !== ''}
+                    style={{ minWidth: '300px', maxWidth: '200px', maxHeight: '200px' }}
+                    title={'Error'}
+                    message={error}
+                    submitButton={'OK'}
+                    onSubmit={() => setError('')}
+                />
+            )}
+        </>
+    );
+}
+
+function WebhookItem(props) {
+    return (
+        <div className='webhooks-item'>
+            <div className='webhooks-item-left'>
+                <span className='webhooks-item-name'>{props.webhook.name}</span>
+                <span className='webhooks-item-url'>{props.webhook.url}</span>
+            </div>
+            <div className='webhooks-item-right'>
+                {!props.webhook.enabled && (
+                    <span className='webhooks-item-disabled'>Disabled</span>
+                )}
+                <button className='webhooks-item-button' onClick={props.onTest}>
+                    Test
+                </button>
+                <button className='webhooks-item-button' onClick={props.onDeliveries}>
+                    Log
+                </button>
+                <button className='webhooks-item-button' onClick={props.onEdit}>
+                    Edit
+                </button>
+            </div>
+        </div>
+    );
+}
+
+function ModalWebhook(props) {
+    const [deleting, setDeleting] = useState(false);
+    const [enabled, setEnabled] = useState(
+        props.webhook === undefined ? true : props.webhook.enabled
+    );
+    const toggleEnabled = () => {
+        if (loading) {
+            return;
+        }
+        setEnabled(!enabled);
+    };
+    const [error, setError] = useState('');
+    const [events, setEvents] = useState(
+        props.webhook === undefined || !props.webhook.events
+            ? []
+            : props.webhook.events.split(',')
+    );
+    const toggleEvent = (kind) => {
+        if (loading) {
+            return;
+        }
+        if (events.includes(kind)) {
+            setEvents(events.filter((event) => event !== kind));
+        } else {
+            setEvents([...events, kind]);
+        }
+        setEventsValid(true);
+    };
+    const [eventsValid, setEventsValid] = useState(true);
+    const [loading, setLoading] = useState(false);
+    const [name, setName] = useState(props.webhook === undefined ? '' : props.webhook.name);
+    const updateName = (event) => {
+        if (loading) {
+            return;
+        }
+        setName(event.target.value);
+    };
+    const [nameValid, setNameValid] = useState(true);
+    const [secret, setSecret] = useState(
+        props.webhook === undefined || !props.webhook.secret ? '' : props.webhook.secret
+    );
+    const updateSecret = (event) => {
+        if (loading) {
+            return;
+        }
+        setSecret(event.target.value);
+    };
+    const [url, setUrl] = useState(props.webhook === undefined ? '' : props.webhook.url);
+    const updateUrl = (event) => {
+        if (loading) {
+            return;
+        }
+        setUrl(event.target.value);
+    };
+    const [urlValid, setUrlValid] = useState(true);
+
+    useEffect(() => {
+        if (loading) {
+            props
+                .submit({
+                    id: props.webhook === undefined ? null : props.webhook.id,
+                    name: name,
+                    url: url,
+                    secret: secret === '' ? null : secret,
+                    events: events.join(','),
+                    enabled: enabled,
+                })
+                .then(() => {
+                    props.onSaved();
+                    props.onClose();
+                })
+                .catch((err) => {
+                    setLoading(false);
+                    setError(err);
+                });
+        }
+    }, [loading]);
+
+    const close = () => {
+        if (loading) {
+            return;
+        }
+
+        props.onClose();
+    };
+
+    const confirmDelete = () => {
+        DeleteWebhook(props.webhook.id)
+            .then(() => {
+                setDeleting(false);
+                props.onSaved();
+                props.onClose();
+            })
+            .catch((err) => {
+                setDeleting(false);
+                setError(err);
+            });
+    };
+
+    const submit = () => {
+        if (name === '') {
+            setNameValid(false);
+            return;
+        }
+        setNameValid(true);
+
+        if (!url.startsWith('http://') && !url.startsWith('https://')) {
+            setUrlValid(false);
+            return;
+        }
+        setUrlValid(true);
+
+        if (events.length === 0) {
+            setEventsValid(false);
+            return;
+        }
+
+        setLoading(true);
+    };
+
+    return (
+        <>
+            {error !== '' && (
+                <SmallModal
+                    onClose={() => setError('')}
+                    show={error !== ''}
+                    style={{ minWidth: '300px', maxWidth: '200px', maxHeight: '200px' }}
+                    title={'Error'}
+                    message={error}
+                    submitButton={'OK'}
+                    onSubmit={() => setError('')}
+                />
+            )}
+            {deleting && (
+                <SmallModal
+                    cancelButton={'Cancel'}
+                    onCancel={() => setDeleting(false)}
+                    onClose={() => setDeleting(false)}
+                    show={deleting}
+                    style={{ minWidth: '300px', maxWidth: '200px', maxHeight: '200px' }}
+                    title={'Delete Webhook'}
+                    message={
+                        'Are you sure you want to delete the webhook? Its delivery log will be deleted as well.'
+                    }
+                    submitButton={'OK'}
+                    onSubmit={confirmDelete}
+                />
+            )}
+            <Modal
+                cancelButton={props.cancelButton}
+                onCancel={close}
+                onClose={close}
+                deleteActive={true}
+                deleteButton={props.deleteButton}
+                onDelete={() => setDeleting(true)}
+                show={props.show}
+                style={{ minWidth: '400px', maxWidth: '400px', maxHeight: '640px' }}
+                submitButton={loading ? props.submittingButton : props.submitButton}
+                onSubmit={submit}
+                title={props.title}
+            >
+                <div className='webhook-modal-form'>
+                    {nameValid ? (
+                        <label className='webhook-modal-label'>Name</label>
+                    ) : (
+                        <label className='webhook-modal-label-warning'>
+                            Name - Please enter a valid name
+                        </label>
+                    )}
+                    <input
+                        className='webhook-modal-input'
+                        onChange={updateName}
+                        placeholder={'Name'}
+                        type={'text'}
+                        value={name}
+                    ></input>
+                    {urlValid ? (
+                        <label className='webhook-modal-label'>URL</label>
+                    ) : (
+                        <label className='webhook-modal-label-warning'>
+                            URL - Please enter an http or https URL
+                        </label>
+                    )}
+                    <input
+                        className='webhook-modal-input'
+                        onChange={updateUrl}
+                        placeholder={'https://example.com/webhook'}
+                        type={'text'}
+                        value={url}
+                    ></input>
+                    <label className='webhook-modal-label'>Signing Secret</label>
+                    <input
+                        className='webhook-modal-input'
+                        onChange={updateSecret}
+                        placeholder={'Generated if left empty'}
+                        type={'text'}
+                        value={secret}
+                    ></input>
+                    <div className='webhook-modal-setting'>
+                        <label className='webhook-modal-setting-description'>Enabled</label>
+                        <label className='webhook-modal-toggle-switch'>
+                            <input checked={enabled} onChange={toggleEnabled} type='checkbox' />
+                            <span className='webhook-modal-toggle-slider round'></span>
+                        </label>
+                    </div>
+                    {eventsValid ? (
+                        <label className='webhook-modal-label'>Events</label>
+                    ) : (
+                        <label className='webhook-modal-label-warning'>
+                            Events - Please choose at least one event
+                        </label>
+                    )}
+                    {webhookEvents.map((event) => (
+                        <div className='webhook-modal-setting' key={event.kind}>
+                            <label className='webhook-modal-setting-description'>
+                                {event.name}
+                            </label>
+                            <label className='webhook-modal-toggle-switch'>
+                                <input
+                                    checked={events.includes(event.kind)}
+                                    onChange={() => toggleEvent(event.kind)}
+                                    type='checkbox'
+                                />
+                                <span className='webhook-modal-toggle-slider round'></span>
+                            </label>
+                        </div>
+                    ))}
+                </div>
+            </Modal>
+        </>
+    );
+}
+
+function ModalWebhookDeliveries(props) {
+    const [deliveries, setDeliveries] = useState([]);
+    const [error, setError] = useState('');
+    const [open, setOpen] = useState(null);
+
+    useEffect(() => {
+        refresh();
+
+        EventsOn('WebhookDelivery', (delivery) => {
+            if (delivery.webhook_id === props.webhook.id) {
+                refresh();
+            }
+        });
+
+        return () => {
+            EventsOff('WebhookDelivery');
+        };
+    }, [props.webhook.id]);
+
+    const refresh = () => {
+        WebhookDeliveries(props.webhook.id)
+            .then((response) => {
+                setDeliveries(response === null ? [] : response);
+            })
+            .catch((error) => {
+                setError(error);
+            });
+    };
+
+    return (
+        <>
+            {error !== '' && (
+                <SmallModal
+                    onClose={() => setError('')}
+                    show={error !== ''}
+                    style={{ minWidth: '300px', maxWidth: '200px', maxHeight: '200px' }}
+                    title={'Error'}
+                    message={error}
+                    submitButton={'OK'}
+                    onSubmit={() => setError('')}
+                />
+            )}
+            <Modal
+                cancelButton={'Close'}
+                onCancel={props.onClose}
+                onClose={props.onClose}
+                show={props.show}
+                style={{ minWidth: '600px', maxWidth: '600px', maxHeight: '500px' }}
+                submitButton={'Refresh'}
+                onSubmit={refresh}
+                title={props.webhook.name + ' Deliveries'}
+            >
+                <div className='webhooks'>
+                    {deliveries.length === 0 && (
+                        <span className='webhooks-empty'>No events have been sent yet.</span>
+                    )}
+                    {deliveries.map((delivery) => (
+                        <WebhookDelivery
+                            delivery={delivery}
+                            key={delivery.id}
+                            onClick={() => setOpen(open === delivery.id ? null : delivery.id)}
+                            open={open === delivery.id}
+                        />
+                    ))}
+                </div>
+            </Modal>
+        </>
+    );
+}
+
+function WebhookDelivery(props) {
+    const delivery = props.delivery;
+
+    return (
+        <div className='webhooks-delivery'>
+            <button className='webhooks-delivery-summary' onClick={props.onClick}>
+                <span className='webhooks-delivery-event'>{delivery.event}</span>
+                <span className={'webhooks-delivery-status webhooks-delivery-' + delivery.status}>
+                    {delivery.status}
+                </span>
+                <span className='webhooks-delivery-detail'>
+                    {delivery.attempts} {delivery.attempts === 1 ? 'attempt' : 'attempts'}
+                    {delivery.response_code !== null && ' - HTTP ' + delivery.response_code}
+                </span>
+                <span className='webhooks-delivery-date'>{dateString(delivery.created_at)}</span>
+            </button>
+            {delivery.error !== null && (
+                <span className='webhooks-delivery-error'>{delivery.error}</span>
+            )}
+            {delivery.status === 'pending' && delivery.next_attempt !== null && (
+                <span className='webhooks-delivery-detail'>
+                    Next attempt {dateString(delivery.next_attempt)}
+                </span>
+            )}
+            {props.open && <pre className='webhooks-delivery-payload'>{delivery.payload}</pre>}
+        </div>
+    );
+}
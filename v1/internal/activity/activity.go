@@ -0,0 +1,216 @@
+package activity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+type Kind string
+
+const (
+	KindCategoryChanged Kind = "category_changed"
+	KindFollower        Kind = "follower"
+	KindRant            Kind = "rant"
+	KindStreamEnded     Kind = "stream_ended"
+	KindStreamStarted   Kind = "stream_started"
+	KindSubscriber      Kind = "subscriber"
+	KindTitleChanged    Kind = "title_changed"
+)
+
+// Event is something that happened on a page between two API responses.
+// Text is the rant message, or the new title or category; Previous is the old title or category.
+// Livestream is the url of the livestream the event happened on, if any.
+type Event struct {
+	ID          int64     `json:"id"`
+	Kind        Kind      `json:"kind"`
+	Page        string    `json:"page"`
+	Livestream  string    `json:"livestream,omitempty"`
+	Username    string    `json:"username,omitempty"`
+	AmountCents int64     `json:"amount_cents,omitempty"`
+	Text        string    `json:"text,omitempty"`
+	Previous    string    `json:"previous,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// EndedAfter is the number of API responses in a row a livestream must be missing from before it ends.
+// The API sometimes leaves a livestream out of a single response while it is still live.
+const EndedAfter = 3
+
+// Offline holds the page's livestreams that are missing from its latest API responses but have not ended yet.
+// The zero value is ready to use.
+type Offline struct {
+	ended       []rumblelivestreamlib.Livestream
+	livestreams map[string]offlineLivestream
+	mu          sync.Mutex
+}
+
+type offlineLivestream struct {
+	livestream rumblelivestreamlib.Livestream
+	missed     int
+}
+
+// Ended returns the livestream with the url that ended in the latest Diff.
+func (o *Offline) Ended(url string) (rumblelivestreamlib.Livestream, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, ls := range o.ended {
+		if livestreamUrl(ls) == url {
+			return ls, true
+		}
+	}
+
+	return rumblelivestreamlib.Livestream{}, false
+}
+
+// Diff returns the events that happened on the page between the prev and next API responses, oldest first where known.
+// If prev is nil there is nothing to compare against and no events are returned.
+// A livestream missing from next only ends once it has been missing from EndedAfter responses in a row,
+// which offline keeps track of between calls.
+func Diff(page string, prev *rumblelivestreamlib.LivestreamResponse, next *rumblelivestreamlib.LivestreamResponse, offline *Offline, now time.Time) []Event {
+	offline.mu.Lock()
+	defer offline.mu.Unlock()
+
+	offline.ended = nil
+	diff := []Event{}
+	if prev == nil || next == nil {
+		offline.livestreams = nil
+		return diff
+	}
+	if offline.livestreams == nil {
+		offline.livestreams = map[string]offlineLivestream{}
+	}
+
+	prevLivestreams := map[string]rumblelivestreamlib.Livestream{}
+	for _, o := range offline.livestreams {
+		prevLivestreams[o.livestream.ID] = o.livestream
+	}
+	for _, ls := range prev.Livestreams {
+		prevLivestreams[ls.ID] = ls
+	}
+	nextLivestreams := map[string]rumblelivestreamlib.Livestream{}
+	for _, ls := range next.Livestreams {
+		nextLivestreams[ls.ID] = ls
+		delete(offline.livestreams, ls.ID)
+	}
+
+	ended := []Event{}
+	for id, ls := range prevLivestreams {
+		if _, live := nextLivestreams[id]; live {
+			continue
+		}
+
+		missed := offline.livestreams[id].missed + 1
+		if missed < EndedAfter {
+			offline.livestreams[id] = offlineLivestream{livestream: ls, missed: missed}
+			continue
+		}
+
+		delete(offline.livestreams, id)
+		offline.ended = append(offline.ended, ls)
+		ended = append(ended, Event{Kind: KindStreamEnded, Page: page, Livestream: livestreamUrl(ls), Text: ls.Title, Time: now})
+	}
+	slices.SortFunc(ended, func(a, b Event) int {
+		return strings.Compare(a.Livestream, b.Livestream)
+	})
+	diff = append(diff, ended...)
+
+	for _, ls := range next.Livestreams {
+		url := livestreamUrl(ls)
+		prevLs, existed := prevLivestreams[ls.ID]
+		if !existed {
+			diff = append(diff, Event{Kind: KindStreamStarted, Page: page, Livestream: url, Text: ls.Title, Time: parseTime(ls.CreatedOn, now)})
+		} else {
+			if prevLs.Title != ls.Title {
+				diff = append(diff, Event{Kind: KindTitleChanged, Page: page, Livestream: url, Text: ls.Title, Previous: prevLs.Title, Time: now})
+			}
+			if category(prevLs.Categories) != category(ls.Categories) {
+				diff = append(diff, Event{Kind: KindCategoryChanged, Page: page, Livestream: url, Text: category(ls.Categories), Previous: category(prevLs.Categories), Time: now})
+			}
+		}
+	}
+
+	seenFollowers := map[string]bool{}
+	for _, f := range prev.Followers.RecentFollowers {
+		seenFollowers[f.Username+"\x00"+f.FollowedOn] = true
+	}
+	followers := []Event{}
+	for _, f := range next.Followers.RecentFollowers {
+		if !seenFollowers[f.Username+"\x00"+f.FollowedOn] {
+			followers = append(followers, Event{Kind: KindFollower, Page: page, Username: f.Username, Time: parseTime(f.FollowedOn, now)})
+		}
+	}
+	diff = append(diff, oldestFirst(followers)...)
+
+	seenSubscribers := map[string]bool{}
+	for _, s := range prev.Subscribers.RecentSubscribers {
+		seenSubscribers[s.Username+"\x00"+s.SubscribedOn] = true
+	}
+	subscribers := []Event{}
+	for _, s := range next.Subscribers.RecentSubscribers {
+		if !seenSubscribers[s.Username+"\x00"+s.SubscribedOn] {
+			subscribers = append(subscribers, Event{Kind: KindSubscriber, Page: page, Username: s.Username, AmountCents: s.AmountCents, Time: parseTime(s.SubscribedOn, now)})
+		}
+	}
+	diff = append(diff, oldestFirst(subscribers)...)
+
+	for _, ls := range next.Livestreams {
+		seenRants := map[string]bool{}
+		for _, r := range prevLivestreams[ls.ID].Chat.RecentRants {
+			seenRants[rantKey(r)] = true
+		}
+		rants := []Event{}
+		for _, r := range ls.Chat.RecentRants {
+			if !seenRants[rantKey(r)] {
+				rants = append(rants, Event{Kind: KindRant, Page: page, Livestream: livestreamUrl(ls), Username: r.Username, AmountCents: r.AmountCents, Text: r.Text, Time: parseTime(r.CreatedOn, now)})
+			}
+		}
+		diff = append(diff, oldestFirst(rants)...)
+	}
+
+	return diff
+}
+
+func livestreamUrl(ls rumblelivestreamlib.Livestream) string {
+	return fmt.Sprintf("https://rumble.com/v%s", ls.ID)
+}
+
+// category returns the livestream's categories for display.
+func category(c rumblelivestreamlib.Categories) string {
+	switch {
+	case c.Primary.Title != "" && c.Secondary.Title != "":
+		return c.Primary.Title + ", " + c.Secondary.Title
+	case c.Primary.Title != "":
+		return c.Primary.Title
+	default:
+		return c.Secondary.Title
+	}
+}
+
+func rantKey(r rumblelivestreamlib.Rant) string {
+	return fmt.Sprintf("%s\x00%s\x00%d\x00%s", r.Username, r.CreatedOn, r.AmountCents, r.Text)
+}
+
+func oldestFirst(list []Event) []Event {
+	slices.SortStableFunc(list, func(a, b Event) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return list
+}
+
+// parseTime parses a time from the API, falling back to def if it is invalid.
+func parseTime(s string, def time.Time) time.Time {
+	t, err := events.ParseTime(s)
+	if err != nil {
+		return def
+	}
+
+	return t
+}
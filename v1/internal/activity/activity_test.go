@@ -0,0 +1,80 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+func kinds(diff []Event) []Kind {
+	list := []Kind{}
+	for _, e := range diff {
+		list = append(list, e.Kind)
+	}
+
+	return list
+}
+
+func TestDiffEndsStreamAfterMissedResponses(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 18, 0, 0, 0, time.UTC)
+	live := &rumblelivestreamlib.LivestreamResponse{
+		Livestreams: []rumblelivestreamlib.Livestream{{ID: "abc", Title: "Stream"}},
+	}
+	offline := &rumblelivestreamlib.LivestreamResponse{}
+	var o Offline
+
+	// A single response without the livestream neither ends nor restarts it.
+	diff := Diff("/c/Channel", live, offline, &o, now)
+	if len(diff) != 0 {
+		t.Fatalf("diff after one offline response = %v, want no events", kinds(diff))
+	}
+	diff = Diff("/c/Channel", offline, live, &o, now)
+	if len(diff) != 0 {
+		t.Fatalf("diff after coming back = %v, want no events", kinds(diff))
+	}
+
+	// The livestream ends once it is missing from EndedAfter responses in a row.
+	prev := live
+	for i := 1; i < EndedAfter; i++ {
+		diff = Diff("/c/Channel", prev, offline, &o, now)
+		if len(diff) != 0 {
+			t.Fatalf("diff after %d offline responses = %v, want no events", i, kinds(diff))
+		}
+		prev = offline
+	}
+	diff = Diff("/c/Channel", prev, offline, &o, now)
+	if len(diff) != 1 || diff[0].Kind != KindStreamEnded || diff[0].Livestream != "https://rumble.com/vabc" || diff[0].Text != "Stream" {
+		t.Fatalf("diff after %d offline responses = %+v, want stream ended", EndedAfter, diff)
+	}
+	ls, ok := o.Ended("https://rumble.com/vabc")
+	if !ok || ls.ID != "abc" {
+		t.Fatalf("Ended = %+v, %v, want the ended livestream", ls, ok)
+	}
+
+	diff = Diff("/c/Channel", offline, offline, &o, now)
+	if len(diff) != 0 {
+		t.Fatalf("diff after the stream ended = %v, want no events", kinds(diff))
+	}
+	diff = Diff("/c/Channel", offline, live, &o, now)
+	if len(diff) != 1 || diff[0].Kind != KindStreamStarted {
+		t.Fatalf("diff after going live again = %v, want stream started", kinds(diff))
+	}
+}
+
+func TestDiffKeepsRantsWhileMissing(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 18, 0, 0, 0, time.UTC)
+	rant := rumblelivestreamlib.Rant{Message: rumblelivestreamlib.Message{Username: "supporter", Text: "hi", CreatedOn: "2024-03-10T17:59:00+00:00"}, AmountCents: 500}
+	live := &rumblelivestreamlib.LivestreamResponse{
+		Livestreams: []rumblelivestreamlib.Livestream{{ID: "abc", Title: "Stream"}},
+	}
+	live.Livestreams[0].Chat.RecentRants = []rumblelivestreamlib.Rant{rant}
+	offline := &rumblelivestreamlib.LivestreamResponse{}
+	var o Offline
+
+	Diff("/c/Channel", live, offline, &o, now)
+	diff := Diff("/c/Channel", offline, live, &o, now)
+	if len(diff) != 0 {
+		t.Fatalf("diff after coming back = %+v, want the rant not counted again", diff)
+	}
+}
@@ -0,0 +1,66 @@
+package activity
+
+import (
+	"slices"
+	"sync"
+)
+
+// Feed keeps the most recent events of each page.
+type Feed struct {
+	events   map[string][]Event
+	eventsMu sync.Mutex
+	lastID   int64
+	size     int
+}
+
+// NewFeed creates a feed that keeps up to size events per page.
+func NewFeed(size int) *Feed {
+	return &Feed{
+		events: map[string][]Event{},
+		size:   size,
+	}
+}
+
+// Add appends the events to the page's feed, dropping the oldest events past the feed size.
+// It returns the events with their feed IDs set.
+func (f *Feed) Add(page string, events []Event) []Event {
+	f.eventsMu.Lock()
+	defer f.eventsMu.Unlock()
+
+	added := make([]Event, len(events))
+	for i, e := range events {
+		f.lastID++
+		e.ID = f.lastID
+		added[i] = e
+	}
+
+	feed := append(f.events[page], added...)
+	if len(feed) > f.size {
+		feed = slices.Clone(feed[len(feed)-f.size:])
+	}
+	f.events[page] = feed
+
+	return added
+}
+
+// Events returns the page's feed, most recent first.
+func (f *Feed) Events(page string) []Event {
+	f.eventsMu.Lock()
+	defer f.eventsMu.Unlock()
+
+	feed := slices.Clone(f.events[page])
+	slices.Reverse(feed)
+	if feed == nil {
+		feed = []Event{}
+	}
+
+	return feed
+}
+
+// Remove drops the page's feed.
+func (f *Feed) Remove(page string) {
+	f.eventsMu.Lock()
+	defer f.eventsMu.Unlock()
+
+	delete(f.events, page)
+}
@@ -0,0 +1,195 @@
+package audience
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+// Tracker merges the followers and subscribers listed in each API response into the page's history.
+type Tracker struct {
+	followerS   models.FollowerService
+	seeded      map[string]bool
+	seededMu    sync.Mutex
+	subscriberS models.SubscriberService
+}
+
+func NewTracker(followerS models.FollowerService, subscriberS models.SubscriberService) *Tracker {
+	return &Tracker{
+		followerS:   followerS,
+		seeded:      map[string]bool{},
+		subscriberS: subscriberS,
+	}
+}
+
+// Update is what changed in a page's history after merging an API response.
+// Count is nil if the follower count did not change and no one followed.
+type Update struct {
+	Page        string                    `json:"page"`
+	Followers   []models.FollowerChange   `json:"followers"`
+	Subscribers []models.SubscriberChange `json:"subscribers"`
+	Count       *models.FollowerCount     `json:"count"`
+}
+
+// Empty reports whether the update has no changes.
+func (u *Update) Empty() bool {
+	return len(u.Followers) == 0 && len(u.Subscribers) == 0 && u.Count == nil
+}
+
+// Resubs returns the subscribers who subscribed again.
+func (u *Update) Resubs() []models.Subscriber {
+	resubs := []models.Subscriber{}
+	for _, change := range u.Subscribers {
+		if change.Status == models.SubscriberStatusResub {
+			resubs = append(resubs, change.Subscriber)
+		}
+	}
+
+	return resubs
+}
+
+// Merge adds the followers and subscribers in resp to the page's history.
+// The first time a page with no history is merged, its recent followers and subscribers
+// are recorded without being reported as changes.
+func (t *Tracker) Merge(page string, resp *rumblelivestreamlib.LivestreamResponse, now time.Time) (*Update, error) {
+	if page == "" || resp == nil {
+		return nil, pkgErr("", fmt.Errorf("invalid response"))
+	}
+
+	followers := []models.FollowerObservation{}
+	for _, f := range resp.Followers.RecentFollowers {
+		followedOn, err := events.ParseTime(f.FollowedOn)
+		if err != nil {
+			return nil, pkgErr("error parsing followed on time", err)
+		}
+		followers = append(followers, models.FollowerObservation{Username: f.Username, FollowedOn: followedOn})
+	}
+
+	subscribers := []models.SubscriberObservation{}
+	for _, s := range resp.Subscribers.RecentSubscribers {
+		subscribedOn, err := events.ParseTime(s.SubscribedOn)
+		if err != nil {
+			return nil, pkgErr("error parsing subscribed on time", err)
+		}
+		subscribers = append(subscribers, models.SubscriberObservation{
+			Username:     s.Username,
+			User:         s.User,
+			AmountCents:  s.AmountCents,
+			SubscribedOn: subscribedOn,
+		})
+	}
+
+	seeded, err := t.isSeeded(page)
+	if err != nil {
+		return nil, err
+	}
+
+	followerChanges, err := t.followerS.Merge(page, followers, now)
+	if err != nil {
+		return nil, pkgErr("error merging followers", err)
+	}
+	subscriberChanges, err := t.subscriberS.Merge(page, subscribers, now)
+	if err != nil {
+		return nil, pkgErr("error merging subscribers", err)
+	}
+
+	t.seededMu.Lock()
+	t.seeded[page] = true
+	t.seededMu.Unlock()
+
+	update := &Update{
+		Page:        page,
+		Followers:   followerChanges,
+		Subscribers: subscriberChanges,
+	}
+	if !seeded {
+		update.Followers = []models.FollowerChange{}
+		update.Subscribers = []models.SubscriberChange{}
+	}
+
+	update.Count, err = t.count(page, resp.Followers.NumFollowers, int64(len(update.Followers)), now)
+	if err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// isSeeded reports whether the page already had follower or subscriber history.
+func (t *Tracker) isSeeded(page string) (bool, error) {
+	t.seededMu.Lock()
+	defer t.seededMu.Unlock()
+
+	if t.seeded[page] {
+		return true, nil
+	}
+
+	followers, err := t.followerS.CountByPage(page)
+	if err != nil {
+		return false, pkgErr("error counting followers", err)
+	}
+	subscribers, err := t.subscriberS.CountByPage(page)
+	if err != nil {
+		return false, pkgErr("error counting subscribers", err)
+	}
+
+	return followers > 0 || subscribers > 0, nil
+}
+
+// count records the page's follower count if it changed or anyone followed since the last count.
+func (t *Tracker) count(page string, followers int64, newFollowers int64, now time.Time) (*models.FollowerCount, error) {
+	latest, err := t.followerS.LatestCount(page)
+	if err != nil {
+		return nil, pkgErr("error getting latest follower count", err)
+	}
+	if latest != nil && latest.Followers != nil && *latest.Followers == followers && newFollowers == 0 {
+		return nil, nil
+	}
+
+	fc := &models.FollowerCount{
+		Page:         &page,
+		Time:         &now,
+		Followers:    &followers,
+		NewFollowers: &newFollowers,
+	}
+	id, err := t.followerS.CreateCount(fc)
+	if err != nil {
+		return nil, pkgErr("error creating follower count", err)
+	}
+	fc.ID = &id
+
+	return fc, nil
+}
+
+// EstimateUnfollows estimates how many users unfollowed over the counts, ordered oldest first.
+// Unfollows are follows that do not show up as growth in the follower count,
+// so the estimate is low if follows were missed because more users followed between polls than the API lists.
+func EstimateUnfollows(counts []models.FollowerCount) int64 {
+	if len(counts) < 2 {
+		return 0
+	}
+
+	first := counts[0].Followers
+	last := counts[len(counts)-1].Followers
+	if first == nil || last == nil {
+		return 0
+	}
+
+	var follows int64
+	for _, c := range counts[1:] {
+		if c.NewFollowers != nil {
+			follows += *c.NewFollowers
+		}
+	}
+
+	unfollows := follows - (*last - *first)
+	if unfollows < 0 {
+		return 0
+	}
+
+	return unfollows
+}
@@ -0,0 +1,14 @@
+package audience
+
+import "fmt"
+
+const pkgName = "audience"
+
+func pkgErr(prefix string, err error) error {
+	pkgErr := pkgName
+	if prefix != "" {
+		pkgErr = fmt.Sprintf("%s: %s", pkgErr, prefix)
+	}
+
+	return fmt.Errorf("%s: %v", pkgErr, err)
+}
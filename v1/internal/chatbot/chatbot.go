@@ -1,15 +1,18 @@
 package chatbot
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/tylertravisty/rum-goggles/v1/internal/activity"
 	"github.com/tylertravisty/rum-goggles/v1/internal/events"
 	"github.com/tylertravisty/rum-goggles/v1/internal/models"
 	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
@@ -43,31 +46,66 @@ func (c clients) byUsernameLivestream(username string, url string) *rumblelivest
 	return user.byLivestream(url)
 }
 
+// Stream change kinds, matching the models.StreamChange kinds.
+const (
+	changeCategory = "category"
+	changeTitle    = "title"
+)
+
+type changeReceiver struct {
+	kind     string
+	changeCh chan events.ApiStreamChange
+}
+
 type followReceiver struct {
-	apiCh  chan events.ApiFollower
-	latest time.Time
+	apiCh chan events.ApiFollower
+}
+
+type liveReceiver struct {
+	end    bool
+	liveCh chan events.ApiLive
+}
+
+type milestoneReceiver struct {
+	count   milestoneCount
+	countCh chan events.ApiCount
 }
 
 type receiver struct {
-	onCommand   map[string]map[int64]chan events.Chat
-	onCommandMu sync.Mutex
-	onFollow    map[int64]*followReceiver
-	onFollowMu  sync.Mutex
-	onRaid      map[int64]chan events.Chat
-	onRaidMu    sync.Mutex
-	onRant      map[int64]chan events.Chat
-	onRantMu    sync.Mutex
-	onSub       map[int64]chan events.Chat
-	onSubMu     sync.Mutex
+	onChange      map[int64]*changeReceiver
+	onChangeMu    sync.Mutex
+	onCommand     map[string]map[int64]chan events.Chat
+	onCommandMu   sync.Mutex
+	onFollow      map[int64]*followReceiver
+	onFollowMu    sync.Mutex
+	onGoal        map[int64]chan events.GoalMilestone
+	onGoalMu      sync.Mutex
+	onLive        map[int64]*liveReceiver
+	onLiveMu      sync.Mutex
+	onMilestone   map[int64]*milestoneReceiver
+	onMilestoneMu sync.Mutex
+	onRaid        map[int64]chan events.Chat
+	onRaidMu      sync.Mutex
+	onRant        map[int64]chan events.Chat
+	onRantMu      sync.Mutex
+	onResub       map[int64]chan events.ApiSubscriber
+	onResubMu     sync.Mutex
+	onSub         map[int64]chan events.Chat
+	onSubMu       sync.Mutex
 }
 
 func newReceiver() *receiver {
 	return &receiver{
-		onCommand: map[string]map[int64]chan events.Chat{},
-		onFollow:  map[int64]*followReceiver{},
-		onRaid:    map[int64]chan events.Chat{},
-		onRant:    map[int64]chan events.Chat{},
-		onSub:     map[int64]chan events.Chat{},
+		onChange:    map[int64]*changeReceiver{},
+		onCommand:   map[string]map[int64]chan events.Chat{},
+		onFollow:    map[int64]*followReceiver{},
+		onGoal:      map[int64]chan events.GoalMilestone{},
+		onLive:      map[int64]*liveReceiver{},
+		onMilestone: map[int64]*milestoneReceiver{},
+		onRaid:      map[int64]chan events.Chat{},
+		onRant:      map[int64]chan events.Chat{},
+		onResub:     map[int64]chan events.ApiSubscriber{},
+		onSub:       map[int64]chan events.Chat{},
 	}
 }
 
@@ -77,30 +115,49 @@ type Bot struct {
 }
 
 type Chatbot struct {
-	accountS    models.AccountService
-	bots        map[int64]*Bot
-	botsMu      sync.Mutex
-	chatbotS    models.ChatbotService
-	clients     clients
-	clientsMu   sync.Mutex
-	logError    *log.Logger
-	receivers   map[string]*receiver
-	receiversMu sync.Mutex
+	accountS      models.AccountService
+	bots          map[int64]*Bot
+	botsMu        sync.Mutex
+	chatbotS      models.ChatbotService
+	clients       clients
+	clientsMu     sync.Mutex
+	followMarks   map[string]*followMark
+	followMarksMu sync.Mutex
+	followerMarkS models.FollowerMarkService
+	logError      *log.Logger
+	obs           Obs
+	onRuleError   func(RuleError)
+	receivers     map[string]*receiver
+	receiversMu   sync.Mutex
+	ruleLocks     map[int64]*sync.Mutex
+	ruleLocksMu   sync.Mutex
 	//runners     map[int64]*Runner
 	// runnersMu sync.Mutex
-	wails context.Context
+	states      map[int64]RunnerStatus
+	statesMu    sync.Mutex
+	supervisorS models.ChatbotSupervisorService
+	wails       context.Context
 }
 
-func New(accountS models.AccountService, chatbotS models.ChatbotService, logError *log.Logger, wails context.Context) *Chatbot {
+// New creates a chatbot.
+// onRuleError, if not nil, is called whenever a rule's runner returns an error.
+func New(accountS models.AccountService, chatbotS models.ChatbotService, supervisorS models.ChatbotSupervisorService, followerMarkS models.FollowerMarkService, obs Obs, logError *log.Logger, onRuleError func(RuleError), wails context.Context) *Chatbot {
 	return &Chatbot{
-		accountS:  accountS,
-		bots:      map[int64]*Bot{},
-		chatbotS:  chatbotS,
-		clients:   map[string]*user{},
-		logError:  logError,
-		receivers: map[string]*receiver{},
+		accountS:      accountS,
+		bots:          map[int64]*Bot{},
+		chatbotS:      chatbotS,
+		clients:       map[string]*user{},
+		followMarks:   map[string]*followMark{},
+		followerMarkS: followerMarkS,
+		logError:      logError,
+		obs:           obs,
+		onRuleError:   onRuleError,
+		receivers:     map[string]*receiver{},
+		ruleLocks:     map[int64]*sync.Mutex{},
 		// runners:   map[int64]*Runner{},
-		wails: wails,
+		states:      map[int64]RunnerStatus{},
+		supervisorS: supervisorS,
+		wails:       wails,
 	}
 }
 
@@ -153,6 +210,18 @@ func (cb *Chatbot) addClient(username string, livestreamUrl string) (*rumblelive
 	return client, nil
 }
 
+// RemoveLivestream drops the clients connected to a livestream that is no longer in use.
+func (cb *Chatbot) RemoveLivestream(url string) {
+	cb.clientsMu.Lock()
+	defer cb.clientsMu.Unlock()
+
+	for _, u := range cb.clients {
+		u.livestreamsMu.Lock()
+		delete(u.livestreams, url)
+		u.livestreamsMu.Unlock()
+	}
+}
+
 func (cb *Chatbot) Run(rule *Rule, url string) error {
 	if rule == nil ||
 		rule.ChatbotID == nil ||
@@ -162,11 +231,15 @@ func (cb *Chatbot) Run(rule *Rule, url string) error {
 		return pkgErr("", fmt.Errorf("invalid rule"))
 	}
 
+	// Hold the rule's lock until the new runner is registered,
+	// so a concurrent Run cannot start a second runner between the stop and the init.
+	ruleLock := cb.ruleLock(*rule.ID)
+	ruleLock.Lock()
+	defer ruleLock.Unlock()
+
 	stopped := cb.stopRunner(*rule.ChatbotID, *rule.ID)
-	if stopped {
-		// TODO: figure out better way to determine when running rule is cleaned up.
-		// If rule was stopped, wait for everything to complete before running again.
-		time.Sleep(1 * time.Second)
+	if stopped != nil {
+		<-stopped.done
 	}
 
 	var err error
@@ -178,6 +251,11 @@ func (cb *Chatbot) Run(rule *Rule, url string) error {
 		}
 	}
 
+	supervisor, err := cb.supervisorS.ByChatbotID(*rule.ChatbotID)
+	if err != nil {
+		return pkgErr("error querying chatbot supervisor", err)
+	}
+
 	page := ""
 	rulePage := rule.Page()
 	if rulePage != nil {
@@ -186,23 +264,42 @@ func (cb *Chatbot) Run(rule *Rule, url string) error {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	runner := &Runner{
-		cancel: cancel,
-		client: client,
-		page:   page,
-		rule:   *rule,
-		wails:  cb.wails,
+		cancel:     cancel,
+		client:     client,
+		done:       make(chan struct{}),
+		logError:   cb.logError,
+		obs:        cb.obs,
+		page:       page,
+		rule:       *rule,
+		supervisor: newSupervisor(supervisor),
 	}
 
 	err = cb.initRunner(runner)
 	if err != nil {
+		cancel()
 		return pkgErr("error initializing runner", err)
 	}
 
+	cb.setState(runner, RunnerStateStarting, nil)
 	go cb.run(ctx, runner)
 
 	return nil
 }
 
+// ruleLock returns the lock that serializes starting and stopping the rule.
+func (cb *Chatbot) ruleLock(ruleID int64) *sync.Mutex {
+	cb.ruleLocksMu.Lock()
+	defer cb.ruleLocksMu.Unlock()
+
+	lock, exists := cb.ruleLocks[ruleID]
+	if !exists {
+		lock = &sync.Mutex{}
+		cb.ruleLocks[ruleID] = lock
+	}
+
+	return lock
+}
+
 func (cb *Chatbot) initRunner(runner *Runner) error {
 	if runner == nil || runner.rule.ID == nil || runner.rule.ChatbotID == nil || runner.rule.Parameters == nil || runner.rule.Parameters.Trigger == nil {
 		return fmt.Errorf("invalid runner")
@@ -231,6 +328,9 @@ func (cb *Chatbot) initRunner(runner *Runner) error {
 	case runner.rule.Parameters.Trigger.OnTimer != nil:
 		runner.run = runner.runOnTimer
 	}
+	if runner.run == nil {
+		return fmt.Errorf("runner trigger not supported")
+	}
 
 	// cb.runnersMu.Lock()
 	// defer cb.runnersMu.Unlock()
@@ -304,6 +404,20 @@ func (cb *Chatbot) initRunnerEventFromAccount(runner *Runner) error {
 	switch {
 	case fromAccount.OnFollow != nil:
 		return cb.initRunnerEventFromAccountOnFollow(runner)
+	case fromAccount.OnCategoryChange != nil:
+		return cb.initRunnerEventOnChange(runner, changeCategory)
+	case fromAccount.OnFollowerMilestone != nil, fromAccount.OnLikeMilestone != nil, fromAccount.OnViewerMilestone != nil:
+		return cb.initRunnerEventOnMilestone(runner)
+	case fromAccount.OnGoalMilestone != nil:
+		return cb.initRunnerEventFromAccountOnGoalMilestone(runner)
+	case fromAccount.OnLiveEnd != nil:
+		return cb.initRunnerEventOnLive(runner, true)
+	case fromAccount.OnLiveStart != nil:
+		return cb.initRunnerEventOnLive(runner, false)
+	case fromAccount.OnResub != nil:
+		return cb.initRunnerEventFromAccountOnResub(runner)
+	case fromAccount.OnTitleChange != nil:
+		return cb.initRunnerEventOnChange(runner, changeTitle)
 	}
 
 	return fmt.Errorf("runner event not supported")
@@ -326,16 +440,42 @@ func (cb *Chatbot) initRunnerEventFromAccountOnFollow(runner *Runner) error {
 	// TODO: should I check if channel already exists, if so delete it?
 	rcvr.onFollowMu.Lock()
 	defer rcvr.onFollowMu.Unlock()
-	rcvr.onFollow[*runner.rule.ID] = &followReceiver{apiCh, time.Now()}
+	rcvr.onFollow[*runner.rule.ID] = &followReceiver{apiCh}
 
 	return nil
 }
 
+func (cb *Chatbot) initRunnerEventFromAccountOnGoalMilestone(runner *Runner) error {
+	runner.run = runner.runOnEventFromAccountOnGoalMilestone
+
+	return cb.initRunnerEventOnGoalMilestone(runner)
+}
+
+func (cb *Chatbot) initRunnerEventFromAccountOnResub(runner *Runner) error {
+	runner.run = runner.runOnEventFromAccountOnResub
+
+	return cb.initRunnerEventOnResub(runner)
+}
+
 func (cb *Chatbot) initRunnerEventFromChannel(runner *Runner) error {
 	fromChannel := runner.rule.Parameters.Trigger.OnEvent.FromChannel
 	switch {
 	case fromChannel.OnFollow != nil:
 		return cb.initRunnerEventFromChannelOnFollow(runner)
+	case fromChannel.OnCategoryChange != nil:
+		return cb.initRunnerEventOnChange(runner, changeCategory)
+	case fromChannel.OnFollowerMilestone != nil, fromChannel.OnLikeMilestone != nil, fromChannel.OnViewerMilestone != nil:
+		return cb.initRunnerEventOnMilestone(runner)
+	case fromChannel.OnGoalMilestone != nil:
+		return cb.initRunnerEventFromChannelOnGoalMilestone(runner)
+	case fromChannel.OnLiveEnd != nil:
+		return cb.initRunnerEventOnLive(runner, true)
+	case fromChannel.OnLiveStart != nil:
+		return cb.initRunnerEventOnLive(runner, false)
+	case fromChannel.OnResub != nil:
+		return cb.initRunnerEventFromChannelOnResub(runner)
+	case fromChannel.OnTitleChange != nil:
+		return cb.initRunnerEventOnChange(runner, changeTitle)
 	}
 
 	return fmt.Errorf("runner event not supported")
@@ -358,7 +498,123 @@ func (cb *Chatbot) initRunnerEventFromChannelOnFollow(runner *Runner) error {
 	// TODO: should I check if channel already exists, if so delete it?
 	rcvr.onFollowMu.Lock()
 	defer rcvr.onFollowMu.Unlock()
-	rcvr.onFollow[*runner.rule.ID] = &followReceiver{apiCh, time.Now()}
+	rcvr.onFollow[*runner.rule.ID] = &followReceiver{apiCh}
+
+	return nil
+}
+
+func (cb *Chatbot) initRunnerEventFromChannelOnGoalMilestone(runner *Runner) error {
+	runner.run = runner.runOnEventFromChannelOnGoalMilestone
+
+	return cb.initRunnerEventOnGoalMilestone(runner)
+}
+
+func (cb *Chatbot) initRunnerEventFromChannelOnResub(runner *Runner) error {
+	runner.run = runner.runOnEventFromChannelOnResub
+
+	return cb.initRunnerEventOnResub(runner)
+}
+
+func (cb *Chatbot) initRunnerEventOnGoalMilestone(runner *Runner) error {
+	goalCh := make(chan events.GoalMilestone, 10)
+	runner.goalCh = goalCh
+
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+	rcvr, exists := cb.receivers[runner.page]
+	if !exists {
+		rcvr = newReceiver()
+		cb.receivers[runner.page] = rcvr
+	}
+
+	rcvr.onGoalMu.Lock()
+	defer rcvr.onGoalMu.Unlock()
+	rcvr.onGoal[*runner.rule.ID] = goalCh
+
+	return nil
+}
+
+// initRunnerEventOnChange registers the runner for the page's livestreams changing their title or category.
+func (cb *Chatbot) initRunnerEventOnChange(runner *Runner, kind string) error {
+	runner.run = runner.runOnChange
+
+	changeCh := make(chan events.ApiStreamChange, 10)
+	runner.changeCh = changeCh
+
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+	rcvr, exists := cb.receivers[runner.page]
+	if !exists {
+		rcvr = newReceiver()
+		cb.receivers[runner.page] = rcvr
+	}
+
+	rcvr.onChangeMu.Lock()
+	defer rcvr.onChangeMu.Unlock()
+	rcvr.onChange[*runner.rule.ID] = &changeReceiver{kind, changeCh}
+
+	return nil
+}
+
+// initRunnerEventOnLive registers the runner for the page's livestreams ending if end is true, or starting otherwise.
+func (cb *Chatbot) initRunnerEventOnLive(runner *Runner, end bool) error {
+	runner.run = runner.runOnLive
+
+	liveCh := make(chan events.ApiLive, 10)
+	runner.liveCh = liveCh
+
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+	rcvr, exists := cb.receivers[runner.page]
+	if !exists {
+		rcvr = newReceiver()
+		cb.receivers[runner.page] = rcvr
+	}
+
+	rcvr.onLiveMu.Lock()
+	defer rcvr.onLiveMu.Unlock()
+	rcvr.onLive[*runner.rule.ID] = &liveReceiver{end, liveCh}
+
+	return nil
+}
+
+func (cb *Chatbot) initRunnerEventOnMilestone(runner *Runner) error {
+	runner.run = runner.runOnMilestone
+
+	_, count := runner.rule.Parameters.Trigger.OnEvent.milestone()
+	countCh := make(chan events.ApiCount, 10)
+	runner.countCh = countCh
+
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+	rcvr, exists := cb.receivers[runner.page]
+	if !exists {
+		rcvr = newReceiver()
+		cb.receivers[runner.page] = rcvr
+	}
+
+	rcvr.onMilestoneMu.Lock()
+	defer rcvr.onMilestoneMu.Unlock()
+	rcvr.onMilestone[*runner.rule.ID] = &milestoneReceiver{count, countCh}
+
+	return nil
+}
+
+func (cb *Chatbot) initRunnerEventOnResub(runner *Runner) error {
+	subCh := make(chan events.ApiSubscriber, 10)
+	runner.subCh = subCh
+
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+	rcvr, exists := cb.receivers[runner.page]
+	if !exists {
+		rcvr = newReceiver()
+		cb.receivers[runner.page] = rcvr
+	}
+
+	rcvr.onResubMu.Lock()
+	defer rcvr.onResubMu.Unlock()
+	rcvr.onResub[*runner.rule.ID] = subCh
 
 	return nil
 }
@@ -443,28 +699,127 @@ func (cb *Chatbot) initRunnerEventFromLiveStreamOnSub(runner *Runner) error {
 	return nil
 }
 
+// RuleError is an error returned by a rule's runner.
+type RuleError struct {
+	ChatbotID int64     `json:"chatbot_id"`
+	RuleID    int64     `json:"rule_id"`
+	Error     string    `json:"error"`
+	Time      time.Time `json:"time"`
+}
+
 func (cb *Chatbot) run(ctx context.Context, runner *Runner) {
-	if runner == nil || runner.rule.ID == nil || runner.run == nil {
+	if runner == nil {
 		cb.logError.Println("invalid runner")
 		return
 	}
+	defer close(runner.done)
+
+	if runner.rule.ID == nil || runner.run == nil {
+		cb.logError.Println("invalid runner")
+		cb.removeRunner(runner)
+		cb.setState(runner, RunnerStateErrored, fmt.Errorf("invalid runner"))
+		return
+	}
+
+	sup := newSupervision(runner.supervisor)
+	var err error
+	for {
+		cb.setState(runner, RunnerStateRunning, nil)
+		err = runner.run(ctx)
+		if err == nil {
+			break
+		}
 
-	runtime.EventsEmit(cb.wails, fmt.Sprintf("ChatbotRuleActive-%d", *runner.rule.ID), true)
-	err := runner.run(ctx)
-	if err != nil {
 		prefix := fmt.Sprintf("chatbot runner for rule %d returned error:", *runner.rule.ID)
 		cb.logError.Println(prefix, err)
 		runtime.EventsEmit(cb.wails, fmt.Sprintf("ChatbotRuleError-%d", *runner.rule.ID), "Chatbot encountered an error while running this rule.")
+		if cb.onRuleError != nil && runner.rule.ChatbotID != nil {
+			cb.onRuleError(RuleError{
+				ChatbotID: *runner.rule.ChatbotID,
+				RuleID:    *runner.rule.ID,
+				Error:     err.Error(),
+				Time:      time.Now(),
+			})
+		}
+
+		if sup == nil {
+			break
+		}
+
+		backoff, restart := sup.fail(err, time.Now())
+		if !restart {
+			cb.logError.Printf("chatbot supervisor for rule %d gave up after %d failures", *runner.rule.ID, len(sup.failures))
+			runtime.EventsEmit(cb.wails, fmt.Sprintf("ChatbotRuleCircuitOpen-%d", *runner.rule.ID), RuleCircuitOpen{
+				RuleID: *runner.rule.ID,
+				Errors: sup.errors(),
+			})
+			break
+		}
+
+		cb.setState(runner, RunnerStateBackingOff, err)
+		if !runner.backoff(ctx, backoff) {
+			err = nil
+			break
+		}
 	}
 
-	err = cb.stop(&runner.rule)
+	cb.removeRunner(runner)
+
 	if err != nil {
-		prefix := fmt.Sprintf("error stopping rule %d after runner returns:", *runner.rule.ID)
-		cb.logError.Println(prefix, err)
+		cb.setState(runner, RunnerStateErrored, err)
 		return
 	}
+	cb.setState(runner, RunnerStateStopped, nil)
+}
+
+// setState records the runner's new state and notifies the UI if the state changed.
+func (cb *Chatbot) setState(runner *Runner, state RunnerState, err error) {
+	if runner.rule.ID == nil || runner.rule.ChatbotID == nil {
+		return
+	}
+
+	runner.stateMu.Lock()
+	prev := runner.state
+	runner.state = state
+	runner.stateMu.Unlock()
+	if prev == state {
+		return
+	}
+
+	status := RunnerStatus{
+		ChatbotID: *runner.rule.ChatbotID,
+		RuleID:    *runner.rule.ID,
+		State:     state,
+		Updated:   time.Now(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	cb.statesMu.Lock()
+	cb.states[status.RuleID] = status
+	cb.statesMu.Unlock()
+
+	runtime.EventsEmit(cb.wails, fmt.Sprintf("ChatbotRuleState-%d", status.RuleID), status)
+	if prev.active() != state.active() {
+		runtime.EventsEmit(cb.wails, fmt.Sprintf("ChatbotRuleActive-%d", status.RuleID), state.active())
+	}
+}
+
+// States returns the last known state of every rule that has been run.
+func (cb *Chatbot) States() []RunnerStatus {
+	cb.statesMu.Lock()
+	defer cb.statesMu.Unlock()
+
+	states := make([]RunnerStatus, 0, len(cb.states))
+	for _, status := range cb.states {
+		states = append(states, status)
+	}
+	slices.SortFunc(states, func(a, b RunnerStatus) int {
+		return cmp.Compare(a.RuleID, b.RuleID)
+	})
 
-	runtime.EventsEmit(cb.wails, fmt.Sprintf("ChatbotRuleActive-%d", *runner.rule.ID), false)
+	return states
 }
 
 func (cb *Chatbot) Running(chatbotID int64, ruleID int64) bool {
@@ -486,44 +841,90 @@ func (cb *Chatbot) Running(chatbotID int64, ruleID int64) bool {
 	return exists
 }
 
+// Stop stops the rule's runner and waits for it to finish.
 func (cb *Chatbot) Stop(rule *Rule) error {
-	err := cb.stop(rule)
+	stopped, err := cb.stop(rule)
 	if err != nil {
 		return pkgErr("", err)
 	}
 
+	if stopped != nil {
+		<-stopped.done
+	}
+
 	return nil
 }
 
-func (cb *Chatbot) stop(rule *Rule) error {
+func (cb *Chatbot) stop(rule *Rule) (*Runner, error) {
 	if rule == nil || rule.ID == nil || rule.ChatbotID == nil {
-		return fmt.Errorf("invalid rule")
+		return nil, fmt.Errorf("invalid rule")
 	}
 
-	cb.stopRunner(*rule.ChatbotID, *rule.ID)
+	ruleLock := cb.ruleLock(*rule.ID)
+	ruleLock.Lock()
+	defer ruleLock.Unlock()
 
-	return nil
+	return cb.stopRunner(*rule.ChatbotID, *rule.ID), nil
 }
 
-func (cb *Chatbot) stopRunner(chatbotID int64, ruleID int64) bool {
+// stopRunner cancels the rule's runner and detaches it from the chatbot.
+// The stopped runner is returned so the caller can wait on it, or nil if the rule was not running.
+func (cb *Chatbot) stopRunner(chatbotID int64, ruleID int64) *Runner {
 	cb.botsMu.Lock()
-	defer cb.botsMu.Unlock()
 	bot, exists := cb.bots[chatbotID]
 	if !exists {
-		return false
+		cb.botsMu.Unlock()
+		return nil
 	}
 
 	bot.runnersMu.Lock()
-	defer bot.runnersMu.Unlock()
 	runner, exists := bot.runners[ruleID]
+	if exists {
+		delete(bot.runners, ruleID)
+	}
+	bot.runnersMu.Unlock()
+	cb.botsMu.Unlock()
+
 	if !exists {
-		return false
+		return nil
 	}
 
-	stopped := true
 	runner.stop()
-	delete(bot.runners, ruleID)
+	cb.closeRunner(runner)
 
+	return runner
+}
+
+// removeRunner detaches a runner that returned on its own.
+// It is a no-op if the runner was already stopped or replaced.
+func (cb *Chatbot) removeRunner(runner *Runner) {
+	if runner.rule.ID == nil || runner.rule.ChatbotID == nil {
+		return
+	}
+
+	cb.botsMu.Lock()
+	bot, exists := cb.bots[*runner.rule.ChatbotID]
+	if !exists {
+		cb.botsMu.Unlock()
+		return
+	}
+
+	bot.runnersMu.Lock()
+	current, exists := bot.runners[*runner.rule.ID]
+	if !exists || current != runner {
+		bot.runnersMu.Unlock()
+		cb.botsMu.Unlock()
+		return
+	}
+	delete(bot.runners, *runner.rule.ID)
+	bot.runnersMu.Unlock()
+	cb.botsMu.Unlock()
+
+	runner.stop()
+	cb.closeRunner(runner)
+}
+
+func (cb *Chatbot) closeRunner(runner *Runner) {
 	switch {
 	case runner.rule.Parameters.Trigger.OnCommand != nil:
 		err := cb.closeRunnerCommand(runner)
@@ -536,8 +937,6 @@ func (cb *Chatbot) stopRunner(chatbotID int64, ruleID int64) bool {
 			cb.logError.Println("error closing runner event:", err)
 		}
 	}
-
-	return stopped
 }
 
 func (cb *Chatbot) closeRunnerCommand(runner *Runner) error {
@@ -614,6 +1013,16 @@ func (cb *Chatbot) closeRunnerEventFromAccount(runner *Runner) error {
 		}
 		close(followR.apiCh)
 		delete(rcvr.onFollow, *runner.rule.ID)
+	case fromAccount.OnCategoryChange != nil, fromAccount.OnTitleChange != nil:
+		return rcvr.closeOnChange(*runner.rule.ID)
+	case fromAccount.OnFollowerMilestone != nil, fromAccount.OnLikeMilestone != nil, fromAccount.OnViewerMilestone != nil:
+		return rcvr.closeOnMilestone(*runner.rule.ID)
+	case fromAccount.OnGoalMilestone != nil:
+		return rcvr.closeOnGoalMilestone(*runner.rule.ID)
+	case fromAccount.OnLiveEnd != nil, fromAccount.OnLiveStart != nil:
+		return rcvr.closeOnLive(*runner.rule.ID)
+	case fromAccount.OnResub != nil:
+		return rcvr.closeOnResub(*runner.rule.ID)
 	}
 
 	return nil
@@ -643,7 +1052,82 @@ func (cb *Chatbot) closeRunnerEventFromChannel(runner *Runner) error {
 		}
 		close(followR.apiCh)
 		delete(rcvr.onFollow, *runner.rule.ID)
+	case fromChannel.OnCategoryChange != nil, fromChannel.OnTitleChange != nil:
+		return rcvr.closeOnChange(*runner.rule.ID)
+	case fromChannel.OnFollowerMilestone != nil, fromChannel.OnLikeMilestone != nil, fromChannel.OnViewerMilestone != nil:
+		return rcvr.closeOnMilestone(*runner.rule.ID)
+	case fromChannel.OnGoalMilestone != nil:
+		return rcvr.closeOnGoalMilestone(*runner.rule.ID)
+	case fromChannel.OnLiveEnd != nil, fromChannel.OnLiveStart != nil:
+		return rcvr.closeOnLive(*runner.rule.ID)
+	case fromChannel.OnResub != nil:
+		return rcvr.closeOnResub(*runner.rule.ID)
+	}
+
+	return nil
+}
+
+func (rcvr *receiver) closeOnGoalMilestone(ruleID int64) error {
+	rcvr.onGoalMu.Lock()
+	defer rcvr.onGoalMu.Unlock()
+	ch, exists := rcvr.onGoal[ruleID]
+	if !exists {
+		return fmt.Errorf("channel for runner does not exist")
+	}
+	close(ch)
+	delete(rcvr.onGoal, ruleID)
+
+	return nil
+}
+
+func (rcvr *receiver) closeOnChange(ruleID int64) error {
+	rcvr.onChangeMu.Lock()
+	defer rcvr.onChangeMu.Unlock()
+	changeR, exists := rcvr.onChange[ruleID]
+	if !exists {
+		return fmt.Errorf("channel for runner does not exist")
+	}
+	close(changeR.changeCh)
+	delete(rcvr.onChange, ruleID)
+
+	return nil
+}
+
+func (rcvr *receiver) closeOnLive(ruleID int64) error {
+	rcvr.onLiveMu.Lock()
+	defer rcvr.onLiveMu.Unlock()
+	liveR, exists := rcvr.onLive[ruleID]
+	if !exists {
+		return fmt.Errorf("channel for runner does not exist")
+	}
+	close(liveR.liveCh)
+	delete(rcvr.onLive, ruleID)
+
+	return nil
+}
+
+func (rcvr *receiver) closeOnMilestone(ruleID int64) error {
+	rcvr.onMilestoneMu.Lock()
+	defer rcvr.onMilestoneMu.Unlock()
+	milestoneR, exists := rcvr.onMilestone[ruleID]
+	if !exists {
+		return fmt.Errorf("channel for runner does not exist")
 	}
+	close(milestoneR.countCh)
+	delete(rcvr.onMilestone, ruleID)
+
+	return nil
+}
+
+func (rcvr *receiver) closeOnResub(ruleID int64) error {
+	rcvr.onResubMu.Lock()
+	defer rcvr.onResubMu.Unlock()
+	ch, exists := rcvr.onResub[ruleID]
+	if !exists {
+		return fmt.Errorf("channel for runner does not exist")
+	}
+	close(ch)
+	delete(rcvr.onResub, ruleID)
 
 	return nil
 }
@@ -699,6 +1183,7 @@ func (cb *Chatbot) HandleApi(event events.Api) {
 	errs := cb.runApiFuncs(
 		event,
 		cb.handleApiFollow,
+		cb.handleApiMilestone,
 	)
 
 	for _, err := range errs {
@@ -722,7 +1207,41 @@ func (cb *Chatbot) runApiFuncs(api events.Api, fns ...apiFunc) []error {
 	return errs
 }
 
+// handleApiFollow sends the page's new follows to its follow rules.
+// The page's follow mark advances even if no rules are running,
+// so rules started later do not announce follows from before they started.
 func (cb *Chatbot) handleApiFollow(api events.Api) error {
+	if api.Stop || api.Resp == nil {
+		return nil
+	}
+
+	follows, err := parseFollows(api.Resp.Followers.RecentFollowers)
+	if err != nil {
+		return fmt.Errorf("error parsing followed_on time: %v", err)
+	}
+
+	mark, err := cb.followMark(api.Name)
+	if err != nil {
+		return fmt.Errorf("error getting follow mark: %v", err)
+	}
+
+	fresh, next, missed := newFollows(mark, follows, int(api.Resp.MaxNumResults))
+	if next == nil || next == mark {
+		return nil
+	}
+	err = cb.setFollowMark(api.Name, next)
+	if err != nil {
+		return fmt.Errorf("error saving follow mark: %v", err)
+	}
+
+	if missed {
+		cb.logError.Println("chatbot: follows may have been missed for", api.Name, "since more users followed than the API lists")
+	}
+	if len(fresh) > maxFollowAnnouncements {
+		cb.logError.Println("chatbot: announcing only the", maxFollowAnnouncements, "most recent of", len(fresh), "follows for", api.Name)
+		fresh = fresh[len(fresh)-maxFollowAnnouncements:]
+	}
+
 	cb.receiversMu.Lock()
 	defer cb.receiversMu.Unlock()
 
@@ -738,27 +1257,178 @@ func (cb *Chatbot) handleApiFollow(api events.Api) error {
 	defer rcvr.onFollowMu.Unlock()
 
 	for _, runner := range rcvr.onFollow {
-		latest := runner.latest
-		for _, follower := range api.Resp.Followers.RecentFollowers {
-			followedOn, err := time.Parse(time.RFC3339, follower.FollowedOn)
-			// TODO: fix this in the API, not in the code
-			followedOn = followedOn.Add(-4 * time.Hour)
-			if err != nil {
-				return fmt.Errorf("error parsing followed_on time: %v", err)
+		for _, f := range fresh {
+			select {
+			case runner.apiCh <- events.ApiFollower{Username: f.username}:
+			default:
+				cb.logError.Println("chatbot: follow rule is busy, dropping follow from", f.username)
+			}
+		}
+	}
+
+	return nil
+}
+
+// HandleActivity sends the page's livestreams that started or ended to its live rules,
+// and the title and category changes of its livestreams to its stream change rules.
+// ls is the livestream the event happened on, as listed in the API response the event was found in.
+func (cb *Chatbot) HandleActivity(e activity.Event, ls rumblelivestreamlib.Livestream) {
+	live := apiLive(ls)
+	change := events.ApiStreamChange{Old: e.Previous, New: e.Text, Live: live}
+	switch e.Kind {
+	case activity.KindStreamStarted, activity.KindStreamEnded:
+	case activity.KindTitleChanged:
+		change.Kind = changeTitle
+	case activity.KindCategoryChanged:
+		change.Kind = changeCategory
+	default:
+		return
+	}
+
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+
+	rcvr, exists := cb.receivers[e.Page]
+	if !exists || rcvr == nil {
+		return
+	}
+
+	if change.Kind == "" {
+		rcvr.onLiveMu.Lock()
+		defer rcvr.onLiveMu.Unlock()
+
+		end := e.Kind == activity.KindStreamEnded
+		for _, liveR := range rcvr.onLive {
+			if liveR.end != end {
+				continue
 			}
-			if followedOn.After(runner.latest) {
-				if followedOn.After(latest) {
-					latest = followedOn
-				}
-				runner.apiCh <- events.ApiFollower{Username: follower.Username}
+			select {
+			case liveR.liveCh <- live:
+			default:
+				cb.logError.Println("chatbot: live rule is busy, dropping livestream", live.Url)
 			}
 		}
-		runner.latest = latest
+
+		return
+	}
+
+	rcvr.onChangeMu.Lock()
+	defer rcvr.onChangeMu.Unlock()
+
+	for _, changeR := range rcvr.onChange {
+		if change.Kind != changeR.kind {
+			continue
+		}
+		select {
+		case changeR.changeCh <- change:
+		default:
+			cb.logError.Println("chatbot: stream change rule is busy, dropping", change.Kind, "change on", live.Url)
+		}
+	}
+}
+
+func apiLive(ls rumblelivestreamlib.Livestream) events.ApiLive {
+	live := events.ApiLive{
+		Url:      fmt.Sprintf("https://rumble.com/v%s", ls.ID),
+		Title:    ls.Title,
+		Category: ls.Categories.Primary.Title,
+	}
+
+	categories := []string{}
+	for _, c := range []rumblelivestreamlib.Category{ls.Categories.Primary, ls.Categories.Secondary} {
+		if c.Title != "" {
+			categories = append(categories, c.Title)
+		}
+	}
+	live.Categories = strings.Join(categories, ", ")
+
+	startedAt, err := events.ParseTime(ls.CreatedOn)
+	if err == nil {
+		live.StartedAt = startedAt
+	}
+
+	return live
+}
+
+// handleApiMilestone sends the page's counts to its milestone rules.
+func (cb *Chatbot) handleApiMilestone(api events.Api) error {
+	if api.Stop || api.Resp == nil {
+		return nil
+	}
+
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+
+	rcvr, exists := cb.receivers[api.Name]
+	if !exists {
+		return nil
+	}
+	if rcvr == nil {
+		return fmt.Errorf("receiver is nil for API: %s", api.Name)
+	}
+
+	rcvr.onMilestoneMu.Lock()
+	defer rcvr.onMilestoneMu.Unlock()
+
+	for _, milestoneR := range rcvr.onMilestone {
+		count, ok := milestoneR.count.count(api.Resp)
+		if !ok {
+			continue
+		}
+		select {
+		case milestoneR.countCh <- count:
+		default:
+			cb.logError.Println("chatbot: milestone rule is busy, dropping count for", api.Name)
+		}
 	}
 
 	return nil
 }
 
+// HandleGoalMilestone sends the milestone to the page's rules triggered on goal milestones.
+func (cb *Chatbot) HandleGoalMilestone(page string, milestone events.GoalMilestone) {
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+
+	rcvr, exists := cb.receivers[page]
+	if !exists || rcvr == nil {
+		return
+	}
+
+	rcvr.onGoalMu.Lock()
+	defer rcvr.onGoalMu.Unlock()
+
+	for _, ch := range rcvr.onGoal {
+		select {
+		case ch <- milestone:
+		default:
+			cb.logError.Println("chatbot: goal milestone rule is busy, dropping", milestone.Percent, "percent milestone of", milestone.Goal)
+		}
+	}
+}
+
+// HandleResub sends the subscriber to the page's rules triggered on resubs.
+func (cb *Chatbot) HandleResub(page string, subscriber events.ApiSubscriber) {
+	cb.receiversMu.Lock()
+	defer cb.receiversMu.Unlock()
+
+	rcvr, exists := cb.receivers[page]
+	if !exists || rcvr == nil {
+		return
+	}
+
+	rcvr.onResubMu.Lock()
+	defer rcvr.onResubMu.Unlock()
+
+	for _, ch := range rcvr.onResub {
+		select {
+		case ch <- subscriber:
+		default:
+			cb.logError.Println("chatbot: resub rule is busy, dropping resub from", subscriber.Username)
+		}
+	}
+}
+
 func (cb *Chatbot) HandleChat(event events.Chat) {
 	switch event.Message.Type {
 	case rumblelivestreamlib.ChatTypeMessages:
@@ -823,7 +1493,11 @@ func (cb *Chatbot) handleMessageCommand(chat events.Chat) error {
 	}
 
 	for _, runner := range runners {
-		runner <- chat
+		select {
+		case runner <- chat:
+		default:
+			cb.logError.Println("chatbot: command rule is busy, dropping message from", chat.Message.Username)
+		}
 	}
 
 	return nil
@@ -849,7 +1523,11 @@ func (cb *Chatbot) handleMessageEventRaid(chat events.Chat) error {
 	defer receiver.onRaidMu.Unlock()
 
 	for _, runner := range receiver.onRaid {
-		runner <- chat
+		select {
+		case runner <- chat:
+		default:
+			cb.logError.Println("chatbot: raid rule is busy, dropping message from", chat.Message.Username)
+		}
 	}
 
 	return nil
@@ -875,7 +1553,11 @@ func (cb *Chatbot) handleMessageEventRant(chat events.Chat) error {
 	defer receiver.onRantMu.Unlock()
 
 	for _, runner := range receiver.onRant {
-		runner <- chat
+		select {
+		case runner <- chat:
+		default:
+			cb.logError.Println("chatbot: rant rule is busy, dropping message from", chat.Message.Username)
+		}
 	}
 
 	return nil
@@ -901,7 +1583,11 @@ func (cb *Chatbot) handleMessageEventSub(chat events.Chat) error {
 	defer receiver.onSubMu.Unlock()
 
 	for _, runner := range receiver.onSub {
-		runner <- chat
+		select {
+		case runner <- chat:
+		default:
+			cb.logError.Println("chatbot: sub rule is busy, dropping message from", chat.Message.Username)
+		}
 	}
 
 	return nil
@@ -0,0 +1,127 @@
+package chatbot
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+// maxFollowAnnouncements is the most follows announced for one API response.
+// It matches the buffer of the follow runners' channels so handling a burst never blocks.
+const maxFollowAnnouncements = 10
+
+type follow struct {
+	username   string
+	followedOn time.Time
+}
+
+func parseFollows(followers []rumblelivestreamlib.Follower) ([]follow, error) {
+	follows := []follow{}
+	for _, follower := range followers {
+		followedOn, err := events.ParseTime(follower.FollowedOn)
+		if err != nil {
+			return nil, err
+		}
+		follows = append(follows, follow{follower.Username, followedOn})
+	}
+
+	return follows, nil
+}
+
+// followMark is the most recent follow handled for a page and the users who followed at that time.
+type followMark struct {
+	followedOn time.Time
+	usernames  []string
+}
+
+func (fm *followMark) handled(f follow) bool {
+	if f.followedOn.Before(fm.followedOn) {
+		return true
+	}
+	if f.followedOn.Equal(fm.followedOn) {
+		return slices.ContainsFunc(fm.usernames, func(u string) bool { return strings.EqualFold(u, f.username) })
+	}
+
+	return false
+}
+
+// newFollows returns the follows not yet handled, oldest first, and the mark advanced past them.
+// Follows are only compared with each other, never with the local clock,
+// so a wrong UTC offset in the API does not hide or repeat follows.
+// If mark is nil every follow is considered handled, so a page's past follows are not announced.
+// It reports whether follows may have been missed because none of the follows were already handled
+// even though the API listed as many follows as it returns.
+func newFollows(mark *followMark, follows []follow, window int) ([]follow, *followMark, bool) {
+	sorted := slices.Clone(follows)
+	slices.SortStableFunc(sorted, func(a, b follow) int {
+		return a.followedOn.Compare(b.followedOn)
+	})
+
+	fresh := []follow{}
+	next := mark
+	overlap := false
+	for _, f := range sorted {
+		if mark != nil && mark.handled(f) {
+			overlap = true
+			continue
+		}
+		if mark != nil {
+			fresh = append(fresh, f)
+		}
+
+		switch {
+		case next == nil || f.followedOn.After(next.followedOn):
+			next = &followMark{f.followedOn, []string{f.username}}
+		case f.followedOn.Equal(next.followedOn) && !next.handled(f):
+			next = &followMark{next.followedOn, append(slices.Clone(next.usernames), f.username)}
+		}
+	}
+
+	missed := mark != nil && !overlap && window > 0 && len(follows) >= window
+
+	return fresh, next, missed
+}
+
+// followMark returns the page's mark, loading it from the database the first time.
+func (cb *Chatbot) followMark(page string) (*followMark, error) {
+	cb.followMarksMu.Lock()
+	defer cb.followMarksMu.Unlock()
+
+	mark, loaded := cb.followMarks[page]
+	if loaded {
+		return mark, nil
+	}
+
+	fm, err := cb.followerMarkS.ByPage(page)
+	if err != nil {
+		return nil, err
+	}
+	if fm != nil && fm.FollowedOn != nil {
+		mark = &followMark{*fm.FollowedOn, fm.Usernames}
+	}
+	cb.followMarks[page] = mark
+
+	return mark, nil
+}
+
+// setFollowMark saves the page's mark.
+func (cb *Chatbot) setFollowMark(page string, mark *followMark) error {
+	cb.followMarksMu.Lock()
+	defer cb.followMarksMu.Unlock()
+
+	err := cb.followerMarkS.Upsert(&models.FollowerMark{
+		Page:       &page,
+		FollowedOn: &mark.followedOn,
+		Usernames:  mark.usernames,
+	})
+	if err != nil {
+		return err
+	}
+	cb.followMarks[page] = mark
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package chatbot
+
+import (
+	"fmt"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+type milestoneCount int
+
+const (
+	countFollowers milestoneCount = iota
+	countLikes
+	countViewers
+)
+
+// count returns the count from the API response, or false if the response does not have it,
+// like viewers while the page is not live.
+func (mc milestoneCount) count(resp *rumblelivestreamlib.LivestreamResponse) (events.ApiCount, bool) {
+	switch mc {
+	case countFollowers:
+		return events.ApiCount{Value: resp.Followers.NumFollowers}, true
+	case countLikes, countViewers:
+		if len(resp.Livestreams) == 0 {
+			return events.ApiCount{}, false
+		}
+		ls := resp.Livestreams[0]
+		count := events.ApiCount{Livestream: fmt.Sprintf("https://rumble.com/v%s", ls.ID), Value: ls.WatchingNow}
+		if mc == countLikes {
+			count.Value = ls.Likes
+		}
+		return count, true
+	}
+
+	return events.ApiCount{}, false
+}
+
+// milestone returns the event's milestone trigger and the count it watches, or nil if it is not a milestone trigger.
+func (rte *RuleTriggerEvent) milestone() (*RuleTriggerEventMilestone, milestoneCount) {
+	switch {
+	case rte.FromAccount != nil && rte.FromAccount.OnFollowerMilestone != nil:
+		return rte.FromAccount.OnFollowerMilestone, countFollowers
+	case rte.FromAccount != nil && rte.FromAccount.OnLikeMilestone != nil:
+		return rte.FromAccount.OnLikeMilestone, countLikes
+	case rte.FromAccount != nil && rte.FromAccount.OnViewerMilestone != nil:
+		return rte.FromAccount.OnViewerMilestone, countViewers
+	case rte.FromChannel != nil && rte.FromChannel.OnFollowerMilestone != nil:
+		return rte.FromChannel.OnFollowerMilestone, countFollowers
+	case rte.FromChannel != nil && rte.FromChannel.OnLikeMilestone != nil:
+		return rte.FromChannel.OnLikeMilestone, countLikes
+	case rte.FromChannel != nil && rte.FromChannel.OnViewerMilestone != nil:
+		return rte.FromChannel.OnViewerMilestone, countViewers
+	}
+
+	return nil, 0
+}
+
+func (m *RuleTriggerEventMilestone) validate() error {
+	if len(m.Thresholds) == 0 && m.Every <= 0 {
+		return fmt.Errorf("milestone needs thresholds or every")
+	}
+	for _, t := range m.Thresholds {
+		if t < 1 {
+			return fmt.Errorf("milestone threshold must be positive")
+		}
+	}
+	if m.Every < 0 {
+		return fmt.Errorf("milestone every cannot be negative")
+	}
+	if m.Hysteresis < 0 {
+		return fmt.Errorf("milestone hysteresis cannot be negative")
+	}
+
+	return nil
+}
+
+// milestoneState remembers which of a rule's milestones have fired.
+// The first count it sees, and the first count of every livestream, only sets which milestones are already passed.
+type milestoneState struct {
+	every      int64
+	fired      map[int64]bool
+	livestream string
+	started    bool
+}
+
+// update returns the highest milestone the count reached that has not fired, or false if there is none.
+func (s *milestoneState) update(m *RuleTriggerEventMilestone, count events.ApiCount) (int64, bool) {
+	value := count.Value
+	if !s.started || count.Livestream != s.livestream {
+		s.started = true
+		s.livestream = count.Livestream
+		s.fired = map[int64]bool{}
+		for _, t := range m.Thresholds {
+			if t <= value {
+				s.fired[t] = true
+			}
+		}
+		s.every = 0
+		if m.Every > 0 {
+			s.every = value / m.Every * m.Every
+		}
+		return 0, false
+	}
+
+	if m.Hysteresis > 0 {
+		for t := range s.fired {
+			if value <= t-m.Hysteresis {
+				delete(s.fired, t)
+			}
+		}
+		for s.every > 0 && value <= s.every-m.Hysteresis {
+			s.every -= m.Every
+		}
+	}
+
+	reached := int64(0)
+	for _, t := range m.Thresholds {
+		if t <= value && !s.fired[t] {
+			s.fired[t] = true
+			reached = max(reached, t)
+		}
+	}
+	if m.Every > 0 {
+		multiple := value / m.Every * m.Every
+		if multiple > s.every {
+			s.every = multiple
+			reached = max(reached, multiple)
+		}
+	}
+
+	return reached, reached > 0
+}
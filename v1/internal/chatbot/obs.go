@@ -0,0 +1,59 @@
+package chatbot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Obs controls OBS for rules with OBS actions.
+type Obs interface {
+	SetScene(name string) error
+	SetSourceVisible(scene string, source string, visible *bool) error
+	StartRecording() error
+	StopRecording() error
+}
+
+// runObs runs the rule's OBS actions.
+// args is the text after the command for command triggers.
+func (r *Runner) runObs(args string) error {
+	action := r.rule.Parameters.Obs
+	if r.obs == nil {
+		return fmt.Errorf("OBS is not configured")
+	}
+
+	if action.SetScene != nil {
+		scene := action.SetScene.Name
+		if action.SetScene.FromCommand && strings.TrimSpace(args) != "" {
+			scene = strings.TrimSpace(args)
+		}
+		if scene != "" {
+			err := r.obs.SetScene(scene)
+			if err != nil {
+				return fmt.Errorf("error setting scene: %v", err)
+			}
+		}
+	}
+
+	if action.ToggleSource != nil {
+		err := r.obs.SetSourceVisible(action.ToggleSource.Scene, action.ToggleSource.Source, action.ToggleSource.Visible)
+		if err != nil {
+			return fmt.Errorf("error setting source visibility: %v", err)
+		}
+	}
+
+	if action.StartRecording {
+		err := r.obs.StartRecording()
+		if err != nil {
+			return fmt.Errorf("error starting recording: %v", err)
+		}
+	}
+
+	if action.StopRecording {
+		err := r.obs.StopRecording()
+		if err != nil {
+			return fmt.Errorf("error stopping recording: %v", err)
+		}
+	}
+
+	return nil
+}
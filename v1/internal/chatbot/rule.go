@@ -50,6 +50,7 @@ func (r *Rule) Page() *Page {
 
 type RuleParameters struct {
 	Message *RuleMessage `json:"message"`
+	Obs     *RuleObs     `json:"obs"`
 	SendAs  *RuleSender  `json:"send_as"`
 	Trigger *RuleTrigger `json:"trigger"`
 }
@@ -124,6 +125,58 @@ func (rmf *RuleMessageFile) string() (string, error) {
 	return line, nil
 }
 
+// RuleObs controls OBS when the rule is triggered, with or without also sending a message.
+type RuleObs struct {
+	SetScene       *RuleObsScene  `json:"set_scene"`
+	StartRecording bool           `json:"start_recording"`
+	StopRecording  bool           `json:"stop_recording"`
+	ToggleSource   *RuleObsSource `json:"toggle_source"`
+}
+
+// String describes the actions for display.
+func (ro *RuleObs) String() string {
+	actions := []string{}
+	if ro.SetScene != nil {
+		if ro.SetScene.FromCommand {
+			actions = append(actions, "switch to scene from command")
+		} else {
+			actions = append(actions, "switch to scene "+ro.SetScene.Name)
+		}
+	}
+	if ro.ToggleSource != nil {
+		switch {
+		case ro.ToggleSource.Visible == nil:
+			actions = append(actions, "toggle "+ro.ToggleSource.Source)
+		case *ro.ToggleSource.Visible:
+			actions = append(actions, "show "+ro.ToggleSource.Source)
+		default:
+			actions = append(actions, "hide "+ro.ToggleSource.Source)
+		}
+	}
+	if ro.StartRecording {
+		actions = append(actions, "start recording")
+	}
+	if ro.StopRecording {
+		actions = append(actions, "stop recording")
+	}
+
+	return "OBS: " + strings.Join(actions, ", ")
+}
+
+// RuleObsScene switches to the scene Name.
+// If FromCommand is set, a command's text after the command names the scene instead, like "!scene Gaming".
+type RuleObsScene struct {
+	FromCommand bool   `json:"from_command"`
+	Name        string `json:"name"`
+}
+
+// RuleObsSource shows or hides Source in Scene, or toggles it if Visible is nil.
+type RuleObsSource struct {
+	Scene   string `json:"scene"`
+	Source  string `json:"source"`
+	Visible *bool  `json:"visible"`
+}
+
 type RuleMessageFile struct {
 	Filepath   string `json:"filepath"`
 	RandomRead bool   `json:"random_read"`
@@ -205,18 +258,58 @@ func (rte *RuleTriggerEvent) Page() *Page {
 }
 
 type RuleTriggerEventAccount struct {
-	Name     string                         `json:"name"`
-	OnFollow *RuleTriggerEventAccountFollow `json:"on_follow"`
+	Name                string                                 `json:"name"`
+	OnCategoryChange    *RuleTriggerEventAccountCategoryChange `json:"on_category_change"`
+	OnFollow            *RuleTriggerEventAccountFollow         `json:"on_follow"`
+	OnFollowerMilestone *RuleTriggerEventMilestone             `json:"on_follower_milestone"`
+	OnGoalMilestone     *RuleTriggerEventAccountGoalMilestone  `json:"on_goal_milestone"`
+	OnLikeMilestone     *RuleTriggerEventMilestone             `json:"on_like_milestone"`
+	OnLiveEnd           *RuleTriggerEventAccountLiveEnd        `json:"on_live_end"`
+	OnLiveStart         *RuleTriggerEventAccountLiveStart      `json:"on_live_start"`
+	OnResub             *RuleTriggerEventAccountResub          `json:"on_resub"`
+	OnTitleChange       *RuleTriggerEventAccountTitleChange    `json:"on_title_change"`
+	OnViewerMilestone   *RuleTriggerEventMilestone             `json:"on_viewer_milestone"`
 }
 
+type RuleTriggerEventAccountCategoryChange struct{}
 type RuleTriggerEventAccountFollow struct{}
+type RuleTriggerEventAccountGoalMilestone struct{}
+type RuleTriggerEventAccountLiveEnd struct{}
+type RuleTriggerEventAccountLiveStart struct{}
+type RuleTriggerEventAccountResub struct{}
+type RuleTriggerEventAccountTitleChange struct{}
 
 type RuleTriggerEventChannel struct {
-	Name     string                         `json:"name"`
-	OnFollow *RuleTriggerEventChannelFollow `json:"on_follow"`
+	Name                string                                 `json:"name"`
+	OnCategoryChange    *RuleTriggerEventChannelCategoryChange `json:"on_category_change"`
+	OnFollow            *RuleTriggerEventChannelFollow         `json:"on_follow"`
+	OnFollowerMilestone *RuleTriggerEventMilestone             `json:"on_follower_milestone"`
+	OnGoalMilestone     *RuleTriggerEventChannelGoalMilestone  `json:"on_goal_milestone"`
+	OnLikeMilestone     *RuleTriggerEventMilestone             `json:"on_like_milestone"`
+	OnLiveEnd           *RuleTriggerEventChannelLiveEnd        `json:"on_live_end"`
+	OnLiveStart         *RuleTriggerEventChannelLiveStart      `json:"on_live_start"`
+	OnResub             *RuleTriggerEventChannelResub          `json:"on_resub"`
+	OnTitleChange       *RuleTriggerEventChannelTitleChange    `json:"on_title_change"`
+	OnViewerMilestone   *RuleTriggerEventMilestone             `json:"on_viewer_milestone"`
 }
 
+type RuleTriggerEventChannelCategoryChange struct{}
 type RuleTriggerEventChannelFollow struct{}
+type RuleTriggerEventChannelGoalMilestone struct{}
+type RuleTriggerEventChannelLiveEnd struct{}
+type RuleTriggerEventChannelLiveStart struct{}
+type RuleTriggerEventChannelResub struct{}
+type RuleTriggerEventChannelTitleChange struct{}
+
+// RuleTriggerEventMilestone fires when a count rises to one of Thresholds, or to a multiple of Every.
+// Each milestone fires once, and can only fire again after the count falls Hysteresis or more below it,
+// so a count going back and forth across a milestone does not fire it repeatedly.
+// If Hysteresis is 0 milestones never fire again, until the next livestream for viewer and like counts.
+type RuleTriggerEventMilestone struct {
+	Thresholds []int64 `json:"thresholds"`
+	Every      int64   `json:"every"`
+	Hysteresis int64   `json:"hysteresis"`
+}
 
 type RuleTriggerEventLiveStream struct {
 	OnRaid *RuleTriggerEventLiveStreamRaid `json:"on_raid"`
@@ -5,33 +5,113 @@ import (
 	"context"
 	"fmt"
 	"html/template"
+	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/tylertravisty/rum-goggles/v1/internal/events"
 	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+type RunnerState string
+
+const (
+	RunnerStateStarting   RunnerState = "starting"
+	RunnerStateRunning    RunnerState = "running"
+	RunnerStateBackingOff RunnerState = "backing-off"
+	RunnerStateErrored    RunnerState = "errored"
+	RunnerStateStopped    RunnerState = "stopped"
+)
+
+// active reports whether a runner in this state is still attached to the chatbot.
+func (rs RunnerState) active() bool {
+	switch rs {
+	case RunnerStateStarting, RunnerStateRunning, RunnerStateBackingOff:
+		return true
+	default:
+		return false
+	}
+}
+
+type RunnerStatus struct {
+	ChatbotID int64       `json:"chatbot_id"`
+	RuleID    int64       `json:"rule_id"`
+	State     RunnerState `json:"state"`
+	Error     string      `json:"error"`
+	Updated   time.Time   `json:"updated"`
+}
+
 type Runner struct {
 	apiCh       chan events.ApiFollower
 	cancel      context.CancelFunc
 	cancelMu    sync.Mutex
+	changeCh    chan events.ApiStreamChange
 	channelID   *int
 	channelIDMu sync.Mutex
 	chatCh      chan events.Chat
 	client      *rumblelivestreamlib.Client
+	countCh     chan events.ApiCount
+	done        chan struct{}
+	goalCh      chan events.GoalMilestone
+	liveCh      chan events.ApiLive
+	logError    *log.Logger
+	milestone   milestoneState
+	obs         Obs
 	page        string
 	rule        Rule
 	run         runFunc
-	wails       context.Context
+	state       RunnerState
+	stateMu     sync.Mutex
+	subCh       chan events.ApiSubscriber
+	supervisor  *Supervisor
 }
 
+const (
+	badgeAdmin     = "admin"
+	badgeModerator = "moderator"
+)
+
 type chatFields struct {
-	ChannelName string
-	DisplayName string
-	Username    string
-	Rant        int
+	ChannelName   string
+	DisplayName   string
+	Username      string
+	Rant          int
+	Subscriptions int
+	Goal          string
+	Percent       int64
+	Progress      string
+	Target        string
+	Milestone     int64
+	Value         int64
+	Title         string
+	Category      string
+	Categories    string
+	Url           string
+	StartedAt     time.Time
+	Old           string
+	New           string
+}
+
+// act sends the rule's message, if any, and runs its OBS actions, if any.
+// args is the text after the command for command triggers.
+// OBS actions that fail are logged rather than stopping the runner, since OBS may not be running.
+func (r *Runner) act(fields *chatFields, args string) error {
+	if r.rule.Parameters.Message != nil {
+		err := r.chat(fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.rule.Parameters.Obs != nil {
+		err := r.runObs(args)
+		if err != nil {
+			r.logError.Printf("chatbot rule %d OBS action failed: %v", *r.rule.ID, err)
+		}
+	}
+
+	return nil
 }
 
 func (r *Runner) chat(fields *chatFields) error {
@@ -100,12 +180,13 @@ func (r *Runner) runOnCommand(ctx context.Context) error {
 
 	var prev time.Time
 	for {
-		runtime.EventsEmit(r.wails, fmt.Sprintf("ChatbotRuleActive-%d", *r.rule.ID), true)
-
 		select {
 		case <-ctx.Done():
 			return nil
-		case chat := <-r.chatCh:
+		case chat, ok := <-r.chatCh:
+			if !ok {
+				return nil
+			}
 			now := time.Now()
 			if now.Sub(prev) < r.rule.Parameters.Trigger.OnCommand.Timeout*time.Second {
 				break
@@ -130,6 +211,27 @@ func (r *Runner) blockCommand(chat events.Chat) bool {
 		return false
 	}
 
+	admin := false
+	mod := false
+	for _, badge := range chat.Message.Badges {
+		switch badge {
+		case badgeAdmin:
+			admin = true
+		case badgeModerator:
+			mod = true
+		}
+	}
+
+	if r.rule.Parameters.Trigger.OnCommand.Restrict.ToAdmin &&
+		!admin {
+		return true
+	}
+
+	if r.rule.Parameters.Trigger.OnCommand.Restrict.ToMod &&
+		!mod && !admin {
+		return true
+	}
+
 	if r.rule.Parameters.Trigger.OnCommand.Restrict.ToFollower &&
 		!chat.Message.IsFollower {
 		return true
@@ -167,9 +269,15 @@ func (r *Runner) handleCommand(chat events.Chat) error {
 		Rant:        chat.Message.Rant / 100,
 	}
 
-	err := r.chat(fields)
+	args := ""
+	_, after, found := strings.Cut(strings.TrimSpace(chat.Message.Text), " ")
+	if found {
+		args = after
+	}
+
+	err := r.act(fields, args)
 	if err != nil {
-		return fmt.Errorf("error sending chat: %v", err)
+		return fmt.Errorf("error acting on rule: %v", err)
 	}
 
 	return nil
@@ -184,12 +292,13 @@ func (r *Runner) runOnEventFromAccountOnFollow(ctx context.Context) error {
 	}
 
 	for {
-		runtime.EventsEmit(r.wails, fmt.Sprintf("ChatbotRuleActive-%d", *r.rule.ID), true)
-
 		select {
 		case <-ctx.Done():
 			return nil
-		case api := <-r.apiCh:
+		case api, ok := <-r.apiCh:
+			if !ok {
+				return nil
+			}
 			err := r.handleEventOnFollow(api)
 			if err != nil {
 				return fmt.Errorf("error handling event: %v", err)
@@ -207,12 +316,13 @@ func (r *Runner) runOnEventFromChannelOnFollow(ctx context.Context) error {
 	}
 
 	for {
-		runtime.EventsEmit(r.wails, fmt.Sprintf("ChatbotRuleActive-%d", *r.rule.ID), true)
-
 		select {
 		case <-ctx.Done():
 			return nil
-		case api := <-r.apiCh:
+		case api, ok := <-r.apiCh:
+			if !ok {
+				return nil
+			}
 			err := r.handleEventOnFollow(api)
 			if err != nil {
 				return fmt.Errorf("error handling event: %v", err)
@@ -227,9 +337,253 @@ func (r *Runner) handleEventOnFollow(follower events.ApiFollower) error {
 		Username:    follower.Username,
 	}
 
-	err := r.chat(fields)
+	err := r.act(fields, "")
 	if err != nil {
-		return fmt.Errorf("error sending chat: %v", err)
+		return fmt.Errorf("error acting on rule: %v", err)
+	}
+
+	return nil
+}
+
+func (r *Runner) runOnEventFromAccountOnGoalMilestone(ctx context.Context) error {
+	if r.rule.ID == nil || r.rule.Parameters == nil || r.rule.Parameters.Trigger == nil {
+		return fmt.Errorf("invalid rule")
+	}
+	if r.rule.Parameters.Trigger.OnEvent == nil || r.rule.Parameters.Trigger.OnEvent.FromAccount == nil || r.rule.Parameters.Trigger.OnEvent.FromAccount.OnGoalMilestone == nil {
+		return fmt.Errorf("event is nil")
+	}
+
+	return r.runOnGoalMilestone(ctx)
+}
+
+func (r *Runner) runOnEventFromChannelOnGoalMilestone(ctx context.Context) error {
+	if r.rule.ID == nil || r.rule.Parameters == nil || r.rule.Parameters.Trigger == nil {
+		return fmt.Errorf("invalid rule")
+	}
+	if r.rule.Parameters.Trigger.OnEvent == nil || r.rule.Parameters.Trigger.OnEvent.FromChannel == nil || r.rule.Parameters.Trigger.OnEvent.FromChannel.OnGoalMilestone == nil {
+		return fmt.Errorf("event is nil")
+	}
+
+	return r.runOnGoalMilestone(ctx)
+}
+
+func (r *Runner) runOnGoalMilestone(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case milestone, ok := <-r.goalCh:
+			if !ok {
+				return nil
+			}
+			err := r.handleEventOnGoalMilestone(milestone)
+			if err != nil {
+				return fmt.Errorf("error handling event: %v", err)
+			}
+		}
+	}
+}
+
+func (r *Runner) handleEventOnGoalMilestone(milestone events.GoalMilestone) error {
+	fields := &chatFields{
+		Goal:     milestone.Goal,
+		Percent:  milestone.Percent,
+		Progress: milestone.Progress,
+		Target:   milestone.Target,
+	}
+
+	err := r.act(fields, "")
+	if err != nil {
+		return fmt.Errorf("error acting on rule: %v", err)
+	}
+
+	return nil
+}
+
+func (r *Runner) runOnChange(ctx context.Context) error {
+	if r.rule.ID == nil || r.rule.Parameters == nil || r.rule.Parameters.Trigger == nil {
+		return fmt.Errorf("invalid rule")
+	}
+	if r.rule.Parameters.Trigger.OnEvent == nil || (r.rule.Parameters.Trigger.OnEvent.FromAccount == nil && r.rule.Parameters.Trigger.OnEvent.FromChannel == nil) {
+		return fmt.Errorf("event is nil")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case change, ok := <-r.changeCh:
+			if !ok {
+				return nil
+			}
+			err := r.handleEventOnChange(change)
+			if err != nil {
+				return fmt.Errorf("error handling event: %v", err)
+			}
+		}
+	}
+}
+
+func (r *Runner) handleEventOnChange(change events.ApiStreamChange) error {
+	fields := &chatFields{
+		Title:      change.Live.Title,
+		Category:   change.Live.Category,
+		Categories: change.Live.Categories,
+		Url:        change.Live.Url,
+		StartedAt:  change.Live.StartedAt,
+		Old:        change.Old,
+		New:        change.New,
+	}
+
+	err := r.act(fields, "")
+	if err != nil {
+		return fmt.Errorf("error acting on rule: %v", err)
+	}
+
+	return nil
+}
+
+func (r *Runner) runOnLive(ctx context.Context) error {
+	if r.rule.ID == nil || r.rule.Parameters == nil || r.rule.Parameters.Trigger == nil {
+		return fmt.Errorf("invalid rule")
+	}
+	if r.rule.Parameters.Trigger.OnEvent == nil || (r.rule.Parameters.Trigger.OnEvent.FromAccount == nil && r.rule.Parameters.Trigger.OnEvent.FromChannel == nil) {
+		return fmt.Errorf("event is nil")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case live, ok := <-r.liveCh:
+			if !ok {
+				return nil
+			}
+			err := r.handleEventOnLive(live)
+			if err != nil {
+				return fmt.Errorf("error handling event: %v", err)
+			}
+		}
+	}
+}
+
+func (r *Runner) handleEventOnLive(live events.ApiLive) error {
+	fields := &chatFields{
+		Title:      live.Title,
+		Category:   live.Category,
+		Categories: live.Categories,
+		Url:        live.Url,
+		StartedAt:  live.StartedAt,
+	}
+
+	err := r.act(fields, "")
+	if err != nil {
+		return fmt.Errorf("error acting on rule: %v", err)
+	}
+
+	return nil
+}
+
+func (r *Runner) runOnMilestone(ctx context.Context) error {
+	if r.rule.ID == nil || r.rule.Parameters == nil || r.rule.Parameters.Trigger == nil {
+		return fmt.Errorf("invalid rule")
+	}
+	if r.rule.Parameters.Trigger.OnEvent == nil {
+		return fmt.Errorf("event is nil")
+	}
+	milestone, _ := r.rule.Parameters.Trigger.OnEvent.milestone()
+	if milestone == nil {
+		return fmt.Errorf("event is nil")
+	}
+	err := milestone.validate()
+	if err != nil {
+		return fmt.Errorf("invalid milestone: %v", err)
+	}
+
+	// The milestone state is kept on the runner so that a restarted runner does not fire milestones again.
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case count, ok := <-r.countCh:
+			if !ok {
+				return nil
+			}
+			reached, fire := r.milestone.update(milestone, count)
+			if !fire {
+				continue
+			}
+			err := r.handleEventOnMilestone(reached, count.Value)
+			if err != nil {
+				return fmt.Errorf("error handling event: %v", err)
+			}
+		}
+	}
+}
+
+func (r *Runner) handleEventOnMilestone(milestone int64, value int64) error {
+	fields := &chatFields{
+		Milestone: milestone,
+		Value:     value,
+	}
+
+	err := r.act(fields, "")
+	if err != nil {
+		return fmt.Errorf("error acting on rule: %v", err)
+	}
+
+	return nil
+}
+
+func (r *Runner) runOnEventFromAccountOnResub(ctx context.Context) error {
+	if r.rule.ID == nil || r.rule.Parameters == nil || r.rule.Parameters.Trigger == nil {
+		return fmt.Errorf("invalid rule")
+	}
+	if r.rule.Parameters.Trigger.OnEvent == nil || r.rule.Parameters.Trigger.OnEvent.FromAccount == nil || r.rule.Parameters.Trigger.OnEvent.FromAccount.OnResub == nil {
+		return fmt.Errorf("event is nil")
+	}
+
+	return r.runOnResub(ctx)
+}
+
+func (r *Runner) runOnEventFromChannelOnResub(ctx context.Context) error {
+	if r.rule.ID == nil || r.rule.Parameters == nil || r.rule.Parameters.Trigger == nil {
+		return fmt.Errorf("invalid rule")
+	}
+	if r.rule.Parameters.Trigger.OnEvent == nil || r.rule.Parameters.Trigger.OnEvent.FromChannel == nil || r.rule.Parameters.Trigger.OnEvent.FromChannel.OnResub == nil {
+		return fmt.Errorf("event is nil")
+	}
+
+	return r.runOnResub(ctx)
+}
+
+func (r *Runner) runOnResub(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sub, ok := <-r.subCh:
+			if !ok {
+				return nil
+			}
+			err := r.handleEventOnResub(sub)
+			if err != nil {
+				return fmt.Errorf("error handling event: %v", err)
+			}
+		}
+	}
+}
+
+func (r *Runner) handleEventOnResub(subscriber events.ApiSubscriber) error {
+	fields := &chatFields{
+		DisplayName:   subscriber.Username,
+		Username:      subscriber.Username,
+		Subscriptions: int(subscriber.Subscriptions),
+	}
+
+	err := r.act(fields, "")
+	if err != nil {
+		return fmt.Errorf("error acting on rule: %v", err)
 	}
 
 	return nil
@@ -244,12 +598,13 @@ func (r *Runner) runOnEventFromLiveStreamOnRaid(ctx context.Context) error {
 	}
 
 	for {
-		runtime.EventsEmit(r.wails, fmt.Sprintf("ChatbotRuleActive-%d", *r.rule.ID), true)
-
 		select {
 		case <-ctx.Done():
 			return nil
-		case chat := <-r.chatCh:
+		case chat, ok := <-r.chatCh:
+			if !ok {
+				return nil
+			}
 			err := r.handleEventFromLiveStreamOnRaid(chat)
 			if err != nil {
 				return fmt.Errorf("error handling event: %v", err)
@@ -275,9 +630,9 @@ func (r *Runner) handleEventFromLiveStreamOnRaid(chat events.Chat) error {
 		Rant:        chat.Message.Rant / 100,
 	}
 
-	err := r.chat(fields)
+	err := r.act(fields, "")
 	if err != nil {
-		return fmt.Errorf("error sending chat: %v", err)
+		return fmt.Errorf("error acting on rule: %v", err)
 	}
 
 	return nil
@@ -292,12 +647,13 @@ func (r *Runner) runOnEventFromLiveStreamOnRant(ctx context.Context) error {
 	}
 
 	for {
-		runtime.EventsEmit(r.wails, fmt.Sprintf("ChatbotRuleActive-%d", *r.rule.ID), true)
-
 		select {
 		case <-ctx.Done():
 			return nil
-		case chat := <-r.chatCh:
+		case chat, ok := <-r.chatCh:
+			if !ok {
+				return nil
+			}
 			err := r.handleEventFromLiveStreamOnRant(chat)
 			if err != nil {
 				return fmt.Errorf("error handling event: %v", err)
@@ -333,9 +689,9 @@ func (r *Runner) handleEventFromLiveStreamOnRant(chat events.Chat) error {
 		Rant:        chat.Message.Rant / 100,
 	}
 
-	err := r.chat(fields)
+	err := r.act(fields, "")
 	if err != nil {
-		return fmt.Errorf("error sending chat: %v", err)
+		return fmt.Errorf("error acting on rule: %v", err)
 	}
 
 	return nil
@@ -350,12 +706,13 @@ func (r *Runner) runOnEventFromLiveStreamOnSub(ctx context.Context) error {
 	}
 
 	for {
-		runtime.EventsEmit(r.wails, fmt.Sprintf("ChatbotRuleActive-%d", *r.rule.ID), true)
-
 		select {
 		case <-ctx.Done():
 			return nil
-		case chat := <-r.chatCh:
+		case chat, ok := <-r.chatCh:
+			if !ok {
+				return nil
+			}
 			err := r.handleEventFromLiveStreamOnSub(chat)
 			if err != nil {
 				return fmt.Errorf("error handling event: %v", err)
@@ -381,9 +738,9 @@ func (r *Runner) handleEventFromLiveStreamOnSub(chat events.Chat) error {
 		Rant:        chat.Message.Rant / 100,
 	}
 
-	err := r.chat(fields)
+	err := r.act(fields, "")
 	if err != nil {
-		return fmt.Errorf("error sending chat: %v", err)
+		return fmt.Errorf("error acting on rule: %v", err)
 	}
 
 	return nil
@@ -398,10 +755,9 @@ func (r *Runner) runOnTimer(ctx context.Context) error {
 	}
 
 	for {
-		runtime.EventsEmit(r.wails, fmt.Sprintf("ChatbotRuleActive-%d", *r.rule.ID), true)
-		err := r.chat(nil)
+		err := r.act(nil, "")
 		if err != nil {
-			return fmt.Errorf("error sending chat: %v", err)
+			return fmt.Errorf("error acting on rule: %v", err)
 		}
 
 		trigger := time.NewTimer(*r.rule.Parameters.Trigger.OnTimer * time.Second)
@@ -414,6 +770,50 @@ func (r *Runner) runOnTimer(ctx context.Context) error {
 	}
 }
 
+// backoff waits before the runner is restarted, discarding any events received in the meantime.
+// It returns false if the runner was stopped while waiting.
+func (r *Runner) backoff(ctx context.Context, wait time.Duration) bool {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return true
+		case _, ok := <-r.apiCh:
+			if !ok {
+				return false
+			}
+		case _, ok := <-r.changeCh:
+			if !ok {
+				return false
+			}
+		case _, ok := <-r.chatCh:
+			if !ok {
+				return false
+			}
+		case _, ok := <-r.countCh:
+			if !ok {
+				return false
+			}
+		case _, ok := <-r.goalCh:
+			if !ok {
+				return false
+			}
+		case _, ok := <-r.liveCh:
+			if !ok {
+				return false
+			}
+		case _, ok := <-r.subCh:
+			if !ok {
+				return false
+			}
+		}
+	}
+}
+
 func (r *Runner) stop() {
 	r.cancelMu.Lock()
 	if r.cancel != nil {
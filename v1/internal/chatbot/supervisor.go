@@ -0,0 +1,113 @@
+package chatbot
+
+import (
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+)
+
+const (
+	defaultSupervisorMaxFailures   = 5
+	defaultSupervisorFailureWindow = 10 * time.Minute
+	defaultSupervisorBackoffMin    = 5 * time.Second
+	defaultSupervisorBackoffMax    = 5 * time.Minute
+)
+
+// Supervisor restarts a failed runner with exponential backoff.
+// Once MaxFailures failures occur within FailureWindow, the circuit opens and the runner is given up on.
+type Supervisor struct {
+	MaxFailures   int
+	FailureWindow time.Duration
+	BackoffMin    time.Duration
+	BackoffMax    time.Duration
+}
+
+// newSupervisor converts the stored settings into a Supervisor.
+// Nil is returned if supervision is disabled.
+func newSupervisor(ms *models.ChatbotSupervisor) *Supervisor {
+	if ms == nil || ms.Enabled == nil || !*ms.Enabled {
+		return nil
+	}
+
+	s := &Supervisor{
+		MaxFailures:   defaultSupervisorMaxFailures,
+		FailureWindow: defaultSupervisorFailureWindow,
+		BackoffMin:    defaultSupervisorBackoffMin,
+		BackoffMax:    defaultSupervisorBackoffMax,
+	}
+	if ms.MaxFailures != nil && *ms.MaxFailures > 0 {
+		s.MaxFailures = int(*ms.MaxFailures)
+	}
+	if ms.FailureWindow != nil && *ms.FailureWindow > 0 {
+		s.FailureWindow = time.Duration(*ms.FailureWindow) * time.Second
+	}
+	if ms.BackoffMin != nil && *ms.BackoffMin > 0 {
+		s.BackoffMin = time.Duration(*ms.BackoffMin) * time.Second
+	}
+	if ms.BackoffMax != nil && *ms.BackoffMax > 0 {
+		s.BackoffMax = time.Duration(*ms.BackoffMax) * time.Second
+	}
+	if s.BackoffMax < s.BackoffMin {
+		s.BackoffMax = s.BackoffMin
+	}
+
+	return s
+}
+
+type supervisorFailure struct {
+	err  string
+	time time.Time
+}
+
+type supervision struct {
+	failures []supervisorFailure
+	settings Supervisor
+}
+
+func newSupervision(settings *Supervisor) *supervision {
+	if settings == nil {
+		return nil
+	}
+
+	return &supervision{settings: *settings}
+}
+
+// fail records a runner failure. It returns how long to wait before restarting,
+// or false if the circuit is open and the runner should not be restarted.
+func (s *supervision) fail(err error, now time.Time) (time.Duration, bool) {
+	s.failures = append(s.failures, supervisorFailure{err.Error(), now})
+
+	start := 0
+	for start < len(s.failures) && now.Sub(s.failures[start].time) > s.settings.FailureWindow {
+		start++
+	}
+	s.failures = s.failures[start:]
+
+	if len(s.failures) >= s.settings.MaxFailures {
+		return 0, false
+	}
+
+	backoff := s.settings.BackoffMin
+	for i := 1; i < len(s.failures) && backoff < s.settings.BackoffMax; i++ {
+		backoff = backoff * 2
+	}
+	if backoff > s.settings.BackoffMax {
+		backoff = s.settings.BackoffMax
+	}
+
+	return backoff, true
+}
+
+func (s *supervision) errors() []string {
+	errs := make([]string, len(s.failures))
+	for i, failure := range s.failures {
+		errs[i] = failure.err
+	}
+
+	return errs
+}
+
+type RuleCircuitOpen struct {
+	RuleID int64    `json:"rule_id"`
+	Errors []string `json:"errors"`
+}
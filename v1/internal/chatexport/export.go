@@ -0,0 +1,281 @@
+package chatexport
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSONL  Format = "jsonl"
+	FormatSRT    Format = "srt"
+	FormatWebVTT Format = "vtt"
+)
+
+// Formats lists every supported export format.
+var Formats = []Format{FormatCSV, FormatJSONL, FormatSRT, FormatWebVTT}
+
+func (f Format) Valid() bool {
+	for _, format := range Formats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Extension returns the file extension for the format, including the leading dot.
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+func (f Format) Name() string {
+	switch f {
+	case FormatCSV:
+		return "CSV"
+	case FormatJSONL:
+		return "JSON Lines"
+	case FormatSRT:
+		return "SubRip subtitles"
+	case FormatWebVTT:
+		return "WebVTT subtitles"
+	default:
+		return string(f)
+	}
+}
+
+type Kind string
+
+const (
+	KindCategoryChange Kind = "category_change"
+	KindMessage        Kind = "message"
+	KindRant           Kind = "rant"
+	KindRaid           Kind = "raid"
+	KindSub            Kind = "sub"
+	KindTitleChange    Kind = "title_change"
+)
+
+// cueDuration is how long each chat message stays on screen in subtitle exports.
+const cueDuration = 5 * time.Second
+
+// Record is a single exported chat message or event.
+// Offset is the time since the start of the stream.
+// For title and category changes Text is the new value and Previous the old one.
+type Record struct {
+	Time        time.Time     `json:"time"`
+	Offset      time.Duration `json:"-"`
+	Kind        Kind          `json:"kind"`
+	Username    string        `json:"username"`
+	ChannelName string        `json:"channel_name,omitempty"`
+	Badges      []string      `json:"badges,omitempty"`
+	Rant        int64         `json:"rant,omitempty"`
+	Text        string        `json:"text"`
+	Previous    string        `json:"previous,omitempty"`
+}
+
+// Records converts stored chat messages and the livestream's title and category changes into export records
+// timed against start, oldest first.
+// Records before start have an offset of zero.
+func Records(messages []models.ChatMessage, changes []models.StreamChange, start time.Time) []Record {
+	records := make([]Record, 0, len(messages)+len(changes))
+	for _, message := range messages {
+		r := Record{Kind: KindMessage}
+		if message.Time != nil {
+			r.Time = *message.Time
+			if r.Time.After(start) {
+				r.Offset = r.Time.Sub(start)
+			}
+		}
+		if message.Username != nil {
+			r.Username = *message.Username
+		}
+		if message.ChannelName != nil {
+			r.ChannelName = *message.ChannelName
+		}
+		if message.Badges != nil && *message.Badges != "" {
+			r.Badges = strings.Split(*message.Badges, ",")
+		}
+		if message.Text != nil {
+			r.Text = *message.Text
+		}
+
+		switch {
+		case message.Rant != nil && *message.Rant > 0:
+			r.Kind = KindRant
+			r.Rant = *message.Rant
+		case message.Raid != nil && *message.Raid:
+			r.Kind = KindRaid
+		case message.Sub != nil && *message.Sub:
+			r.Kind = KindSub
+		}
+
+		records = append(records, r)
+	}
+
+	for _, change := range changes {
+		if change.Kind == nil || change.Time == nil {
+			continue
+		}
+
+		r := Record{Kind: KindTitleChange, Time: *change.Time}
+		if *change.Kind == models.StreamChangeKindCategory {
+			r.Kind = KindCategoryChange
+		}
+		if r.Time.After(start) {
+			r.Offset = r.Time.Sub(start)
+		}
+		if change.Value != nil {
+			r.Text = *change.Value
+		}
+		if change.Previous != nil {
+			r.Previous = *change.Previous
+		}
+
+		records = append(records, r)
+	}
+	slices.SortStableFunc(records, func(a, b Record) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+
+	return records
+}
+
+// Write writes the records to w in the given format.
+func Write(w io.Writer, format Format, records []Record) error {
+	var err error
+	switch format {
+	case FormatCSV:
+		err = writeCSV(w, records)
+	case FormatJSONL:
+		err = writeJSONL(w, records)
+	case FormatSRT:
+		err = writeSRT(w, records)
+	case FormatWebVTT:
+		err = writeWebVTT(w, records)
+	default:
+		return pkgErr("", fmt.Errorf("unsupported format: %s", format))
+	}
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error writing %s", format.Name()), err)
+	}
+
+	return nil
+}
+
+func writeCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{"time", "offset_seconds", "kind", "username", "channel_name", "badges", "rant_cents", "text", "previous"})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		err = cw.Write([]string{
+			r.Time.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(r.Offset.Seconds(), 'f', 3, 64),
+			string(r.Kind),
+			r.Username,
+			r.ChannelName,
+			strings.Join(r.Badges, ","),
+			strconv.FormatInt(r.Rant, 10),
+			r.Text,
+			r.Previous,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSONL(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		err := enc.Encode(struct {
+			Record
+			OffsetSeconds float64 `json:"offset_seconds"`
+		}{r, r.Offset.Seconds()})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeSRT(w io.Writer, records []Record) error {
+	for i, r := range records {
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, srtTimestamp(r.Offset), srtTimestamp(r.Offset+cueDuration), cueText(r))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeWebVTT(w io.Writer, records []Record) error {
+	_, err := io.WriteString(w, "WEBVTT\n\n")
+	if err != nil {
+		return err
+	}
+
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	for i, r := range records {
+		_, err = fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, vttTimestamp(r.Offset), vttTimestamp(r.Offset+cueDuration), escaper.Replace(cueText(r)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cueText(r Record) string {
+	text := strings.Join(strings.Fields(r.Text), " ")
+
+	switch r.Kind {
+	case KindRant:
+		return fmt.Sprintf("[$%d.%02d rant] %s: %s", r.Rant/100, r.Rant%100, r.Username, text)
+	case KindRaid:
+		return fmt.Sprintf("[raid] %s: %s", r.Username, text)
+	case KindSub:
+		return fmt.Sprintf("[sub] %s: %s", r.Username, text)
+	case KindTitleChange:
+		return fmt.Sprintf("[title] %s", text)
+	case KindCategoryChange:
+		return fmt.Sprintf("[category] %s", text)
+	default:
+		return fmt.Sprintf("%s: %s", r.Username, text)
+	}
+}
+
+func srtTimestamp(d time.Duration) string {
+	h, m, s, ms := splitDuration(d)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
+
+func vttTimestamp(d time.Duration) string {
+	h, m, s, ms := splitDuration(d)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
+
+func splitDuration(d time.Duration) (int64, int64, int64, int64) {
+	ms := d.Milliseconds()
+	return ms / 3600000, ms / 60000 % 60, ms / 1000 % 60, ms % 1000
+}
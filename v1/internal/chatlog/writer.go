@@ -0,0 +1,244 @@
+package chatlog
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+const (
+	writerBufferSize    = 4096
+	writerBatchSize     = 200
+	writerFlushInterval = 2 * time.Second
+)
+
+// Writer stores chat messages and updates the viewer directory in batches on a background goroutine,
+// so that the chat processing loop is never blocked by the database.
+type Writer struct {
+	ch           chan entry
+	chatMessageS models.ChatMessageService
+	closed       bool
+	closedMu     sync.Mutex
+	done         chan struct{}
+	flushCh      chan chan struct{}
+	logError     *log.Logger
+	viewerS      models.ViewerService
+}
+
+type entry struct {
+	message models.ChatMessage
+	viewer  models.ViewerObservation
+}
+
+func NewWriter(chatMessageS models.ChatMessageService, viewerS models.ViewerService, logError *log.Logger) *Writer {
+	w := &Writer{
+		ch:           make(chan entry, writerBufferSize),
+		chatMessageS: chatMessageS,
+		done:         make(chan struct{}),
+		flushCh:      make(chan chan struct{}),
+		logError:     logError,
+		viewerS:      viewerS,
+	}
+
+	go w.run()
+
+	return w
+}
+
+// Write queues the chat event to be stored under livestream, the url of the livestream the message was sent to.
+// The event's url may be a page's static live url, which is the same for every livestream of the page.
+// If the queue is full, the message is dropped rather than blocking the caller.
+func (w *Writer) Write(livestream string, event events.Chat) {
+	message, ok := chatMessage(livestream, event)
+	if !ok {
+		return
+	}
+	e := entry{message, viewerObservation(livestream, event)}
+
+	w.closedMu.Lock()
+	defer w.closedMu.Unlock()
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.ch <- e:
+	default:
+		w.logError.Println(pkgErr("", fmt.Errorf("chat message queue is full, dropping message")))
+	}
+}
+
+// Flush stores all queued messages before returning.
+func (w *Writer) Flush() {
+	w.closedMu.Lock()
+	closed := w.closed
+	w.closedMu.Unlock()
+	if closed {
+		return
+	}
+
+	// The lock is not held while waiting for the writer, so that Write does not block on a batch being stored.
+	// If the writer is closed in the meantime, closing stores the queued messages instead.
+	flushed := make(chan struct{})
+	select {
+	case w.flushCh <- flushed:
+	case <-w.done:
+		return
+	}
+
+	<-flushed
+}
+
+// Close flushes queued messages and stops the writer.
+func (w *Writer) Close() {
+	w.closedMu.Lock()
+	if w.closed {
+		w.closedMu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.ch)
+	w.closedMu.Unlock()
+
+	<-w.done
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(writerFlushInterval)
+	defer ticker.Stop()
+
+	batch := []entry{}
+	for {
+		select {
+		case e, ok := <-w.ch:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+
+			batch = append(batch, e)
+			if len(batch) >= writerBatchSize {
+				w.flush(batch)
+				batch = []entry{}
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = []entry{}
+			}
+		case flushed := <-w.flushCh:
+			batch = w.drain(batch)
+			w.flush(batch)
+			batch = []entry{}
+			close(flushed)
+		}
+	}
+}
+
+// drain appends the queued entries to the batch without waiting for more.
+func (w *Writer) drain(batch []entry) []entry {
+	for {
+		select {
+		case e, ok := <-w.ch:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, e)
+		default:
+			return batch
+		}
+	}
+}
+
+func (w *Writer) flush(batch []entry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	messages := make([]models.ChatMessage, len(batch))
+	for i, e := range batch {
+		messages[i] = e.message
+	}
+
+	err := w.chatMessageS.CreateBatch(messages)
+	if err != nil {
+		w.logError.Println(pkgErr("error storing chat messages", err))
+		return
+	}
+
+	// Only new messages count towards the viewer directory, so replayed chat is not counted twice.
+	observations := []models.ViewerObservation{}
+	for i, message := range messages {
+		if message.ID != nil {
+			observations = append(observations, batch[i].viewer)
+		}
+	}
+	if len(observations) == 0 {
+		return
+	}
+
+	err = w.viewerS.Observe(observations)
+	if err != nil {
+		w.logError.Println(pkgErr("error updating viewers", err))
+	}
+}
+
+func chatMessage(livestream string, event events.Chat) (models.ChatMessage, bool) {
+	view := event.Message
+	if livestream == "" || view.Username == "" || view.Time.IsZero() {
+		return models.ChatMessage{}, false
+	}
+
+	username := view.Username
+	channelName := view.ChannelName
+	badges := strings.Join(view.Badges, ",")
+	rant := int64(view.Rant)
+	raid := view.Raid
+	sub := view.Sub
+	text := view.Text
+	t := view.Time.UTC()
+
+	return models.ChatMessage{
+		Livestream:  &livestream,
+		Username:    &username,
+		ChannelName: &channelName,
+		Badges:      &badges,
+		Rant:        &rant,
+		Raid:        &raid,
+		Sub:         &sub,
+		Text:        &text,
+		Time:        &t,
+	}, true
+}
+
+// viewerObservation records the viewer in the livestream, so that each livestream counts once towards their streams attended.
+func viewerObservation(livestream string, event events.Chat) models.ViewerObservation {
+	view := event.Message
+
+	subscriber := false
+	for _, badge := range view.Badges {
+		if badge == rumblelivestreamlib.ChatBadgeLocalsSupporter || badge == rumblelivestreamlib.ChatBadgeRecurringSubscription {
+			subscriber = true
+		}
+	}
+
+	return models.ViewerObservation{
+		Username:    view.Username,
+		ChannelName: view.ChannelName,
+		Badges:      strings.Join(view.Badges, ","),
+		Color:       view.Color,
+		Follower:    view.IsFollower,
+		Subscriber:  subscriber,
+		Livestream:  livestream,
+		RantCents:   int64(view.Rant),
+		Time:        view.Time,
+	}
+}
@@ -11,10 +11,15 @@ const (
 	configDirNix = ".rum-goggles"
 	configDirWin = "RumGoggles"
 
-	imageDir = "images"
-
-	logFile = "rumgoggles.log"
-	sqlFile = "rumgoggles.db"
+	imageDir    = "images"
+	textFileDir = "text"
+
+	discordFile   = "discord.json"
+	logFile       = "rumgoggles.log"
+	obsFile       = "obs.json"
+	overlayFile   = "overlay.json"
+	sqlFile       = "rumgoggles.db"
+	textFilesFile = "textfiles.json"
 )
 
 func Database() (string, error) {
@@ -34,6 +39,16 @@ func Database() (string, error) {
 	return path, nil
 }
 
+// DiscordSettings returns the path of the Discord settings file, which may not exist yet.
+func DiscordSettings() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", pkgErr("error getting config directory", err)
+	}
+
+	return filepath.Join(dir, discordFile), nil
+}
+
 func ImageDir() (string, error) {
 	cfgDir, err := configDir()
 	if err != nil {
@@ -69,6 +84,53 @@ func Log() (*os.File, error) {
 	return f, nil
 }
 
+// ObsSettings returns the path of the OBS settings file, which may not exist yet.
+func ObsSettings() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", pkgErr("error getting config directory", err)
+	}
+
+	return filepath.Join(dir, obsFile), nil
+}
+
+// OverlaySettings returns the path of the overlay settings file, which may not exist yet.
+func OverlaySettings() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", pkgErr("error getting config directory", err)
+	}
+
+	return filepath.Join(dir, overlayFile), nil
+}
+
+// TextFileDir returns the directory the text files for streaming software are written to, creating it if needed.
+func TextFileDir() (string, error) {
+	cfgDir, err := configDir()
+	if err != nil {
+		return "", pkgErr("error getting config directory", err)
+	}
+
+	dir := filepath.Join(cfgDir, textFileDir)
+
+	err = os.MkdirAll(dir, 0750)
+	if err != nil {
+		return "", pkgErr("error making directory", err)
+	}
+
+	return dir, nil
+}
+
+// TextFileSettings returns the path of the text file settings file, which may not exist yet.
+func TextFileSettings() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", pkgErr("error getting config directory", err)
+	}
+
+	return filepath.Join(dir, textFilesFile), nil
+}
+
 func configDir() (string, error) {
 	var dir string
 	var err error
@@ -0,0 +1,469 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+const (
+	// embedColor is Rumble's green.
+	embedColor = 0x85c742
+
+	maxRateLimitRetries = 3
+	queueSize           = 50
+	requestTimeout      = 10 * time.Second
+	responseErrSize     = 512
+	testTimeout         = 30 * time.Second
+)
+
+// message is the body of a Discord webhook request.
+type message struct {
+	Content string  `json:"content,omitempty"`
+	Embeds  []embed `json:"embeds,omitempty"`
+}
+
+type embed struct {
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Url         string       `json:"url,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Timestamp   string       `json:"timestamp,omitempty"`
+	Fields      []embedField `json:"fields,omitempty"`
+}
+
+type embedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type post struct {
+	sink     string
+	endpoint string
+	msg      message
+}
+
+// Notifier posts go-live, rant and raid notifications to the Discord sinks in its settings.
+// Messages are posted in the background, in order, waiting out Discord's rate limits.
+type Notifier struct {
+	cancel      context.CancelFunc
+	client      *http.Client
+	done        chan struct{}
+	globalUntil time.Time
+	limits      map[string]time.Time
+	limitsMu    sync.Mutex
+	logError    *log.Logger
+	mu          sync.Mutex
+	queue       chan post
+	runMu       sync.Mutex
+	settings    Settings
+}
+
+// NewNotifier creates a notifier that sends requests with client, or a default client if nil.
+func NewNotifier(settings Settings, client *http.Client, logError *log.Logger) *Notifier {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+
+	return &Notifier{
+		client:   client,
+		limits:   map[string]time.Time{},
+		logError: logError,
+		queue:    make(chan post, queueSize),
+		settings: settings,
+	}
+}
+
+func (n *Notifier) Settings() Settings {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.settings
+}
+
+func (n *Notifier) SetSettings(settings Settings) error {
+	err := settings.Validate()
+	if err != nil {
+		return pkgErr("invalid settings", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.settings = settings
+
+	return nil
+}
+
+// Start posts queued messages in the background.
+func (n *Notifier) Start() {
+	n.runMu.Lock()
+	defer n.runMu.Unlock()
+
+	if n.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+	n.done = make(chan struct{})
+	go n.run(ctx, n.done)
+}
+
+// Stop stops posting messages and waits for the notifier to stop.
+// Messages still queued are dropped.
+func (n *Notifier) Stop() {
+	n.runMu.Lock()
+	defer n.runMu.Unlock()
+
+	if n.cancel == nil {
+		return
+	}
+
+	n.cancel()
+	<-n.done
+	n.cancel = nil
+}
+
+func (n *Notifier) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case p := <-n.queue:
+			err := n.send(ctx, p.endpoint, p.msg)
+			if err != nil && ctx.Err() == nil {
+				n.logError.Printf("error posting to Discord sink %q: %v", p.sink, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Live posts the go-live notification for the page's livestream to the sinks that want it.
+func (n *Notifier) Live(page string, ls rumblelivestreamlib.Livestream) {
+	fields := Fields{
+		Page:     page,
+		Title:    ls.Title,
+		Category: category(ls.Categories),
+		Url:      fmt.Sprintf("https://rumble.com/v%s", ls.ID),
+	}
+	startedAt, err := events.ParseTime(ls.CreatedOn)
+	if err != nil {
+		startedAt = time.Now()
+	}
+
+	for _, sink := range n.sinks(page) {
+		if !sink.Live {
+			continue
+		}
+
+		msg, err := liveMessage(sink, fields, startedAt)
+		if err != nil {
+			n.logError.Printf("error executing Discord sink %q live templates: %v", sink.Name, err)
+			continue
+		}
+		n.enqueue(sink, msg)
+	}
+}
+
+// Chat posts the rant or raid in the page's chat to the sinks that want it.
+func (n *Notifier) Chat(page string, livestream string, view rumblelivestreamlib.ChatView) {
+	if view.Rant <= 0 && !view.Raid {
+		return
+	}
+
+	fields := Fields{
+		Page:     page,
+		Url:      livestream,
+		Username: view.Username,
+		Amount:   fmt.Sprintf("$%d.%02d", view.Rant/100, view.Rant%100),
+		Text:     view.Text,
+	}
+
+	for _, sink := range n.sinks(page) {
+		text := ""
+		switch {
+		case view.Rant > 0 && sink.Rants && int64(view.Rant) >= sink.MinRantCents:
+			text = sink.Templates.Rant
+		case view.Raid && sink.Raids:
+			text = sink.Templates.Raid
+		default:
+			continue
+		}
+
+		content, err := execute(text, fields)
+		if err != nil {
+			n.logError.Printf("error executing Discord sink %q template: %v", sink.Name, err)
+			continue
+		}
+		if content == "" {
+			continue
+		}
+		n.enqueue(sink, message{Content: content})
+	}
+}
+
+// Test posts a sample go-live notification to the sink, which does not need to be saved, and returns any error.
+func (n *Notifier) Test(sink Sink) error {
+	err := sink.Validate()
+	if err != nil {
+		return pkgErr("invalid sink", err)
+	}
+
+	page := sink.Page
+	if page == "" {
+		page = "Rum Goggles"
+	}
+	msg, err := liveMessage(sink, Fields{
+		Page:     page,
+		Title:    "Test notification from Rum Goggles",
+		Category: "Gaming",
+		Url:      "https://rumble.com",
+	}, time.Now())
+	if err != nil {
+		return pkgErr("error executing live templates", err)
+	}
+
+	endpoint, err := n.endpoint(sink)
+	if err != nil {
+		return pkgErr("", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	err = n.send(ctx, endpoint, msg)
+	if err != nil {
+		return pkgErr("error posting message", err)
+	}
+
+	return nil
+}
+
+// sinks returns the enabled sinks for the page.
+func (n *Notifier) sinks(page string) []Sink {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	sinks := []Sink{}
+	for _, sink := range n.settings.Sinks {
+		if sink.Enabled && (sink.Page == "" || sink.Page == page) {
+			sinks = append(sinks, sink)
+		}
+	}
+
+	return sinks
+}
+
+func (n *Notifier) endpoint(sink Sink) (string, error) {
+	path, err := webhookPath(sink.WebhookUrl)
+	if err != nil {
+		return "", err
+	}
+
+	n.mu.Lock()
+	base := n.settings.BaseUrl
+	n.mu.Unlock()
+	if base == "" {
+		base = DefaultBaseUrl
+	}
+
+	return strings.TrimSuffix(base, "/") + path, nil
+}
+
+func (n *Notifier) enqueue(sink Sink, msg message) {
+	endpoint, err := n.endpoint(sink)
+	if err != nil {
+		n.logError.Printf("error getting Discord sink %q endpoint: %v", sink.Name, err)
+		return
+	}
+
+	select {
+	case n.queue <- post{sink: sink.Name, endpoint: endpoint, msg: msg}:
+	default:
+		n.logError.Printf("Discord queue is full, dropping message for sink %q", sink.Name)
+	}
+}
+
+// send posts the message to the endpoint, waiting out rate limits.
+// A message that is rate limited is retried after the time Discord asks for, up to maxRateLimitRetries times.
+func (n *Notifier) send(ctx context.Context, endpoint string, msg message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("error marshaling message: %v", err)
+	}
+
+	for retries := 0; ; retries++ {
+		err := n.wait(ctx, endpoint)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("error creating request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Rum-Goggles")
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error sending request: %v", err)
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, responseErrSize))
+		resp.Body.Close()
+
+		n.updateLimits(endpoint, resp, respBody, time.Now())
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests && retries < maxRateLimitRetries:
+			continue
+		case resp.StatusCode < 200 || resp.StatusCode > 299:
+			return fmt.Errorf("received status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+		}
+
+		return nil
+	}
+}
+
+// wait blocks until the endpoint and the global rate limit allow another request, or ctx is done.
+func (n *Notifier) wait(ctx context.Context, endpoint string) error {
+	n.limitsMu.Lock()
+	until := n.limits[endpoint]
+	if n.globalUntil.After(until) {
+		until = n.globalUntil
+	}
+	n.limitsMu.Unlock()
+
+	d := time.Until(until)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// updateLimits records when the endpoint, or every endpoint if the limit is global, can be sent to again.
+// Discord sets X-RateLimit-Remaining and X-RateLimit-Reset-After on every response,
+// and Retry-After or a retry_after field on responses with status 429.
+func (n *Notifier) updateLimits(endpoint string, resp *http.Response, body []byte, now time.Time) {
+	var until time.Time
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		resetAfter, err := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Reset-After"), 64)
+		if err == nil {
+			until = now.Add(seconds(resetAfter))
+		}
+	}
+
+	global := false
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+		var limited struct {
+			RetryAfter float64 `json:"retry_after"`
+			Global     bool    `json:"global"`
+		}
+		if json.Unmarshal(body, &limited) == nil {
+			global = limited.Global
+			if limited.RetryAfter > 0 {
+				retryAfter, err = limited.RetryAfter, nil
+			}
+		}
+		if err != nil {
+			retryAfter = 1
+		}
+		until = now.Add(seconds(retryAfter))
+		global = global || resp.Header.Get("X-RateLimit-Global") == "true"
+	}
+
+	if until.IsZero() {
+		return
+	}
+
+	n.limitsMu.Lock()
+	defer n.limitsMu.Unlock()
+
+	if global {
+		n.globalUntil = until
+		return
+	}
+	n.limits[endpoint] = until
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func liveMessage(sink Sink, fields Fields, startedAt time.Time) (message, error) {
+	content, err := execute(sink.Templates.LiveContent, fields)
+	if err != nil {
+		return message{}, err
+	}
+	title, err := execute(sink.Templates.LiveTitle, fields)
+	if err != nil {
+		return message{}, err
+	}
+	description, err := execute(sink.Templates.LiveDescription, fields)
+	if err != nil {
+		return message{}, err
+	}
+
+	e := embed{
+		Title:       title,
+		Description: description,
+		Url:         fields.Url,
+		Color:       embedColor,
+		Timestamp:   startedAt.UTC().Format(time.RFC3339),
+	}
+	if fields.Category != "" {
+		e.Fields = []embedField{{Name: "Category", Value: fields.Category, Inline: true}}
+	}
+
+	return message{Content: content, Embeds: []embed{e}}, nil
+}
+
+func execute(text string, fields Fields) (string, error) {
+	tmpl, err := template.New("discord").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, fields)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// category returns the livestream's categories as they are displayed, like "Gaming, Minecraft".
+func category(c rumblelivestreamlib.Categories) string {
+	categories := []string{}
+	for _, cat := range []rumblelivestreamlib.Category{c.Primary, c.Secondary} {
+		if cat.Title != "" {
+			categories = append(categories, cat.Title)
+		}
+	}
+
+	return strings.Join(categories, ", ")
+}
@@ -0,0 +1,324 @@
+package discord
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+// response is what the stand-in Discord server answers a request with.
+type response struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+type received struct {
+	path string
+	time time.Time
+	msg  message
+}
+
+// standIn is a stand-in for the Discord API that answers requests with its responses in order,
+// and with 204 No Content once they run out.
+type standIn struct {
+	mu        sync.Mutex
+	received  []received
+	responses []response
+	server    *httptest.Server
+}
+
+func newStandIn(t *testing.T, responses ...response) *standIn {
+	t.Helper()
+
+	s := &standIn{responses: responses}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+func (s *standIn) serve(w http.ResponseWriter, r *http.Request) {
+	var msg message
+	json.NewDecoder(r.Body).Decode(&msg)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = append(s.received, received{path: r.URL.Path, time: time.Now(), msg: msg})
+
+	resp := response{status: http.StatusNoContent}
+	if len(s.responses) > 0 {
+		resp = s.responses[0]
+		s.responses = s.responses[1:]
+	}
+	for key, value := range resp.headers {
+		w.Header().Set(key, value)
+	}
+	w.WriteHeader(resp.status)
+	io.WriteString(w, resp.body)
+}
+
+func (s *standIn) requests() []received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]received{}, s.received...)
+}
+
+func testSink(name string, id string) Sink {
+	sink := DefaultSink()
+	sink.Name = name
+	sink.WebhookUrl = "https://discord.com/api/webhooks/" + id + "/token"
+	sink.Rants = true
+	sink.Raids = true
+
+	return sink
+}
+
+func newTestNotifier(t *testing.T, s *standIn, sinks ...Sink) *Notifier {
+	t.Helper()
+
+	settings := Settings{BaseUrl: s.server.URL + "/api", Sinks: sinks}
+	err := settings.Validate()
+	if err != nil {
+		t.Fatalf("invalid settings: %v", err)
+	}
+
+	return NewNotifier(settings, nil, log.New(io.Discard, "", 0))
+}
+
+// atLeast fails the test if the second request was sent less than d after the first.
+func atLeast(t *testing.T, first received, second received, d time.Duration) {
+	t.Helper()
+
+	// Allow for the resolution of the server's clock.
+	if waited := second.time.Sub(first.time); waited < d-10*time.Millisecond {
+		t.Fatalf("waited %s between requests, want at least %s", waited, d)
+	}
+}
+
+func TestSendRetriesAfter429(t *testing.T) {
+	tests := []struct {
+		name string
+		resp response
+		wait time.Duration
+	}{
+		{
+			name: "retry after header",
+			resp: response{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "0.3"}},
+			wait: 300 * time.Millisecond,
+		},
+		{
+			name: "retry after body",
+			resp: response{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "1"}, body: `{"message": "You are being rate limited.", "retry_after": 0.25, "global": false}`},
+			wait: 250 * time.Millisecond,
+		},
+		{
+			name: "rate limit headers",
+			resp: response{status: http.StatusTooManyRequests, headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset-After": "0.2", "Retry-After": "0.2"}},
+			wait: 200 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStandIn(t, tt.resp)
+			sink := testSink("test", "1")
+			n := newTestNotifier(t, s, sink)
+
+			err := n.Test(sink)
+			if err != nil {
+				t.Fatalf("Test returned error: %v", err)
+			}
+
+			requests := s.requests()
+			if len(requests) != 2 {
+				t.Fatalf("received %d requests, want 2", len(requests))
+			}
+			if requests[1].path != "/api/webhooks/1/token" {
+				t.Fatalf("path = %s, want the sink's webhook path under the base url", requests[1].path)
+			}
+			atLeast(t, requests[0], requests[1], tt.wait)
+		})
+	}
+}
+
+func TestSendGivesUpAfterRetries(t *testing.T) {
+	limited := response{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "0.01"}, body: "slow down"}
+	responses := []response{}
+	for i := 0; i <= maxRateLimitRetries+1; i++ {
+		responses = append(responses, limited)
+	}
+	s := newStandIn(t, responses...)
+	sink := testSink("test", "1")
+	n := newTestNotifier(t, s, sink)
+
+	err := n.Test(sink)
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("Test error = %v, want status 429", err)
+	}
+	if got := len(s.requests()); got != maxRateLimitRetries+1 {
+		t.Fatalf("received %d requests, want %d", got, maxRateLimitRetries+1)
+	}
+}
+
+func TestSendWaitsForBucketReset(t *testing.T) {
+	s := newStandIn(t, response{status: http.StatusNoContent, headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset-After": "0.3"}})
+	first, second := testSink("first", "1"), testSink("second", "2")
+	n := newTestNotifier(t, s, first, second)
+
+	for _, sink := range []Sink{first, second, first} {
+		err := n.Test(sink)
+		if err != nil {
+			t.Fatalf("Test returned error: %v", err)
+		}
+	}
+
+	requests := s.requests()
+	if len(requests) != 3 {
+		t.Fatalf("received %d requests, want 3", len(requests))
+	}
+	// Only the exhausted webhook waits for its bucket to reset.
+	if waited := requests[1].time.Sub(requests[0].time); waited > 200*time.Millisecond {
+		t.Fatalf("other webhook waited %s, want no wait", waited)
+	}
+	atLeast(t, requests[0], requests[2], 300*time.Millisecond)
+}
+
+func TestSendWaitsForGlobalLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers http.Header
+		body    string
+		wait    bool
+	}{
+		{name: "global header", headers: http.Header{"X-Ratelimit-Global": {"true"}, "Retry-After": {"0.3"}}, wait: true},
+		{name: "global body", body: `{"retry_after": 0.3, "global": true}`, wait: true},
+		{name: "webhook limit", headers: http.Header{"Retry-After": {"0.3"}}, wait: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStandIn(t)
+			first, second := testSink("first", "1"), testSink("second", "2")
+			n := newTestNotifier(t, s, first, second)
+
+			limited, err := n.endpoint(first)
+			if err != nil {
+				t.Fatalf("error getting endpoint: %v", err)
+			}
+			headers := tt.headers
+			if headers == nil {
+				headers = http.Header{}
+			}
+			n.updateLimits(limited, &http.Response{StatusCode: http.StatusTooManyRequests, Header: headers}, []byte(tt.body), time.Now())
+
+			start := time.Now()
+			err = n.Test(second)
+			if err != nil {
+				t.Fatalf("Test returned error: %v", err)
+			}
+			waited := time.Since(start)
+			if tt.wait && waited < 290*time.Millisecond {
+				t.Fatalf("other webhook waited %s, want at least 300ms", waited)
+			}
+			if !tt.wait && waited > 200*time.Millisecond {
+				t.Fatalf("other webhook waited %s, want no wait", waited)
+			}
+		})
+	}
+}
+
+func TestNotifierQueue(t *testing.T) {
+	s := newStandIn(t, response{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "0.2"}})
+	big := testSink("big rants", "1")
+	big.Live = false
+	big.MinRantCents = 5000
+	big.Raids = false
+	other := testSink("other page", "2")
+	other.Page = "/c/Other"
+	n := newTestNotifier(t, s, testSink("all", "3"), big, other)
+	n.Start()
+	defer n.Stop()
+
+	n.Live("/c/Channel", rumblelivestreamlib.Livestream{
+		ID:         "abc",
+		Title:      "Playing games",
+		CreatedOn:  "2024-03-10T18:00:00+00:00",
+		Categories: rumblelivestreamlib.Categories{Primary: rumblelivestreamlib.Category{Title: "Gaming"}},
+	})
+	n.Chat("/c/Channel", "https://rumble.com/vabc", rumblelivestreamlib.ChatView{Username: "supporter", Rant: 1000, Text: "hi"})
+	n.Chat("/c/Channel", "https://rumble.com/vabc", rumblelivestreamlib.ChatView{Username: "raider", Raid: true})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.requests()) < 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d requests, want 4", len(s.requests()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	requests := s.requests()
+	if len(requests) != 4 {
+		t.Fatalf("received %d requests, want 4", len(requests))
+	}
+	// The rate limited go-live post is retried before the posts queued after it.
+	for i, want := range []struct {
+		path    string
+		content string
+	}{
+		{"/api/webhooks/3/token", "/c/Channel is live!"},
+		{"/api/webhooks/3/token", "/c/Channel is live!"},
+		{"/api/webhooks/3/token", "**supporter** ranted $10.00: hi"},
+		{"/api/webhooks/3/token", "**raider** raided the stream!"},
+	} {
+		if requests[i].path != want.path || requests[i].msg.Content != want.content {
+			t.Errorf("request %d = %s %q, want %s %q", i, requests[i].path, requests[i].msg.Content, want.path, want.content)
+		}
+	}
+	atLeast(t, requests[0], requests[1], 200*time.Millisecond)
+
+	embeds := requests[1].msg.Embeds
+	if len(embeds) != 1 || embeds[0].Title != "Playing games" || embeds[0].Url != "https://rumble.com/vabc" || embeds[0].Timestamp != "2024-03-10T18:00:00Z" {
+		t.Fatalf("embeds = %+v, want the livestream", embeds)
+	}
+}
+
+func TestStopWhileRateLimited(t *testing.T) {
+	s := newStandIn(t, response{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "60"}})
+	sink := testSink("test", "1")
+	n := newTestNotifier(t, s, sink)
+	n.Start()
+
+	n.Chat("/c/Channel", "https://rumble.com/vabc", rumblelivestreamlib.ChatView{Username: "raider", Raid: true})
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.requests()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("message was not posted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		n.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop waited out the rate limit")
+	}
+	if got := len(s.requests()); got != 1 {
+		t.Fatalf("received %d requests, want 1", got)
+	}
+}
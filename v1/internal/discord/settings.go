@@ -0,0 +1,213 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// DefaultBaseUrl is the Discord API that webhook messages are posted to.
+const DefaultBaseUrl = "https://discord.com/api"
+
+// Settings configure the Discord notifications.
+// BaseUrl replaces the scheme, host and API path of the sinks' webhook urls,
+// so that messages can be sent to a stand-in server.
+type Settings struct {
+	BaseUrl string `json:"base_url"`
+	Sinks   []Sink `json:"sinks"`
+}
+
+// Sink posts notifications to a Discord webhook.
+// Page limits the notifications to the events of one page, like "/c/Channel", or to every page if empty.
+// Rants are posted if they are at least MinRantCents.
+type Sink struct {
+	Name         string    `json:"name"`
+	Enabled      bool      `json:"enabled"`
+	WebhookUrl   string    `json:"webhook_url"`
+	Page         string    `json:"page"`
+	Live         bool      `json:"live"`
+	Rants        bool      `json:"rants"`
+	MinRantCents int64     `json:"min_rant_cents"`
+	Raids        bool      `json:"raids"`
+	Templates    Templates `json:"templates"`
+}
+
+// Templates are Go text templates executed with the notification's Fields.
+// The go-live notification is an embed with LiveTitle and LiveDescription, posted with LiveContent as the message.
+type Templates struct {
+	LiveContent     string `json:"live_content"`
+	LiveTitle       string `json:"live_title"`
+	LiveDescription string `json:"live_description"`
+	Rant            string `json:"rant"`
+	Raid            string `json:"raid"`
+}
+
+// Fields are the values available to the templates.
+// Only the fields that apply to a notification are set.
+type Fields struct {
+	Page     string
+	Title    string
+	Category string
+	Url      string
+	Username string
+	Amount   string
+	Text     string
+}
+
+func DefaultTemplates() Templates {
+	return Templates{
+		LiveContent:     "{{.Page}} is live!",
+		LiveTitle:       "{{.Title}}",
+		LiveDescription: "{{if .Category}}Streaming {{.Category}} on Rumble{{else}}Streaming on Rumble{{end}}",
+		Rant:            "**{{.Username}}** ranted {{.Amount}}: {{.Text}}",
+		Raid:            "**{{.Username}}** raided the stream!",
+	}
+}
+
+// DefaultSink returns the settings a new sink starts with.
+func DefaultSink() Sink {
+	return Sink{
+		Enabled:      true,
+		Live:         true,
+		MinRantCents: 1000,
+		Templates:    DefaultTemplates(),
+	}
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		BaseUrl: DefaultBaseUrl,
+		Sinks:   []Sink{},
+	}
+}
+
+func (s Settings) Validate() error {
+	err := validateHttpUrl(s.BaseUrl)
+	if err != nil {
+		return fmt.Errorf("invalid base url: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, sink := range s.Sinks {
+		err = sink.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid sink %q: %v", sink.Name, err)
+		}
+		if names[sink.Name] {
+			return fmt.Errorf("duplicate sink name: %s", sink.Name)
+		}
+		names[sink.Name] = true
+	}
+
+	return nil
+}
+
+func (s Sink) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("name is empty")
+	}
+
+	_, err := webhookPath(s.WebhookUrl)
+	if err != nil {
+		return err
+	}
+
+	if s.MinRantCents < 0 {
+		return fmt.Errorf("invalid minimum rant: %d", s.MinRantCents)
+	}
+
+	for name, text := range map[string]string{
+		"live content":     s.Templates.LiveContent,
+		"live title":       s.Templates.LiveTitle,
+		"live description": s.Templates.LiveDescription,
+		"rant":             s.Templates.Rant,
+		"raid":             s.Templates.Raid,
+	} {
+		_, err := template.New(name).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid %s template: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateHttpUrl(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https url")
+	}
+
+	return nil
+}
+
+// webhookPath returns the part of a Discord webhook url after the API path, like "/webhooks/ID/TOKEN".
+func webhookPath(webhookUrl string) (string, error) {
+	err := validateHttpUrl(webhookUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook url: %v", err)
+	}
+
+	u, _ := url.Parse(webhookUrl)
+	i := strings.Index(u.Path, "/webhooks/")
+	if i < 0 {
+		return "", fmt.Errorf("invalid webhook url: path does not contain /webhooks/")
+	}
+	path := strings.TrimSuffix(u.Path[i:], "/")
+	if len(strings.Split(path, "/")) != 4 {
+		return "", fmt.Errorf("invalid webhook url: path must end with /webhooks/ID/TOKEN")
+	}
+
+	return path, nil
+}
+
+// LoadSettings reads the settings saved at path, or returns the default settings if none were saved.
+func LoadSettings(path string) (Settings, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return DefaultSettings(), nil
+		}
+		return Settings{}, pkgErr("error reading settings file", err)
+	}
+
+	settings := DefaultSettings()
+	err = json.Unmarshal(b, &settings)
+	if err != nil {
+		return Settings{}, pkgErr("error un-marshaling settings", err)
+	}
+
+	err = settings.Validate()
+	if err != nil {
+		return Settings{}, pkgErr("invalid settings", err)
+	}
+
+	return settings, nil
+}
+
+func SaveSettings(path string, settings Settings) error {
+	err := settings.Validate()
+	if err != nil {
+		return pkgErr("invalid settings", err)
+	}
+
+	b, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return pkgErr("error marshaling settings", err)
+	}
+
+	err = os.WriteFile(path, b, 0644)
+	if err != nil {
+		return pkgErr("error writing settings file", err)
+	}
+
+	return nil
+}
@@ -16,10 +16,73 @@ type Api struct {
 	Stop bool
 }
 
+// ApiCount is a count from a page's API response, like its viewers.
+// Livestream is the url of the livestream the count is for, or empty for page counts like followers.
+type ApiCount struct {
+	Livestream string
+	Value      int64
+}
+
 type ApiFollower struct {
 	Username string
 }
 
+// ApiLive is a livestream starting or ending on a page.
+// Categories is the primary and secondary category for display, like "Gaming, Minecraft".
+type ApiLive struct {
+	Url        string
+	Title      string
+	Category   string
+	Categories string
+	StartedAt  time.Time
+}
+
+// ApiStreamChange is a live livestream's title or categories changing.
+// Kind is "title" or "category", and Live is the livestream after the change.
+type ApiStreamChange struct {
+	Kind string
+	Old  string
+	New  string
+	Live ApiLive
+}
+
+type ApiSubscriber struct {
+	Username      string
+	AmountCents   int64
+	Subscriptions int64
+}
+
+// GoalMilestone is a page's goal reaching one of its milestones.
+// Progress and Target are formatted for display, in dollars for rant goals.
+type GoalMilestone struct {
+	Goal     string
+	Percent  int64
+	Progress string
+	Target   string
+}
+
+// apiTimeLayouts are the layouts times in API responses are parsed with, in order.
+// Times without a UTC offset are in UTC.
+var apiTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// ParseTime parses a time from an API response, keeping its UTC offset.
+func ParseTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range apiTimeLayouts {
+		var t time.Time
+		t, err = time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q: %v", s, err)
+}
+
 type apiProducer struct {
 	cancel   context.CancelFunc
 	cancelMu sync.Mutex
@@ -71,13 +71,13 @@ func NewChatProducer(logError *log.Logger, logInfo *log.Logger) *ChatProducer {
 	}
 }
 
-// func (cp *ChatProducer) Active(url string) bool {
-// 	cp.producersMu.Lock()
-// 	defer cp.producersMu.Unlock()
-// 	_, active := cp.producers[url]
+func (cp *ChatProducer) Active(liveStreamUrl string) bool {
+	cp.producersMu.Lock()
+	defer cp.producersMu.Unlock()
+	_, active := cp.producers[liveStreamUrl]
 
-// 	return active
-// }
+	return active
+}
 
 func (cp *ChatProducer) Start(liveStreamUrl string) (string, error) {
 	if liveStreamUrl == "" {
@@ -200,7 +200,9 @@ func (cp *ChatProducer) stop(p *chatProducer) {
 	cp.Ch <- Chat{Livestream: p.livestream, Stop: true, Url: p.url}
 
 	cp.producersMu.Lock()
-	delete(cp.producers, p.url)
+	if cp.producers[p.livestream] == p {
+		delete(cp.producers, p.livestream)
+	}
 	remaining := len(cp.producers)
 	cp.producersMu.Unlock()
 
@@ -0,0 +1,393 @@
+package goals
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/activity"
+	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+// DefaultMilestones are the milestones used when a goal is created without any.
+const DefaultMilestones = "25,50,75,100"
+
+// Progress is a goal's progress as shown in the UI and overlays.
+// Percent is not capped, goals can go past their target.
+type Progress struct {
+	ID        int64  `json:"id"`
+	Page      string `json:"page"`
+	Title     string `json:"title"`
+	Kind      string `json:"kind"`
+	Reset     string `json:"reset"`
+	Target    int64  `json:"target"`
+	Progress  int64  `json:"progress"`
+	Percent   int64  `json:"percent"`
+	Completed bool   `json:"completed"`
+}
+
+// Update is the result of updating a page's goals from an API response.
+// Changed is true if any goal's progress changed.
+type Update struct {
+	Page       string
+	Goals      []Progress
+	Milestones []events.GoalMilestone
+	Changed    bool
+}
+
+// Tracker updates the progress of the goals stored in the database.
+type Tracker struct {
+	goalS models.GoalService
+	mu    sync.Mutex
+}
+
+func NewTracker(goalS models.GoalService) *Tracker {
+	return &Tracker{goalS: goalS}
+}
+
+// Goals returns the progress of the page's goals.
+func (t *Tracker) Goals(page string) ([]Progress, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	goals, err := t.goalS.ByPage(page)
+	if err != nil {
+		return nil, pkgErr("error querying goals by page", err)
+	}
+
+	return progressList(goals), nil
+}
+
+// Create adds a goal that starts with no progress.
+func (t *Tracker) Create(goal *models.Goal, now time.Time) (int64, error) {
+	if goal == nil {
+		return -1, pkgErr("", fmt.Errorf("goal is nil"))
+	}
+	err := normalizeMilestones(goal)
+	if err != nil {
+		return -1, pkgErr("invalid milestones", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	restart(goal, nil, now)
+	id, err := t.goalS.Create(goal)
+	if err != nil {
+		return -1, pkgErr("error creating goal", err)
+	}
+
+	return id, nil
+}
+
+// Edit updates the goal's title, kind, target, reset and milestones.
+// The goal's progress restarts if its kind or reset changes.
+func (t *Tracker) Edit(goal *models.Goal, now time.Time) error {
+	if goal == nil || goal.ID == nil {
+		return pkgErr("", fmt.Errorf("goal ID is nil"))
+	}
+	err := normalizeMilestones(goal)
+	if err != nil {
+		return pkgErr("invalid milestones", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	existing, err := t.goalS.ByID(*goal.ID)
+	if err != nil {
+		return pkgErr("error querying goal by ID", err)
+	}
+	if existing == nil {
+		return pkgErr("", fmt.Errorf("goal does not exist"))
+	}
+
+	restarts := goal.Kind == nil || existing.Kind == nil || *goal.Kind != *existing.Kind ||
+		goal.Reset == nil || existing.Reset == nil || *goal.Reset != *existing.Reset
+	existing.Title = goal.Title
+	existing.Kind = goal.Kind
+	existing.Target = goal.Target
+	existing.Reset = goal.Reset
+	existing.Milestones = goal.Milestones
+	if restarts {
+		restart(existing, nil, now)
+	} else {
+		complete(existing, now)
+	}
+
+	err = t.goalS.Update(existing)
+	if err != nil {
+		return pkgErr("error updating goal", err)
+	}
+
+	return nil
+}
+
+// Delete removes the goal.
+func (t *Tracker) Delete(id int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.goalS.Delete(&models.Goal{ID: &id})
+	if err != nil {
+		return pkgErr("error deleting goal", err)
+	}
+
+	return nil
+}
+
+// Reset restarts the goal's progress from zero.
+func (t *Tracker) Reset(id int64, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	goal, err := t.goalS.ByID(id)
+	if err != nil {
+		return pkgErr("error querying goal by ID", err)
+	}
+	if goal == nil {
+		return pkgErr("", fmt.Errorf("goal does not exist"))
+	}
+
+	restart(goal, nil, now)
+	err = t.goalS.Update(goal)
+	if err != nil {
+		return pkgErr("error updating goal", err)
+	}
+
+	return nil
+}
+
+// Update adds the page's API response and the activity since the previous response to its goals' progress.
+// Follower and subscriber goals count up from the first count seen, rant goals add the rants in diff.
+// Goals that reset every stream only make progress while the page is live, and restart when a new livestream starts.
+func (t *Tracker) Update(page string, resp *rumblelivestreamlib.LivestreamResponse, diff []activity.Event, now time.Time) (*Update, error) {
+	if resp == nil {
+		return nil, pkgErr("", fmt.Errorf("response is nil"))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	goals, err := t.goalS.ByPage(page)
+	if err != nil {
+		return nil, pkgErr("error querying goals by page", err)
+	}
+
+	livestream := ""
+	if len(resp.Livestreams) > 0 {
+		livestream = fmt.Sprintf("https://rumble.com/v%s", resp.Livestreams[0].ID)
+	}
+
+	update := &Update{Page: page, Milestones: []events.GoalMilestone{}}
+	for i := range goals {
+		goal := &goals[i]
+		if !valid(goal) {
+			continue
+		}
+
+		changed := false
+		if *goal.Reset == models.GoalResetStream {
+			if livestream == "" {
+				continue
+			}
+			if goal.Livestream == nil || *goal.Livestream != livestream {
+				restart(goal, &livestream, now)
+				changed = true
+			}
+		}
+
+		prev := *goal.Progress
+		progress := prev
+		switch *goal.Kind {
+		case models.GoalKindFollowers:
+			changed = count(goal, resp.Followers.NumFollowers) || changed
+		case models.GoalKindSubscribers:
+			changed = count(goal, resp.Subscribers.NumSubscribers) || changed
+		case models.GoalKindRants:
+			for _, e := range diff {
+				if e.Kind != activity.KindRant {
+					continue
+				}
+				// Stream goals count every rant on the livestream, even ones sent before the goal saw it go live.
+				if *goal.Reset == models.GoalResetStream && e.Livestream != livestream {
+					continue
+				}
+				if *goal.Reset == models.GoalResetNever && e.Time.Before(*goal.StartedAt) {
+					continue
+				}
+				progress += e.AmountCents
+			}
+			goal.Progress = &progress
+		}
+		changed = changed || *goal.Progress != prev
+
+		milestone := reached(goal)
+		if milestone > 0 {
+			update.Milestones = append(update.Milestones, events.GoalMilestone{
+				Goal:     *goal.Title,
+				Percent:  milestone,
+				Progress: formatValue(*goal.Kind, *goal.Progress),
+				Target:   formatValue(*goal.Kind, *goal.Target),
+			})
+			goal.Milestone = &milestone
+			changed = true
+		}
+		changed = complete(goal, now) || changed
+
+		if !changed {
+			continue
+		}
+		update.Changed = true
+		err = t.goalS.Update(goal)
+		if err != nil {
+			return nil, pkgErr("error updating goal", err)
+		}
+	}
+	update.Goals = progressList(goals)
+
+	return update, nil
+}
+
+// ParseMilestones parses comma-separated percentages, like 25,50,100, into a sorted list without duplicates.
+func ParseMilestones(s string) ([]int64, error) {
+	milestones := []int64{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		m, err := strconv.ParseInt(strings.TrimSuffix(field, "%"), 10, 64)
+		if err != nil || m < 1 || m > 100 {
+			return nil, fmt.Errorf("milestone %q is not a percentage from 1 to 100", field)
+		}
+		milestones = append(milestones, m)
+	}
+	slices.Sort(milestones)
+
+	return slices.Compact(milestones), nil
+}
+
+func normalizeMilestones(goal *models.Goal) error {
+	s := DefaultMilestones
+	if goal.Milestones != nil && strings.TrimSpace(*goal.Milestones) != "" {
+		s = *goal.Milestones
+	}
+
+	milestones, err := ParseMilestones(s)
+	if err != nil {
+		return err
+	}
+	list := make([]string, len(milestones))
+	for i, m := range milestones {
+		list[i] = strconv.FormatInt(m, 10)
+	}
+	s = strings.Join(list, ",")
+	goal.Milestones = &s
+
+	return nil
+}
+
+func valid(goal *models.Goal) bool {
+	return goal.ID != nil && goal.Page != nil && goal.Title != nil && goal.Kind != nil && goal.Target != nil && *goal.Target > 0 &&
+		goal.Reset != nil && goal.Milestones != nil && goal.Progress != nil && goal.Milestone != nil && goal.StartedAt != nil
+}
+
+// restart clears the goal's progress, starting it over on livestream at now.
+func restart(goal *models.Goal, livestream *string, now time.Time) {
+	zero := int64(0)
+	goal.Baseline = nil
+	goal.Progress = &zero
+	milestone := int64(0)
+	goal.Milestone = &milestone
+	goal.Livestream = livestream
+	goal.StartedAt = &now
+	goal.CompletedAt = nil
+}
+
+// count sets the goal's progress to the count's increase from its baseline, taking count as the baseline if there is none.
+// It returns true if the goal changed.
+func count(goal *models.Goal, n int64) bool {
+	if goal.Baseline == nil {
+		goal.Baseline = &n
+		return true
+	}
+
+	progress := max(n-*goal.Baseline, 0)
+	if progress == *goal.Progress {
+		return false
+	}
+	goal.Progress = &progress
+
+	return true
+}
+
+// reached returns the highest milestone the goal reached that has not been announced yet, or 0 if there is none.
+func reached(goal *models.Goal) int64 {
+	milestones, err := ParseMilestones(*goal.Milestones)
+	if err != nil {
+		return 0
+	}
+
+	highest := int64(0)
+	for _, m := range milestones {
+		if m > *goal.Milestone && *goal.Progress*100 >= *goal.Target*m {
+			highest = m
+		}
+	}
+
+	return highest
+}
+
+// complete marks the goal completed if it reached its target.
+// It returns true if the goal changed.
+func complete(goal *models.Goal, now time.Time) bool {
+	done := goal.Progress != nil && goal.Target != nil && *goal.Progress >= *goal.Target
+	switch {
+	case done && goal.CompletedAt == nil:
+		goal.CompletedAt = &now
+		return true
+	case !done && goal.CompletedAt != nil:
+		goal.CompletedAt = nil
+		return true
+	}
+
+	return false
+}
+
+func progressList(goals []models.Goal) []Progress {
+	list := []Progress{}
+	for _, goal := range goals {
+		if !valid(&goal) {
+			continue
+		}
+
+		list = append(list, Progress{
+			ID:        *goal.ID,
+			Page:      *goal.Page,
+			Title:     *goal.Title,
+			Kind:      *goal.Kind,
+			Reset:     *goal.Reset,
+			Target:    *goal.Target,
+			Progress:  *goal.Progress,
+			Percent:   *goal.Progress * 100 / *goal.Target,
+			Completed: goal.CompletedAt != nil,
+		})
+	}
+
+	return list
+}
+
+// formatValue formats a goal's progress or target, in dollars for rant goals.
+func formatValue(kind string, value int64) string {
+	if kind == models.GoalKindRants {
+		return fmt.Sprintf("$%d.%02d", value/100, value%100)
+	}
+
+	return strconv.FormatInt(value, 10)
+}
@@ -0,0 +1,80 @@
+package goals
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tylertravisty/rum-goggles/v1/internal/activity"
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+func newTestTracker(t *testing.T) (*Tracker, *models.Services) {
+	t.Helper()
+
+	services, err := models.NewServices(
+		models.WithDatabase(filepath.Join(t.TempDir(), "test.db")),
+		models.WithGoalService(),
+	)
+	if err != nil {
+		t.Fatalf("error opening services: %v", err)
+	}
+	t.Cleanup(func() { services.Close() })
+	err = services.AutoMigrate()
+	if err != nil {
+		t.Fatalf("error migrating services: %v", err)
+	}
+
+	return NewTracker(services.GoalS), services
+}
+
+func TestUpdateCountsRants(t *testing.T) {
+	tracker, services := newTestTracker(t)
+	start := time.Date(2024, time.March, 10, 18, 0, 0, 0, time.UTC)
+
+	page, title, kind, reset, milestones := "/c/Channel", "Rants", models.GoalKindRants, models.GoalResetNever, "50,100"
+	target := int64(50000)
+	id, err := tracker.Create(&models.Goal{Page: &page, Title: &title, Kind: &kind, Target: &target, Reset: &reset, Milestones: &milestones}, start)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	resp := &rumblelivestreamlib.LivestreamResponse{
+		Livestreams: []rumblelivestreamlib.Livestream{{ID: "abc"}},
+	}
+	for i := 1; i <= 3; i++ {
+		now := start.Add(time.Duration(i) * time.Minute)
+		diff := []activity.Event{{Kind: activity.KindRant, Page: page, Livestream: "https://rumble.com/vabc", Username: "supporter", AmountCents: 500, Time: now}}
+		update, err := tracker.Update(page, resp, diff, now)
+		if err != nil {
+			t.Fatalf("Update returned error: %v", err)
+		}
+		if !update.Changed {
+			t.Fatalf("update %d is not changed, want the rant counted", i)
+		}
+		if len(update.Goals) != 1 || update.Goals[0].Progress != int64(i*500) {
+			t.Fatalf("goals after update %d = %+v, want progress %d", i, update.Goals, i*500)
+		}
+
+		goal, err := services.GoalS.ByID(id)
+		if err != nil {
+			t.Fatalf("error querying goal: %v", err)
+		}
+		if *goal.Progress != int64(i*500) {
+			t.Fatalf("stored progress after update %d = %d, want %d", i, *goal.Progress, i*500)
+		}
+	}
+
+	// A poll without rants does not change the goal, and rants sent before the goal started are not counted.
+	now := start.Add(time.Hour)
+	diff := []activity.Event{{Kind: activity.KindRant, Page: page, Livestream: "https://rumble.com/vabc", Username: "early", AmountCents: 500, Time: start.Add(-time.Minute)}}
+	update, err := tracker.Update(page, resp, diff, now)
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if update.Changed || update.Goals[0].Progress != 1500 {
+		t.Fatalf("update = %+v, want no change from progress 1500", update)
+	}
+}
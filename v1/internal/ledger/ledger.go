@@ -0,0 +1,295 @@
+package ledger
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+)
+
+type Source string
+
+const (
+	SourceApi  Source = "api"
+	SourceChat Source = "chat"
+)
+
+const (
+	// matchWindow is how far apart the chat and API times of the same rant may be,
+	// once the API's UTC offset is accounted for.
+	matchWindow = 2 * time.Minute
+	// maxOffset is the largest UTC offset error allowed between the chat and API times of the same rant.
+	// The API's times are known to have the wrong UTC offset, which is a whole number of quarter hours.
+	maxOffset = 14 * time.Hour
+	// offsetStep is the smallest unit of UTC offsets.
+	offsetStep = 15 * time.Minute
+)
+
+// Ledger records rants seen in chat and in the API, counting each rant once.
+type Ledger struct {
+	mu    sync.Mutex
+	rantS models.RantService
+}
+
+func New(rantS models.RantService) *Ledger {
+	return &Ledger{rantS: rantS}
+}
+
+// Entry is a rant as seen in one source.
+type Entry struct {
+	Livestream  string
+	Username    string
+	AmountCents int64
+	Text        string
+	Time        time.Time
+}
+
+// Record adds the rant seen in source to the ledger.
+// If the rant is already in the ledger it is only marked as seen in source.
+// The rant's time is the chat time once it is seen in chat, since the API's times have the wrong UTC offset.
+func (l *Ledger) Record(e Entry, source Source) error {
+	if e.Livestream == "" || e.Username == "" || e.AmountCents < 1 || e.Time.IsZero() {
+		return pkgErr("", fmt.Errorf("invalid entry"))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window := maxOffset + matchWindow
+	candidates, err := l.rantS.Near(e.Livestream, e.Username, e.AmountCents, e.Time.Add(-window), e.Time.Add(window))
+	if err != nil {
+		return pkgErr("error getting nearby rants", err)
+	}
+
+	match, duplicate := reconcile(candidates, e, source)
+	if duplicate {
+		return nil
+	}
+	if match != nil {
+		setSeen(match, source, e.Time)
+		if source == SourceChat {
+			match.Time = &e.Time
+		}
+		err = l.rantS.Update(match)
+		if err != nil {
+			return pkgErr("error updating rant", err)
+		}
+		return nil
+	}
+
+	rant := &models.Rant{
+		Livestream:  &e.Livestream,
+		Username:    &e.Username,
+		AmountCents: &e.AmountCents,
+		Text:        &e.Text,
+		Time:        &e.Time,
+	}
+	setSeen(rant, source, e.Time)
+	_, err = l.rantS.Create(rant)
+	if err != nil {
+		return pkgErr("error creating rant", err)
+	}
+
+	return nil
+}
+
+// reconcile finds the rant in candidates that e refers to.
+// It reports a duplicate if e was already recorded from source,
+// otherwise it returns the closest rant not yet seen in source, or nil if e is a new rant.
+// Times from different sources are compared ignoring a UTC offset of up to maxOffset,
+// and candidates that are equally close are taken in order, so identical rants pair up in the order they were sent.
+// Candidates must be rants by the same user for the same amount on the same livestream, oldest first.
+func reconcile(candidates []models.Rant, e Entry, source Source) (*models.Rant, bool) {
+	var match *models.Rant
+	var matchDiff time.Duration
+	for i := range candidates {
+		c := &candidates[i]
+		if c.Time == nil || normalizeText(c.Text) != normalizeText(&e.Text) {
+			continue
+		}
+
+		if seenAt := seen(c, source); seenAt != nil {
+			// The same source reports a rant with the same time every time it is seen again,
+			// e.g. when reconnecting to chat or polling the API.
+			if seenAt.Sub(e.Time).Abs() < time.Second {
+				return nil, true
+			}
+			continue
+		}
+
+		diff, ok := offsetDiff(*c.Time, e.Time)
+		if ok && (match == nil || diff < matchDiff) {
+			match = c
+			matchDiff = diff
+		}
+	}
+
+	return match, false
+}
+
+// offsetDiff returns how far apart a and b are once the closest UTC offset of up to maxOffset is removed,
+// and false if they are too far apart to be the same rant.
+func offsetDiff(a time.Time, b time.Time) (time.Duration, bool) {
+	d := a.Sub(b)
+	offset := d.Round(offsetStep)
+	if offset.Abs() > maxOffset {
+		return 0, false
+	}
+
+	diff := (d - offset).Abs()
+	return diff, diff <= matchWindow
+}
+
+func normalizeText(text *string) string {
+	if text == nil {
+		return ""
+	}
+
+	return strings.Join(strings.Fields(*text), " ")
+}
+
+// seen returns the time source reported for the rant, or nil if the rant was not seen in source.
+func seen(r *models.Rant, source Source) *time.Time {
+	switch source {
+	case SourceApi:
+		return r.ApiTime
+	case SourceChat:
+		return r.ChatTime
+	default:
+		return nil
+	}
+}
+
+func setSeen(r *models.Rant, source Source, t time.Time) {
+	switch source {
+	case SourceApi:
+		r.ApiTime = &t
+	case SourceChat:
+		r.ChatTime = &t
+	}
+}
+
+type Period string
+
+const (
+	PeriodDay        Period = "day"
+	PeriodLivestream Period = "livestream"
+	PeriodMonth      Period = "month"
+	PeriodSupporter  Period = "supporter"
+)
+
+// Total is the sum of the rants grouped under Key.
+type Total struct {
+	Key         string `json:"key"`
+	Count       int64  `json:"count"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+// Totals groups the rants by livestream, supporter, or local day or month.
+// Days and months are ordered most recent first, livestreams and supporters by amount.
+func Totals(rants []models.Rant, by Period) ([]Total, error) {
+	var key func(r models.Rant) string
+	switch by {
+	case PeriodDay:
+		key = func(r models.Rant) string { return r.Time.Local().Format("2006-01-02") }
+	case PeriodLivestream:
+		key = func(r models.Rant) string { return *r.Livestream }
+	case PeriodMonth:
+		key = func(r models.Rant) string { return r.Time.Local().Format("2006-01") }
+	case PeriodSupporter:
+		key = func(r models.Rant) string { return strings.ToLower(*r.Username) }
+	default:
+		return nil, pkgErr("", fmt.Errorf("invalid period: %s", by))
+	}
+
+	totals := map[string]*Total{}
+	for _, r := range rants {
+		if r.Time == nil || r.Livestream == nil || r.Username == nil || r.AmountCents == nil {
+			continue
+		}
+
+		k := key(r)
+		total, exists := totals[k]
+		if !exists {
+			total = &Total{Key: k}
+			if by == PeriodSupporter {
+				total.Key = *r.Username
+			}
+			totals[k] = total
+		}
+		total.Count++
+		total.AmountCents += *r.AmountCents
+	}
+
+	list := []Total{}
+	for _, total := range totals {
+		list = append(list, *total)
+	}
+	slices.SortFunc(list, func(a, b Total) int {
+		if by == PeriodDay || by == PeriodMonth {
+			return cmp.Compare(b.Key, a.Key)
+		}
+		if c := cmp.Compare(b.AmountCents, a.AmountCents); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+
+	return list, nil
+}
+
+// WriteCSV writes the rants to w as CSV for bookkeeping.
+func WriteCSV(w io.Writer, rants []models.Rant) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{"time", "livestream", "username", "amount", "amount_cents", "text", "sources"})
+	if err != nil {
+		return pkgErr("error writing CSV", err)
+	}
+
+	for _, r := range rants {
+		if r.Time == nil || r.Livestream == nil || r.Username == nil || r.AmountCents == nil {
+			continue
+		}
+
+		text := ""
+		if r.Text != nil {
+			text = *r.Text
+		}
+		sources := []string{}
+		if seen(&r, SourceChat) != nil {
+			sources = append(sources, string(SourceChat))
+		}
+		if seen(&r, SourceApi) != nil {
+			sources = append(sources, string(SourceApi))
+		}
+
+		err = cw.Write([]string{
+			r.Time.Local().Format(time.RFC3339),
+			*r.Livestream,
+			*r.Username,
+			fmt.Sprintf("%d.%02d", *r.AmountCents/100, *r.AmountCents%100),
+			strconv.FormatInt(*r.AmountCents, 10),
+			text,
+			strings.Join(sources, ","),
+		})
+		if err != nil {
+			return pkgErr("error writing CSV", err)
+		}
+	}
+
+	cw.Flush()
+	err = cw.Error()
+	if err != nil {
+		return pkgErr("error writing CSV", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+)
+
+var base = time.Date(2024, time.March, 10, 18, 0, 0, 0, time.UTC)
+
+func rant(id int64, text string, t time.Time, chatTime *time.Time, apiTime *time.Time) models.Rant {
+	livestream := "https://rumble.com/vabc"
+	username := "supporter"
+	amount := int64(500)
+	return models.Rant{
+		ID:          &id,
+		Livestream:  &livestream,
+		Username:    &username,
+		AmountCents: &amount,
+		Text:        &text,
+		Time:        &t,
+		ChatTime:    chatTime,
+		ApiTime:     apiTime,
+	}
+}
+
+func at(d time.Duration) *time.Time {
+	t := base.Add(d)
+	return &t
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []models.Rant
+		entry      Entry
+		source     Source
+		wantID     int64
+		duplicate  bool
+	}{
+		{
+			name:       "no candidates",
+			candidates: nil,
+			entry:      Entry{Text: "hello", Time: base},
+			source:     SourceChat,
+		},
+		{
+			name:       "same source under a second is a duplicate",
+			candidates: []models.Rant{rant(1, "hello", base, at(0), nil)},
+			entry:      Entry{Text: "hello", Time: base.Add(500 * time.Millisecond)},
+			source:     SourceChat,
+			duplicate:  true,
+		},
+		{
+			name:       "same source a second or more apart is a new rant",
+			candidates: []models.Rant{rant(1, "hello", base, at(0), nil)},
+			entry:      Entry{Text: "hello", Time: base.Add(time.Second)},
+			source:     SourceChat,
+		},
+		{
+			name:       "api matches chat inside the window",
+			candidates: []models.Rant{rant(1, "hello", base, at(0), nil)},
+			entry:      Entry{Text: "hello", Time: base.Add(90 * time.Second)},
+			source:     SourceApi,
+			wantID:     1,
+		},
+		{
+			name:       "chat matches api inside the window",
+			candidates: []models.Rant{rant(1, "hello", base, nil, at(0))},
+			entry:      Entry{Text: "hello", Time: base.Add(-matchWindow)},
+			source:     SourceChat,
+			wantID:     1,
+		},
+		{
+			name:       "api does not match chat outside the window",
+			candidates: []models.Rant{rant(1, "hello", base, at(0), nil)},
+			entry:      Entry{Text: "hello", Time: base.Add(matchWindow + time.Second)},
+			source:     SourceApi,
+		},
+		{
+			name:       "api matches chat with a wrong UTC offset",
+			candidates: []models.Rant{rant(1, "hello", base, at(0), nil)},
+			entry:      Entry{Text: "hello", Time: base.Add(4*time.Hour + 30*time.Second)},
+			source:     SourceApi,
+			wantID:     1,
+		},
+		{
+			name:       "api does not match chat with a wrong UTC offset outside the window",
+			candidates: []models.Rant{rant(1, "hello", base, at(0), nil)},
+			entry:      Entry{Text: "hello", Time: base.Add(-4*time.Hour + 5*time.Minute)},
+			source:     SourceApi,
+		},
+		{
+			name:       "api does not match chat further apart than the largest offset",
+			candidates: []models.Rant{rant(1, "hello", base, at(0), nil)},
+			entry:      Entry{Text: "hello", Time: base.Add(maxOffset + offsetStep)},
+			source:     SourceApi,
+		},
+		{
+			name: "closest candidate is picked",
+			candidates: []models.Rant{
+				rant(1, "hello", base, at(0), nil),
+				rant(2, "hello", base.Add(time.Minute), at(time.Minute), nil),
+				rant(3, "hello", base.Add(2*time.Minute), at(2*time.Minute), nil),
+			},
+			entry:  Entry{Text: "hello", Time: base.Add(70 * time.Second)},
+			source: SourceApi,
+			wantID: 2,
+		},
+		{
+			name: "equally close candidates are taken in order",
+			candidates: []models.Rant{
+				rant(1, "hello", base, at(0), nil),
+				rant(2, "hello", base.Add(time.Hour), at(time.Hour), nil),
+			},
+			entry:  Entry{Text: "hello", Time: base.Add(4 * time.Hour)},
+			source: SourceApi,
+			wantID: 1,
+		},
+		{
+			name: "candidates already seen in the source are skipped",
+			candidates: []models.Rant{
+				rant(1, "hello", base, at(0), at(0)),
+				rant(2, "hello", base.Add(time.Minute), at(time.Minute), nil),
+			},
+			entry:  Entry{Text: "hello", Time: base.Add(10 * time.Second)},
+			source: SourceApi,
+			wantID: 2,
+		},
+		{
+			name:       "text whitespace is normalised",
+			candidates: []models.Rant{rant(1, "great   stream\n today ", base, at(0), nil)},
+			entry:      Entry{Text: " great stream today", Time: base},
+			source:     SourceApi,
+			wantID:     1,
+		},
+		{
+			name:       "different text does not match",
+			candidates: []models.Rant{rant(1, "great stream", base, at(0), nil)},
+			entry:      Entry{Text: "Great stream", Time: base},
+			source:     SourceApi,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, duplicate := reconcile(tt.candidates, tt.entry, tt.source)
+			if duplicate != tt.duplicate {
+				t.Fatalf("duplicate = %t, want %t", duplicate, tt.duplicate)
+			}
+
+			id := int64(0)
+			if match != nil {
+				id = *match.ID
+			}
+			if id != tt.wantID {
+				t.Fatalf("match = %d, want %d", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestTotals(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+
+	rants := []models.Rant{
+		rant(1, "a", time.Date(2024, time.January, 31, 23, 59, 0, 0, time.UTC), nil, nil),
+		rant(2, "b", time.Date(2024, time.February, 1, 0, 1, 0, 0, time.UTC), nil, nil),
+		rant(3, "c", time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC), nil, nil),
+		rant(4, "d", time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC), nil, nil),
+	}
+	other := "https://rumble.com/vdef"
+	rants[3].Livestream = &other
+	big := int64(2000)
+	rants[3].AmountCents = &big
+	upper := "SUPPORTER"
+	rants[2].Username = &upper
+
+	tests := []struct {
+		by   Period
+		want []Total
+	}{
+		{
+			by: PeriodDay,
+			want: []Total{
+				{Key: "2024-03-05", Count: 1, AmountCents: 2000},
+				{Key: "2024-02-01", Count: 2, AmountCents: 1000},
+				{Key: "2024-01-31", Count: 1, AmountCents: 500},
+			},
+		},
+		{
+			by: PeriodMonth,
+			want: []Total{
+				{Key: "2024-03", Count: 1, AmountCents: 2000},
+				{Key: "2024-02", Count: 2, AmountCents: 1000},
+				{Key: "2024-01", Count: 1, AmountCents: 500},
+			},
+		},
+		{
+			by: PeriodLivestream,
+			want: []Total{
+				{Key: "https://rumble.com/vdef", Count: 1, AmountCents: 2000},
+				{Key: "https://rumble.com/vabc", Count: 3, AmountCents: 1500},
+			},
+		},
+		{
+			by: PeriodSupporter,
+			want: []Total{
+				{Key: "supporter", Count: 4, AmountCents: 3500},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.by), func(t *testing.T) {
+			got, err := Totals(rants, tt.by)
+			if err != nil {
+				t.Fatalf("Totals returned error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d totals, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("total %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	_, err := Totals(rants, Period("week"))
+	if err == nil {
+		t.Fatal("Totals with invalid period returned no error")
+	}
+}
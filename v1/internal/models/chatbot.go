@@ -6,18 +6,22 @@ import (
 )
 
 const (
-	chatbotColumns = "id, name, url"
+	chatbotColumns = "id, name, url, page"
 	chatbotTable   = "chatbot"
 )
 
+// Chatbot is a stored chatbot.
+// Page optionally links the chatbot to an account or channel page, e.g. /c/ChannelName,
+// so its rules can be started and stopped when the page goes live or offline.
 type Chatbot struct {
 	ID   *int64  `json:"id"`
 	Name *string `json:"name"`
 	Url  *string `json:"url"`
+	Page *string `json:"page"`
 }
 
 func (c *Chatbot) values() []any {
-	return []any{c.ID, c.Name, c.Url}
+	return []any{c.ID, c.Name, c.Url, c.Page}
 }
 
 func (c *Chatbot) valuesNoID() []any {
@@ -33,10 +37,11 @@ type sqlChatbot struct {
 	id   sql.NullInt64
 	name sql.NullString
 	url  sql.NullString
+	page sql.NullString
 }
 
 func (sc *sqlChatbot) scan(r Row) error {
-	return r.Scan(&sc.id, &sc.name, &sc.url)
+	return r.Scan(&sc.id, &sc.name, &sc.url, &sc.page)
 }
 
 func (sc sqlChatbot) toChatbot() *Chatbot {
@@ -44,6 +49,7 @@ func (sc sqlChatbot) toChatbot() *Chatbot {
 	c.ID = toInt64(sc.id)
 	c.Name = toString(sc.name)
 	c.Url = toString(sc.url)
+	c.Page = toString(sc.page)
 
 	return &c
 }
@@ -53,6 +59,7 @@ type ChatbotService interface {
 	AutoMigrate() error
 	ByID(id int64) (*Chatbot, error)
 	ByName(name string) (*Chatbot, error)
+	ByPage(page string) ([]Chatbot, error)
 	Create(c *Chatbot) (int64, error)
 	Delete(c *Chatbot) error
 	DestructiveReset() error
@@ -108,6 +115,11 @@ func (cs *chatbotService) AutoMigrate() error {
 		return pkgErr(fmt.Sprintf("error creating %s table", chatbotTable), err)
 	}
 
+	err = addColumnNotExist(cs.Database, chatbotTable, "page", "TEXT")
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error adding page column to %s table", chatbotTable), err)
+	}
+
 	return nil
 }
 
@@ -116,7 +128,8 @@ func (cs *chatbotService) createChatbotTable() error {
 		CREATE TABLE IF NOT EXISTS "%s" (
 			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
 			name TEXT UNIQUE NOT NULL,
-			url TEXT
+			url TEXT,
+			page TEXT
 		)
 	`, chatbotTable)
 
@@ -184,6 +197,38 @@ func (cs *chatbotService) ByName(name string) (*Chatbot, error) {
 	return sc.toChatbot(), nil
 }
 
+func (cs *chatbotService) ByPage(page string) ([]Chatbot, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=?
+	`, chatbotColumns, chatbotTable)
+
+	rows, err := cs.Database.Query(selectQ, page)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	chatbots := []Chatbot{}
+	for rows.Next() {
+		sc := &sqlChatbot{}
+
+		err = sc.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		chatbots = append(chatbots, *sc.toChatbot())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return chatbots, nil
+}
+
 func (cs *chatbotService) Create(c *Chatbot) (int64, error) {
 	err := runChatbotValFuncs(
 		c,
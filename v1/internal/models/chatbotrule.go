@@ -6,14 +6,18 @@ import (
 )
 
 const (
-	chatbotRuleColumns = "id, chatbot_id, parameters"
-	chatbotRuleTable   = "chatbot_rule"
+	chatbotRuleColumns       = "id, chatbot_id, parameters"
+	chatbotRuleSelectColumns = chatbotRuleColumns + ", running"
+	chatbotRuleTable         = "chatbot_rule"
 )
 
+// ChatbotRule is a stored chatbot rule.
+// Running records whether the user left the rule running; it is only written by SetRunning.
 type ChatbotRule struct {
 	ID         *int64  `json:"id"`
 	ChatbotID  *int64  `json:"chatbot_id"`
 	Parameters *string `json:"parameters"`
+	Running    *bool   `json:"running"`
 }
 
 func (c *ChatbotRule) values() []any {
@@ -33,10 +37,11 @@ type sqlChatbotRule struct {
 	id         sql.NullInt64
 	chatbotID  sql.NullInt64
 	parameters sql.NullString
+	running    sql.NullBool
 }
 
 func (sc *sqlChatbotRule) scan(r Row) error {
-	return r.Scan(&sc.id, &sc.chatbotID, &sc.parameters)
+	return r.Scan(&sc.id, &sc.chatbotID, &sc.parameters, &sc.running)
 }
 
 func (sc sqlChatbotRule) toChatbotRule() *ChatbotRule {
@@ -44,6 +49,7 @@ func (sc sqlChatbotRule) toChatbotRule() *ChatbotRule {
 	c.ID = toInt64(sc.id)
 	c.ChatbotID = toInt64(sc.chatbotID)
 	c.Parameters = toString(sc.parameters)
+	c.Running = toBool(sc.running)
 
 	return &c
 }
@@ -54,6 +60,8 @@ type ChatbotRuleService interface {
 	Create(c *ChatbotRule) (int64, error)
 	Delete(c *ChatbotRule) error
 	DestructiveReset() error
+	Running() ([]ChatbotRule, error)
+	SetRunning(id int64, running bool) error
 	Update(c *ChatbotRule) error
 }
 
@@ -75,6 +83,11 @@ func (cs *chatbotRuleService) AutoMigrate() error {
 		return pkgErr(fmt.Sprintf("error creating %s table", chatbotRuleTable), err)
 	}
 
+	err = addColumnNotExist(cs.Database, chatbotRuleTable, "running", "BOOLEAN NOT NULL DEFAULT FALSE")
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error adding running column to %s table", chatbotRuleTable), err)
+	}
+
 	return nil
 }
 
@@ -84,6 +97,7 @@ func (cs *chatbotRuleService) createChatbotRuleTable() error {
 			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
 			chatbot_id INTEGER NOT NULL,
 			parameters TEXT NOT NULL,
+			running BOOLEAN NOT NULL DEFAULT FALSE,
 			FOREIGN KEY (chatbot_id) REFERENCES "%s" (id)
 		)
 	`, chatbotRuleTable, chatbotTable)
@@ -101,7 +115,7 @@ func (cs *chatbotRuleService) ByChatbotID(cid int64) ([]ChatbotRule, error) {
 		SELECT %s
 		FROM "%s"
 		WHERE chatbot_id=?
-	`, chatbotRuleColumns, chatbotRuleTable)
+	`, chatbotRuleSelectColumns, chatbotRuleTable)
 
 	rows, err := cs.Database.Query(selectQ, cid)
 	if err != nil {
@@ -198,6 +212,61 @@ func (cs *chatbotRuleService) dropChatbotRuleTable() error {
 	return nil
 }
 
+func (cs *chatbotRuleService) Running() ([]ChatbotRule, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE running=TRUE
+	`, chatbotRuleSelectColumns, chatbotRuleTable)
+
+	rows, err := cs.Database.Query(selectQ)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	rules := []ChatbotRule{}
+	for rows.Next() {
+		scr := &sqlChatbotRule{}
+
+		err = scr.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		rules = append(rules, *scr.toChatbotRule())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return rules, nil
+}
+
+func (cs *chatbotRuleService) SetRunning(id int64, running bool) error {
+	err := runChatbotRuleValFuncs(
+		&ChatbotRule{ID: &id},
+		chatbotRuleRequireID,
+	)
+	if err != nil {
+		return pkgErr("invalid chat rule", err)
+	}
+
+	updateQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET running=?
+		WHERE id=?
+	`, chatbotRuleTable)
+
+	_, err = cs.Database.Exec(updateQ, running, id)
+	if err != nil {
+		return pkgErr("error executing update query", err)
+	}
+
+	return nil
+}
+
 func (cs *chatbotRuleService) Update(c *ChatbotRule) error {
 	err := runChatbotRuleValFuncs(
 		c,
@@ -0,0 +1,281 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const (
+	chatbotSupervisorColumns = "id, chatbot_id, enabled, max_failures, failure_window, backoff_min, backoff_max"
+	chatbotSupervisorTable   = "chatbot_supervisor"
+)
+
+// ChatbotSupervisor holds the restart settings for a chatbot's rules.
+// Durations are stored in seconds.
+type ChatbotSupervisor struct {
+	ID            *int64 `json:"id"`
+	ChatbotID     *int64 `json:"chatbot_id"`
+	Enabled       *bool  `json:"enabled"`
+	MaxFailures   *int64 `json:"max_failures"`
+	FailureWindow *int64 `json:"failure_window"`
+	BackoffMin    *int64 `json:"backoff_min"`
+	BackoffMax    *int64 `json:"backoff_max"`
+}
+
+func (c *ChatbotSupervisor) values() []any {
+	return []any{c.ID, c.ChatbotID, c.Enabled, c.MaxFailures, c.FailureWindow, c.BackoffMin, c.BackoffMax}
+}
+
+func (c *ChatbotSupervisor) valuesNoID() []any {
+	return c.values()[1:]
+}
+
+func (c *ChatbotSupervisor) valuesEndID() []any {
+	vals := c.values()
+	return append(vals[1:], vals[0])
+}
+
+type sqlChatbotSupervisor struct {
+	id            sql.NullInt64
+	chatbotID     sql.NullInt64
+	enabled       sql.NullBool
+	maxFailures   sql.NullInt64
+	failureWindow sql.NullInt64
+	backoffMin    sql.NullInt64
+	backoffMax    sql.NullInt64
+}
+
+func (sc *sqlChatbotSupervisor) scan(r Row) error {
+	return r.Scan(&sc.id, &sc.chatbotID, &sc.enabled, &sc.maxFailures, &sc.failureWindow, &sc.backoffMin, &sc.backoffMax)
+}
+
+func (sc sqlChatbotSupervisor) toChatbotSupervisor() *ChatbotSupervisor {
+	var c ChatbotSupervisor
+	c.ID = toInt64(sc.id)
+	c.ChatbotID = toInt64(sc.chatbotID)
+	c.Enabled = toBool(sc.enabled)
+	c.MaxFailures = toInt64(sc.maxFailures)
+	c.FailureWindow = toInt64(sc.failureWindow)
+	c.BackoffMin = toInt64(sc.backoffMin)
+	c.BackoffMax = toInt64(sc.backoffMax)
+
+	return &c
+}
+
+type ChatbotSupervisorService interface {
+	AutoMigrate() error
+	ByChatbotID(cid int64) (*ChatbotSupervisor, error)
+	Create(c *ChatbotSupervisor) (int64, error)
+	Delete(c *ChatbotSupervisor) error
+	DestructiveReset() error
+	Update(c *ChatbotSupervisor) error
+}
+
+func NewChatbotSupervisorService(db *sql.DB) ChatbotSupervisorService {
+	return &chatbotSupervisorService{
+		Database: db,
+	}
+}
+
+var _ ChatbotSupervisorService = &chatbotSupervisorService{}
+
+type chatbotSupervisorService struct {
+	Database *sql.DB
+}
+
+func (cs *chatbotSupervisorService) AutoMigrate() error {
+	err := cs.createChatbotSupervisorTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", chatbotSupervisorTable), err)
+	}
+
+	return nil
+}
+
+func (cs *chatbotSupervisorService) createChatbotSupervisorTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			chatbot_id INTEGER UNIQUE NOT NULL,
+			enabled BOOLEAN NOT NULL,
+			max_failures INTEGER,
+			failure_window INTEGER,
+			backoff_min INTEGER,
+			backoff_max INTEGER,
+			FOREIGN KEY (chatbot_id) REFERENCES "%s" (id)
+		)
+	`, chatbotSupervisorTable, chatbotTable)
+
+	_, err := cs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+func (cs *chatbotSupervisorService) ByChatbotID(cid int64) (*ChatbotSupervisor, error) {
+	err := runChatbotSupervisorValFuncs(
+		&ChatbotSupervisor{ChatbotID: &cid},
+		chatbotSupervisorRequireChatbotID,
+	)
+	if err != nil {
+		return nil, pkgErr("", err)
+	}
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE chatbot_id=?
+	`, chatbotSupervisorColumns, chatbotSupervisorTable)
+
+	var sc sqlChatbotSupervisor
+	row := cs.Database.QueryRow(selectQ, cid)
+	err = sc.scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, pkgErr("error executing select query", err)
+	}
+
+	return sc.toChatbotSupervisor(), nil
+}
+
+func (cs *chatbotSupervisorService) Create(c *ChatbotSupervisor) (int64, error) {
+	err := runChatbotSupervisorValFuncs(
+		c,
+		chatbotSupervisorRequireChatbotID,
+		chatbotSupervisorRequireEnabled,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid chatbot supervisor", err)
+	}
+
+	columns := columnsNoID(chatbotSupervisorColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, chatbotSupervisorTable, columns, values(columns))
+
+	var id int64
+	row := cs.Database.QueryRow(insertQ, c.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (cs *chatbotSupervisorService) Delete(c *ChatbotSupervisor) error {
+	err := runChatbotSupervisorValFuncs(
+		c,
+		chatbotSupervisorRequireID,
+	)
+	if err != nil {
+		return pkgErr("invalid chatbot supervisor", err)
+	}
+
+	deleteQ := fmt.Sprintf(`
+		DELETE FROM "%s"
+		WHERE id=?
+	`, chatbotSupervisorTable)
+
+	_, err = cs.Database.Exec(deleteQ, c.ID)
+	if err != nil {
+		return pkgErr("error executing delete query", err)
+	}
+
+	return nil
+}
+
+func (cs *chatbotSupervisorService) DestructiveReset() error {
+	err := cs.dropChatbotSupervisorTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", chatbotSupervisorTable), err)
+	}
+
+	return nil
+}
+
+func (cs *chatbotSupervisorService) dropChatbotSupervisorTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, chatbotSupervisorTable)
+
+	_, err := cs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+func (cs *chatbotSupervisorService) Update(c *ChatbotSupervisor) error {
+	err := runChatbotSupervisorValFuncs(
+		c,
+		chatbotSupervisorRequireID,
+		chatbotSupervisorRequireChatbotID,
+		chatbotSupervisorRequireEnabled,
+	)
+	if err != nil {
+		return pkgErr("invalid chatbot supervisor", err)
+	}
+
+	columns := columnsNoID(chatbotSupervisorColumns)
+	updateQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET %s
+		WHERE id=?
+	`, chatbotSupervisorTable, set(columns))
+
+	_, err = cs.Database.Exec(updateQ, c.valuesEndID()...)
+	if err != nil {
+		return pkgErr("error executing update query", err)
+	}
+
+	return nil
+}
+
+type chatbotSupervisorValFunc func(*ChatbotSupervisor) error
+
+func runChatbotSupervisorValFuncs(c *ChatbotSupervisor, fns ...chatbotSupervisorValFunc) error {
+	if c == nil {
+		return fmt.Errorf("chatbot supervisor is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func chatbotSupervisorRequireID(c *ChatbotSupervisor) error {
+	if c.ID == nil || *c.ID < 1 {
+		return ErrChatbotSupervisorInvalidID
+	}
+
+	return nil
+}
+
+func chatbotSupervisorRequireChatbotID(c *ChatbotSupervisor) error {
+	if c.ChatbotID == nil || *c.ChatbotID < 1 {
+		return ErrChatbotSupervisorInvalidChatbotID
+	}
+
+	return nil
+}
+
+func chatbotSupervisorRequireEnabled(c *ChatbotSupervisor) error {
+	if c.Enabled == nil {
+		return ErrChatbotSupervisorInvalidEnabled
+	}
+
+	return nil
+}
@@ -0,0 +1,734 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+const (
+	chatMessageColumns  = "id, livestream, username, channel_name, badges, rant, raid, sub, text, time"
+	chatMessageTable    = "chat_message"
+	chatMessageFtsTable = "chat_message_fts"
+)
+
+// ChatMessage is a chat message received from a livestream.
+// Badges are stored comma-separated and Rant is in cents.
+type ChatMessage struct {
+	ID          *int64     `json:"id"`
+	Livestream  *string    `json:"livestream"`
+	Username    *string    `json:"username"`
+	ChannelName *string    `json:"channel_name"`
+	Badges      *string    `json:"badges"`
+	Rant        *int64     `json:"rant"`
+	Raid        *bool      `json:"raid"`
+	Sub         *bool      `json:"sub"`
+	Text        *string    `json:"text"`
+	Time        *time.Time `json:"time"`
+}
+
+func (c *ChatMessage) values() []any {
+	return []any{c.ID, c.Livestream, c.Username, c.ChannelName, c.Badges, c.Rant, c.Raid, c.Sub, c.Text, c.Time}
+}
+
+func (c *ChatMessage) valuesNoID() []any {
+	return c.values()[1:]
+}
+
+type sqlChatMessage struct {
+	id          sql.NullInt64
+	livestream  sql.NullString
+	username    sql.NullString
+	channelName sql.NullString
+	badges      sql.NullString
+	rant        sql.NullInt64
+	raid        sql.NullBool
+	sub         sql.NullBool
+	text        sql.NullString
+	time        sql.NullTime
+}
+
+func (sc *sqlChatMessage) scan(r Row) error {
+	return r.Scan(&sc.id, &sc.livestream, &sc.username, &sc.channelName, &sc.badges, &sc.rant, &sc.raid, &sc.sub, &sc.text, &sc.time)
+}
+
+func (sc sqlChatMessage) toChatMessage() *ChatMessage {
+	var c ChatMessage
+	c.ID = toInt64(sc.id)
+	c.Livestream = toString(sc.livestream)
+	c.Username = toString(sc.username)
+	c.ChannelName = toString(sc.channelName)
+	c.Badges = toString(sc.badges)
+	c.Rant = toInt64(sc.rant)
+	c.Raid = toBool(sc.raid)
+	c.Sub = toBool(sc.sub)
+	c.Text = toString(sc.text)
+	c.Time = toTime(sc.time)
+
+	return &c
+}
+
+type ChatMessageService interface {
+	AutoMigrate() error
+	ByLivestream(livestream string, offset int, limit int) ([]ChatMessage, error)
+	ByLivestreamsBetween(livestreams []string, from time.Time, to time.Time) ([]ChatMessage, error)
+	CountByLivestream(livestream string) (int64, error)
+	CreateBatch(cs []ChatMessage) error
+	DestructiveReset() error
+	Livestreams() ([]string, error)
+	Search(s ChatMessageSearch) ([]ChatMessageResult, int64, error)
+}
+
+func NewChatMessageService(db *sql.DB) ChatMessageService {
+	return &chatMessageService{
+		Database: db,
+	}
+}
+
+var _ ChatMessageService = &chatMessageService{}
+
+// chatMessageService searches with FTS5 if SQLite was built with it, like the app is with the sqlite_fts5 build tag.
+// Otherwise searches fall back to LIKE, which is slower and not ranked.
+type chatMessageService struct {
+	Database *sql.DB
+	fts      bool
+}
+
+func (cs *chatMessageService) AutoMigrate() error {
+	err := cs.createChatMessageTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", chatMessageTable), err)
+	}
+
+	cs.fts, err = cs.ftsAvailable()
+	if err != nil {
+		return pkgErr("error checking for FTS5", err)
+	}
+	if !cs.fts {
+		// Messages cannot be inserted while the index triggers refer to a module SQLite does not have.
+		// The index is rebuilt once FTS5 is available again.
+		err = cs.dropChatMessageFtsTriggers()
+		if err != nil {
+			return pkgErr(fmt.Sprintf("error dropping %s triggers", chatMessageFtsTable), err)
+		}
+		return nil
+	}
+
+	err = cs.createChatMessageFtsTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", chatMessageFtsTable), err)
+	}
+
+	return nil
+}
+
+// ftsAvailable returns true if SQLite was compiled with FTS5.
+func (cs *chatMessageService) ftsAvailable() (bool, error) {
+	var used bool
+	err := cs.Database.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&used)
+	if err != nil {
+		return false, fmt.Errorf("error executing compile option query: %v", err)
+	}
+
+	return used, nil
+}
+
+func (cs *chatMessageService) createChatMessageTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			livestream TEXT NOT NULL,
+			username TEXT NOT NULL,
+			channel_name TEXT,
+			badges TEXT,
+			rant INTEGER,
+			raid BOOLEAN,
+			sub BOOLEAN,
+			text TEXT NOT NULL,
+			time DATETIME NOT NULL,
+			UNIQUE (livestream, username, time, text)
+		)
+	`, chatMessageTable)
+
+	_, err := cs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	indexQ := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS "%s_livestream_time" ON "%s" (livestream, time)
+	`, chatMessageTable, chatMessageTable)
+
+	_, err = cs.Database.Exec(indexQ)
+	if err != nil {
+		return fmt.Errorf("error executing create index query: %v", err)
+	}
+
+	return nil
+}
+
+// createChatMessageFtsTable creates the full-text index over chat message text and usernames.
+// The index is kept in sync with the chat message table by triggers.
+func (cs *chatMessageService) createChatMessageFtsTable() error {
+	// The index is stale if the triggers were dropped while FTS5 was unavailable.
+	var indexed bool
+	existsQ := `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type='trigger' AND name=?)`
+	err := cs.Database.QueryRow(existsQ, chatMessageTable+"_ai").Scan(&indexed)
+	if err != nil {
+		return fmt.Errorf("error executing exists query: %v", err)
+	}
+
+	createQ := fmt.Sprintf(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS "%s" USING fts5(
+			text,
+			username,
+			content='%s',
+			content_rowid='id'
+		)
+	`, chatMessageFtsTable, chatMessageTable)
+
+	_, err = cs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	triggersQ := fmt.Sprintf(`
+		CREATE TRIGGER IF NOT EXISTS "%[1]s_ai" AFTER INSERT ON "%[1]s" BEGIN
+			INSERT INTO "%[2]s" (rowid, text, username) VALUES (new.id, new.text, new.username);
+		END;
+		CREATE TRIGGER IF NOT EXISTS "%[1]s_ad" AFTER DELETE ON "%[1]s" BEGIN
+			INSERT INTO "%[2]s" ("%[2]s", rowid, text, username) VALUES ('delete', old.id, old.text, old.username);
+		END;
+		CREATE TRIGGER IF NOT EXISTS "%[1]s_au" AFTER UPDATE ON "%[1]s" BEGIN
+			INSERT INTO "%[2]s" ("%[2]s", rowid, text, username) VALUES ('delete', old.id, old.text, old.username);
+			INSERT INTO "%[2]s" (rowid, text, username) VALUES (new.id, new.text, new.username);
+		END;
+	`, chatMessageTable, chatMessageFtsTable)
+
+	_, err = cs.Database.Exec(triggersQ)
+	if err != nil {
+		return fmt.Errorf("error executing create triggers query: %v", err)
+	}
+
+	if !indexed {
+		// Index messages stored before the full-text table or its triggers existed.
+		rebuildQ := fmt.Sprintf(`INSERT INTO "%[1]s" ("%[1]s") VALUES ('rebuild')`, chatMessageFtsTable)
+		_, err = cs.Database.Exec(rebuildQ)
+		if err != nil {
+			return fmt.Errorf("error executing rebuild query: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (cs *chatMessageService) ByLivestream(livestream string, offset int, limit int) ([]ChatMessage, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE livestream=?
+		ORDER BY time, id
+		LIMIT ? OFFSET ?
+	`, chatMessageColumns, chatMessageTable)
+
+	rows, err := cs.Database.Query(selectQ, livestream, limit, offset)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	messages := []ChatMessage{}
+	for rows.Next() {
+		sc := &sqlChatMessage{}
+
+		err = sc.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		messages = append(messages, *sc.toChatMessage())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return messages, nil
+}
+
+// ByLivestreamsBetween returns the messages sent to any of the livestreams between from and to, oldest first.
+func (cs *chatMessageService) ByLivestreamsBetween(livestreams []string, from time.Time, to time.Time) ([]ChatMessage, error) {
+	if len(livestreams) == 0 {
+		return []ChatMessage{}, nil
+	}
+
+	args := []any{}
+	for _, livestream := range livestreams {
+		args = append(args, livestream)
+	}
+	args = append(args, from.UTC(), to.UTC())
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE livestream IN (%s) AND time>=? AND time<=?
+		ORDER BY time, id
+	`, chatMessageColumns, chatMessageTable, strings.TrimSuffix(strings.Repeat("?, ", len(livestreams)), ", "))
+
+	rows, err := cs.Database.Query(selectQ, args...)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	messages := []ChatMessage{}
+	for rows.Next() {
+		sc := &sqlChatMessage{}
+
+		err = sc.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		messages = append(messages, *sc.toChatMessage())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return messages, nil
+}
+
+func (cs *chatMessageService) CountByLivestream(livestream string) (int64, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM "%s"
+		WHERE livestream=?
+	`, chatMessageTable)
+
+	var count int64
+	err := cs.Database.QueryRow(selectQ, livestream).Scan(&count)
+	if err != nil {
+		return -1, pkgErr("error executing select query", err)
+	}
+
+	return count, nil
+}
+
+// CreateBatch inserts the messages in a single transaction and sets the ID of each inserted message.
+// Messages that were already stored, e.g. replayed when reconnecting to a chat stream, are skipped and keep a nil ID.
+func (cs *chatMessageService) CreateBatch(messages []ChatMessage) error {
+	for i := range messages {
+		err := runChatMessageValFuncs(
+			&messages[i],
+			chatMessageRequireLivestream,
+			chatMessageRequireTime,
+		)
+		if err != nil {
+			return pkgErr("invalid chat message", err)
+		}
+	}
+
+	tx, err := cs.Database.Begin()
+	if err != nil {
+		return pkgErr("error beginning transaction", err)
+	}
+	defer tx.Rollback()
+
+	columns := columnsNoID(chatMessageColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		ON CONFLICT (livestream, username, time, text) DO NOTHING
+		RETURNING id
+	`, chatMessageTable, columns, values(columns))
+
+	stmt, err := tx.Prepare(insertQ)
+	if err != nil {
+		return pkgErr("error preparing insert query", err)
+	}
+	defer stmt.Close()
+
+	ids := make([]*int64, len(messages))
+	for i, message := range messages {
+		var id int64
+		err = stmt.QueryRow(message.valuesNoID()...).Scan(&id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return pkgErr("error executing insert query", err)
+		}
+
+		ids[i] = &id
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return pkgErr("error committing transaction", err)
+	}
+
+	for i := range messages {
+		messages[i].ID = ids[i]
+	}
+
+	return nil
+}
+
+func (cs *chatMessageService) DestructiveReset() error {
+	err := cs.dropChatMessageFtsTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", chatMessageFtsTable), err)
+	}
+
+	err = cs.dropChatMessageTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", chatMessageTable), err)
+	}
+
+	return nil
+}
+
+func (cs *chatMessageService) dropChatMessageTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, chatMessageTable)
+
+	_, err := cs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+// dropChatMessageFtsTable drops the full-text index.
+// Without FTS5 only the triggers can be dropped, the virtual table is left for when FTS5 is available again.
+func (cs *chatMessageService) dropChatMessageFtsTable() error {
+	err := cs.dropChatMessageFtsTriggers()
+	if err != nil {
+		return err
+	}
+	if !cs.fts {
+		return nil
+	}
+
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, chatMessageFtsTable)
+
+	_, err = cs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+func (cs *chatMessageService) dropChatMessageFtsTriggers() error {
+	dropQ := fmt.Sprintf(`
+		DROP TRIGGER IF EXISTS "%[1]s_ai";
+		DROP TRIGGER IF EXISTS "%[1]s_ad";
+		DROP TRIGGER IF EXISTS "%[1]s_au";
+	`, chatMessageTable)
+
+	_, err := cs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop triggers query: %v", err)
+	}
+
+	return nil
+}
+
+// Livestreams returns every livestream with stored chat, most recent first.
+func (cs *chatMessageService) Livestreams() ([]string, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT livestream
+		FROM "%s"
+		GROUP BY livestream
+		ORDER BY MAX(time) DESC
+	`, chatMessageTable)
+
+	rows, err := cs.Database.Query(selectQ)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	livestreams := []string{}
+	for rows.Next() {
+		var livestream string
+		err = rows.Scan(&livestream)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		livestreams = append(livestreams, livestream)
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return livestreams, nil
+}
+
+// ChatMessageSearch filters a chat message search.
+// Query is matched against message text; words must all match and double-quoted words match as a phrase.
+// Empty fields are ignored.
+type ChatMessageSearch struct {
+	Query      string     `json:"query"`
+	Username   string     `json:"username"`
+	Livestream string     `json:"livestream"`
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+	Rants      bool       `json:"rants"`
+	Raids      bool       `json:"raids"`
+	Offset     int        `json:"offset"`
+	Limit      int        `json:"limit"`
+}
+
+// ChatMessageResult is a chat message matched by a search.
+// Highlight is the HTML-escaped message text with matches wrapped in <mark> tags.
+type ChatMessageResult struct {
+	ChatMessage
+	Highlight string  `json:"highlight"`
+	Rank      float64 `json:"rank"`
+}
+
+// Search returns the messages matching s and the total number of matches.
+// Results with a query are ranked by relevance if FTS5 is available, otherwise by most recent.
+func (cs *chatMessageService) Search(s ChatMessageSearch) ([]ChatMessageResult, int64, error) {
+	where := []string{}
+	args := []any{}
+
+	terms := searchTerms(s.Query)
+	query := ""
+	if cs.fts {
+		query = ftsQuery(terms)
+	}
+	if query != "" {
+		where = append(where, fmt.Sprintf(`"%s".text MATCH ?`, chatMessageFtsTable))
+		args = append(args, query)
+	}
+	if !cs.fts {
+		for _, term := range terms {
+			where = append(where, `m.text LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+likeEscaper.Replace(term)+"%")
+		}
+	}
+	if s.Username != "" {
+		where = append(where, "m.username=? COLLATE NOCASE")
+		args = append(args, s.Username)
+	}
+	if s.Livestream != "" {
+		where = append(where, "m.livestream=?")
+		args = append(args, s.Livestream)
+	}
+	if s.From != nil {
+		where = append(where, "m.time>=?")
+		args = append(args, s.From.UTC())
+	}
+	if s.To != nil {
+		where = append(where, "m.time<=?")
+		args = append(args, s.To.UTC())
+	}
+	if s.Rants {
+		where = append(where, "m.rant>0")
+	}
+	if s.Raids {
+		where = append(where, "m.raid")
+	}
+
+	from := fmt.Sprintf(`"%s" AS m`, chatMessageTable)
+	highlight := "m.text"
+	rank := "0"
+	order := "m.time DESC, m.id DESC"
+	if query != "" {
+		from = fmt.Sprintf(`"%[1]s" JOIN "%[2]s" AS m ON m.id="%[1]s".rowid`, chatMessageFtsTable, chatMessageTable)
+		highlight = fmt.Sprintf(`highlight("%s", 0, char(2), char(3))`, chatMessageFtsTable)
+		rank = fmt.Sprintf(`bm25("%s")`, chatMessageFtsTable)
+		order = "rank, m.time DESC"
+	}
+
+	whereQ := ""
+	if len(where) > 0 {
+		whereQ = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	countQ := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM %s
+		%s
+	`, from, whereQ)
+
+	var total int64
+	err := cs.Database.QueryRow(countQ, args...).Scan(&total)
+	if err != nil {
+		return nil, -1, pkgErr("error executing count query", err)
+	}
+
+	limit := s.Limit
+	if limit < 1 {
+		limit = -1
+	}
+	columns := "m." + strings.ReplaceAll(chatMessageColumns, ", ", ", m.")
+	selectQ := fmt.Sprintf(`
+		SELECT %s, %s, %s AS rank
+		FROM %s
+		%s
+		ORDER BY %s
+		LIMIT ? OFFSET ?
+	`, columns, highlight, rank, from, whereQ, order)
+
+	rows, err := cs.Database.Query(selectQ, append(args, limit, s.Offset)...)
+	if err != nil {
+		return nil, -1, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	results := []ChatMessageResult{}
+	for rows.Next() {
+		var sc sqlChatMessage
+		var highlight sql.NullString
+		var rank float64
+
+		err = rows.Scan(&sc.id, &sc.livestream, &sc.username, &sc.channelName, &sc.badges, &sc.rant, &sc.raid, &sc.sub, &sc.text, &sc.time, &highlight, &rank)
+		if err != nil {
+			return nil, -1, pkgErr("error scanning row", err)
+		}
+
+		h := highlight.String
+		if !cs.fts {
+			h = highlightTerms(h, terms)
+		}
+		results = append(results, ChatMessageResult{
+			ChatMessage: *sc.toChatMessage(),
+			Highlight:   highlightHTML(h),
+			Rank:        rank,
+		})
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, -1, pkgErr("error iterating over rows", err)
+	}
+
+	return results, total, nil
+}
+
+// highlightHTML escapes the highlighted text and replaces the match markers with <mark> tags.
+func highlightHTML(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "\x02", "<mark>")
+	s = strings.ReplaceAll(s, "\x03", "</mark>")
+
+	return s
+}
+
+// searchTerms splits user input into words, keeping double-quoted text together as a phrase.
+func searchTerms(q string) []string {
+	terms := []string{}
+	for i, part := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			part = strings.TrimSpace(part)
+			if part != "" {
+				terms = append(terms, part)
+			}
+			continue
+		}
+
+		terms = append(terms, strings.Fields(part)...)
+	}
+
+	return terms
+}
+
+// ftsQuery converts search terms into an FTS5 query, so that FTS5 syntax characters are matched literally.
+func ftsQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"`
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// highlightTerms marks the terms in text like the FTS5 highlight function, ignoring ASCII case as LIKE does.
+func highlightTerms(text string, terms []string) string {
+	lower := asciiLower(text)
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		term = asciiLower(term)
+		for i := 0; term != "" && i+len(term) <= len(lower); {
+			j := strings.Index(lower[i:], term)
+			if j < 0 {
+				break
+			}
+			for k := i + j; k < i+j+len(term); k++ {
+				marked[k] = true
+			}
+			i += j + len(term)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteByte('\x02')
+		}
+		b.WriteByte(text[i])
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			b.WriteByte('\x03')
+		}
+	}
+
+	return b.String()
+}
+
+// asciiLower lowers ASCII letters only, keeping byte offsets the same.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+
+	return string(b)
+}
+
+type chatMessageValFunc func(*ChatMessage) error
+
+func runChatMessageValFuncs(c *ChatMessage, fns ...chatMessageValFunc) error {
+	if c == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func chatMessageRequireLivestream(c *ChatMessage) error {
+	if c.Livestream == nil || *c.Livestream == "" {
+		return ErrChatMessageInvalidLivestream
+	}
+
+	return nil
+}
+
+func chatMessageRequireTime(c *ChatMessage) error {
+	if c.Time == nil || c.Time.IsZero() {
+		return ErrChatMessageInvalidTime
+	}
+
+	return nil
+}
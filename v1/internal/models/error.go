@@ -19,6 +19,78 @@ const (
 
 	ErrChatbotRuleInvalidID         ValidatorError = "invalid chatbot rule id"
 	ErrChatbotRuleInvalidParameters ValidatorError = "invalid chatbot rule parameters"
+
+	ErrChatMessageInvalidLivestream ValidatorError = "invalid chat message livestream"
+	ErrChatMessageInvalidTime       ValidatorError = "invalid chat message time"
+
+	ErrChatbotSupervisorInvalidChatbotID ValidatorError = "invalid chatbot supervisor chatbot id"
+	ErrChatbotSupervisorInvalidEnabled   ValidatorError = "invalid chatbot supervisor enabled"
+	ErrChatbotSupervisorInvalidID        ValidatorError = "invalid chatbot supervisor id"
+
+	ErrFollowerInvalidFollowedOn ValidatorError = "invalid follower followed on"
+	ErrFollowerInvalidPage       ValidatorError = "invalid follower page"
+	ErrFollowerInvalidUsername   ValidatorError = "invalid follower username"
+
+	ErrFollowerCountInvalidFollowers ValidatorError = "invalid follower count followers"
+	ErrFollowerCountInvalidPage      ValidatorError = "invalid follower count page"
+	ErrFollowerCountInvalidTime      ValidatorError = "invalid follower count time"
+
+	ErrFollowerMarkInvalidFollowedOn ValidatorError = "invalid follower mark followed on"
+	ErrFollowerMarkInvalidPage       ValidatorError = "invalid follower mark page"
+
+	ErrGoalInvalidID         ValidatorError = "invalid goal id"
+	ErrGoalInvalidKind       ValidatorError = "invalid goal kind"
+	ErrGoalInvalidMilestone  ValidatorError = "invalid goal milestone"
+	ErrGoalInvalidMilestones ValidatorError = "invalid goal milestones"
+	ErrGoalInvalidPage       ValidatorError = "invalid goal page"
+	ErrGoalInvalidProgress   ValidatorError = "invalid goal progress"
+	ErrGoalInvalidReset      ValidatorError = "invalid goal reset"
+	ErrGoalInvalidStartedAt  ValidatorError = "invalid goal started at"
+	ErrGoalInvalidTarget     ValidatorError = "invalid goal target"
+	ErrGoalInvalidTitle      ValidatorError = "invalid goal title"
+
+	ErrRantInvalidAmountCents ValidatorError = "invalid rant amount cents"
+	ErrRantInvalidID          ValidatorError = "invalid rant id"
+	ErrRantInvalidLivestream  ValidatorError = "invalid rant livestream"
+	ErrRantInvalidTime        ValidatorError = "invalid rant time"
+	ErrRantInvalidUsername    ValidatorError = "invalid rant username"
+
+	ErrSessionReportInvalidEnd        ValidatorError = "invalid session report end"
+	ErrSessionReportInvalidID         ValidatorError = "invalid session report id"
+	ErrSessionReportInvalidLivestream ValidatorError = "invalid session report livestream"
+	ErrSessionReportInvalidReport     ValidatorError = "invalid session report report"
+
+	ErrStreamChangeInvalidKind       ValidatorError = "invalid stream change kind"
+	ErrStreamChangeInvalidLivestream ValidatorError = "invalid stream change livestream"
+	ErrStreamChangeInvalidPage       ValidatorError = "invalid stream change page"
+	ErrStreamChangeInvalidPrevious   ValidatorError = "invalid stream change previous"
+	ErrStreamChangeInvalidTime       ValidatorError = "invalid stream change time"
+	ErrStreamChangeInvalidValue      ValidatorError = "invalid stream change value"
+
+	ErrStreamStatInvalidLivestream ValidatorError = "invalid stream stat livestream"
+	ErrStreamStatInvalidTime       ValidatorError = "invalid stream stat time"
+
+	ErrSubscriberInvalidPage         ValidatorError = "invalid subscriber page"
+	ErrSubscriberInvalidSubscribedOn ValidatorError = "invalid subscriber subscribed on"
+	ErrSubscriberInvalidUsername     ValidatorError = "invalid subscriber username"
+
+	ErrViewerInvalidUsername ValidatorError = "invalid viewer username"
+
+	ErrWebhookInvalidCreatedAt ValidatorError = "invalid webhook created at"
+	ErrWebhookInvalidEnabled   ValidatorError = "invalid webhook enabled"
+	ErrWebhookInvalidEvents    ValidatorError = "invalid webhook events"
+	ErrWebhookInvalidID        ValidatorError = "invalid webhook id"
+	ErrWebhookInvalidName      ValidatorError = "invalid webhook name"
+	ErrWebhookInvalidSecret    ValidatorError = "invalid webhook secret"
+	ErrWebhookInvalidUrl       ValidatorError = "invalid webhook url"
+
+	ErrWebhookDeliveryInvalidAttempts  ValidatorError = "invalid webhook delivery attempts"
+	ErrWebhookDeliveryInvalidCreatedAt ValidatorError = "invalid webhook delivery created at"
+	ErrWebhookDeliveryInvalidEvent     ValidatorError = "invalid webhook delivery event"
+	ErrWebhookDeliveryInvalidID        ValidatorError = "invalid webhook delivery id"
+	ErrWebhookDeliveryInvalidPayload   ValidatorError = "invalid webhook delivery payload"
+	ErrWebhookDeliveryInvalidStatus    ValidatorError = "invalid webhook delivery status"
+	ErrWebhookDeliveryInvalidWebhookID ValidatorError = "invalid webhook delivery webhook id"
 )
 
 func pkgErr(prefix string, err error) error {
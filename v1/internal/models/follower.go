@@ -0,0 +1,513 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	followerColumns      = "id, page, username, first_followed_on, followed_on, follows, last_seen"
+	followerTable        = "follower"
+	followerCountColumns = "id, page, time, followers, new_followers"
+	followerCountTable   = "follower_count"
+)
+
+// Follower is a user who followed a page.
+// FollowedOn is the most recent follow and Follows counts every follow seen, including returns after unfollowing.
+type Follower struct {
+	ID              *int64     `json:"id"`
+	Page            *string    `json:"page"`
+	Username        *string    `json:"username"`
+	FirstFollowedOn *time.Time `json:"first_followed_on"`
+	FollowedOn      *time.Time `json:"followed_on"`
+	Follows         *int64     `json:"follows"`
+	LastSeen        *time.Time `json:"last_seen"`
+}
+
+type sqlFollower struct {
+	id              sql.NullInt64
+	page            sql.NullString
+	username        sql.NullString
+	firstFollowedOn sql.NullTime
+	followedOn      sql.NullTime
+	follows         sql.NullInt64
+	lastSeen        sql.NullTime
+}
+
+func (sf *sqlFollower) scan(r Row) error {
+	return r.Scan(&sf.id, &sf.page, &sf.username, &sf.firstFollowedOn, &sf.followedOn, &sf.follows, &sf.lastSeen)
+}
+
+func (sf sqlFollower) toFollower() *Follower {
+	var f Follower
+	f.ID = toInt64(sf.id)
+	f.Page = toString(sf.page)
+	f.Username = toString(sf.username)
+	f.FirstFollowedOn = toTime(sf.firstFollowedOn)
+	f.FollowedOn = toTime(sf.followedOn)
+	f.Follows = toInt64(sf.follows)
+	f.LastSeen = toTime(sf.lastSeen)
+
+	return &f
+}
+
+// FollowerCount is the follower count of a page at a point in time.
+// NewFollowers are the follows seen since the previous count.
+type FollowerCount struct {
+	ID           *int64     `json:"id"`
+	Page         *string    `json:"page"`
+	Time         *time.Time `json:"time"`
+	Followers    *int64     `json:"followers"`
+	NewFollowers *int64     `json:"new_followers"`
+}
+
+func (fc *FollowerCount) values() []any {
+	return []any{fc.ID, fc.Page, fc.Time, fc.Followers, fc.NewFollowers}
+}
+
+func (fc *FollowerCount) valuesNoID() []any {
+	return fc.values()[1:]
+}
+
+type sqlFollowerCount struct {
+	id           sql.NullInt64
+	page         sql.NullString
+	time         sql.NullTime
+	followers    sql.NullInt64
+	newFollowers sql.NullInt64
+}
+
+func (sfc *sqlFollowerCount) scan(r Row) error {
+	return r.Scan(&sfc.id, &sfc.page, &sfc.time, &sfc.followers, &sfc.newFollowers)
+}
+
+func (sfc sqlFollowerCount) toFollowerCount() *FollowerCount {
+	var fc FollowerCount
+	fc.ID = toInt64(sfc.id)
+	fc.Page = toString(sfc.page)
+	fc.Time = toTime(sfc.time)
+	fc.Followers = toInt64(sfc.followers)
+	fc.NewFollowers = toInt64(sfc.newFollowers)
+
+	return &fc
+}
+
+// FollowerObservation is a follow listed in an API response.
+type FollowerObservation struct {
+	Username   string
+	FollowedOn time.Time
+}
+
+type FollowerStatus string
+
+const (
+	FollowerStatusNew       FollowerStatus = "new"
+	FollowerStatusReturning FollowerStatus = "returning"
+)
+
+// FollowerChange is a follow that was not in the history before it was merged.
+type FollowerChange struct {
+	Follower Follower       `json:"follower"`
+	Status   FollowerStatus `json:"status"`
+}
+
+type FollowerService interface {
+	AutoMigrate() error
+	ByPage(page string) ([]Follower, error)
+	CountByPage(page string) (int64, error)
+	Counts(page string, from time.Time, to time.Time) ([]FollowerCount, error)
+	CreateCount(fc *FollowerCount) (int64, error)
+	DestructiveReset() error
+	LatestCount(page string) (*FollowerCount, error)
+	Merge(page string, observations []FollowerObservation, seen time.Time) ([]FollowerChange, error)
+}
+
+func NewFollowerService(db *sql.DB) FollowerService {
+	return &followerService{
+		Database: db,
+	}
+}
+
+var _ FollowerService = &followerService{}
+
+type followerService struct {
+	Database *sql.DB
+}
+
+func (fs *followerService) AutoMigrate() error {
+	err := fs.createFollowerTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", followerTable), err)
+	}
+
+	err = fs.createFollowerCountTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", followerCountTable), err)
+	}
+
+	return nil
+}
+
+func (fs *followerService) createFollowerTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			page TEXT NOT NULL,
+			username TEXT NOT NULL COLLATE NOCASE,
+			first_followed_on DATETIME NOT NULL,
+			followed_on DATETIME NOT NULL,
+			follows INTEGER NOT NULL DEFAULT 1,
+			last_seen DATETIME NOT NULL,
+			UNIQUE (page, username)
+		)
+	`, followerTable)
+
+	_, err := fs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+func (fs *followerService) createFollowerCountTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			page TEXT NOT NULL,
+			time DATETIME NOT NULL,
+			followers INTEGER NOT NULL,
+			new_followers INTEGER NOT NULL DEFAULT 0
+		)
+	`, followerCountTable)
+
+	_, err := fs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	indexQ := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS "%s_page_time" ON "%s" (page, time)
+	`, followerCountTable, followerCountTable)
+
+	_, err = fs.Database.Exec(indexQ)
+	if err != nil {
+		return fmt.Errorf("error executing create index query: %v", err)
+	}
+
+	return nil
+}
+
+// ByPage returns the page's followers, most recent follow first.
+func (fs *followerService) ByPage(page string) ([]Follower, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=?
+		ORDER BY followed_on DESC
+	`, followerColumns, followerTable)
+
+	rows, err := fs.Database.Query(selectQ, page)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	followers := []Follower{}
+	for rows.Next() {
+		sf := &sqlFollower{}
+
+		err = sf.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		followers = append(followers, *sf.toFollower())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return followers, nil
+}
+
+func (fs *followerService) CountByPage(page string) (int64, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM "%s"
+		WHERE page=?
+	`, followerTable)
+
+	var count int64
+	err := fs.Database.QueryRow(selectQ, page).Scan(&count)
+	if err != nil {
+		return -1, pkgErr("error executing select query", err)
+	}
+
+	return count, nil
+}
+
+// Counts returns the page's follower counts recorded between from and to, oldest first.
+func (fs *followerService) Counts(page string, from time.Time, to time.Time) ([]FollowerCount, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=? AND time>=? AND time<=?
+		ORDER BY time, id
+	`, followerCountColumns, followerCountTable)
+
+	rows, err := fs.Database.Query(selectQ, page, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	counts := []FollowerCount{}
+	for rows.Next() {
+		sfc := &sqlFollowerCount{}
+
+		err = sfc.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		counts = append(counts, *sfc.toFollowerCount())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return counts, nil
+}
+
+func (fs *followerService) CreateCount(fc *FollowerCount) (int64, error) {
+	err := runFollowerCountValFuncs(
+		fc,
+		followerCountRequirePage,
+		followerCountRequireTime,
+		followerCountRequireFollowers,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid follower count", err)
+	}
+
+	t := fc.Time.UTC()
+	fc.Time = &t
+
+	columns := columnsNoID(followerCountColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, followerCountTable, columns, values(columns))
+
+	var id int64
+	row := fs.Database.QueryRow(insertQ, fc.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (fs *followerService) DestructiveReset() error {
+	err := fs.dropTable(followerCountTable)
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", followerCountTable), err)
+	}
+
+	err = fs.dropTable(followerTable)
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", followerTable), err)
+	}
+
+	return nil
+}
+
+func (fs *followerService) dropTable(table string) error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, table)
+
+	_, err := fs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+// LatestCount returns the page's most recent follower count, or nil if none was recorded.
+func (fs *followerService) LatestCount(page string) (*FollowerCount, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=?
+		ORDER BY time DESC, id DESC
+		LIMIT 1
+	`, followerCountColumns, followerCountTable)
+
+	var sfc sqlFollowerCount
+	row := fs.Database.QueryRow(selectQ, page)
+	err := sfc.scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, pkgErr("error executing select query", err)
+	}
+
+	return sfc.toFollowerCount(), nil
+}
+
+// Merge adds the observed follows to the page's follower history.
+// It returns the follows that are new, either from a user never seen following the page
+// or from a user following again after unfollowing.
+func (fs *followerService) Merge(page string, observations []FollowerObservation, seen time.Time) ([]FollowerChange, error) {
+	if page == "" {
+		return nil, pkgErr("", ErrFollowerInvalidPage)
+	}
+	for _, o := range observations {
+		if o.Username == "" {
+			return nil, pkgErr("invalid follower observation", ErrFollowerInvalidUsername)
+		}
+		if o.FollowedOn.IsZero() {
+			return nil, pkgErr("invalid follower observation", ErrFollowerInvalidFollowedOn)
+		}
+	}
+
+	tx, err := fs.Database.Begin()
+	if err != nil {
+		return nil, pkgErr("error beginning transaction", err)
+	}
+	defer tx.Rollback()
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=? AND username=?
+	`, followerColumns, followerTable)
+
+	sel, err := tx.Prepare(selectQ)
+	if err != nil {
+		return nil, pkgErr("error preparing select query", err)
+	}
+	defer sel.Close()
+
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (page, username, first_followed_on, followed_on, follows, last_seen)
+		VALUES (?, ?, ?, ?, 1, ?)
+		RETURNING %s
+	`, followerTable, followerColumns)
+
+	insert, err := tx.Prepare(insertQ)
+	if err != nil {
+		return nil, pkgErr("error preparing insert query", err)
+	}
+	defer insert.Close()
+
+	updateQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET followed_on=?, follows=follows+?, last_seen=?
+		WHERE id=?
+		RETURNING %s
+	`, followerTable, followerColumns)
+
+	update, err := tx.Prepare(updateQ)
+	if err != nil {
+		return nil, pkgErr("error preparing update query", err)
+	}
+	defer update.Close()
+
+	seen = seen.UTC()
+	changes := []FollowerChange{}
+	for _, o := range observations {
+		followedOn := o.FollowedOn.UTC()
+
+		var sf sqlFollower
+		err = sf.scan(sel.QueryRow(page, o.Username))
+		if err == sql.ErrNoRows {
+			var inserted sqlFollower
+			err = inserted.scan(insert.QueryRow(page, o.Username, followedOn, followedOn, seen))
+			if err != nil {
+				return nil, pkgErr("error executing insert query", err)
+			}
+			changes = append(changes, FollowerChange{*inserted.toFollower(), FollowerStatusNew})
+			continue
+		}
+		if err != nil {
+			return nil, pkgErr("error executing select query", err)
+		}
+
+		// A later follow time means the user unfollowed and followed again.
+		follows := 0
+		status := FollowerStatus("")
+		latest := sf.followedOn.Time
+		if followedOn.After(latest) {
+			follows = 1
+			status = FollowerStatusReturning
+			latest = followedOn
+		}
+
+		var updated sqlFollower
+		err = updated.scan(update.QueryRow(latest, follows, seen, sf.id.Int64))
+		if err != nil {
+			return nil, pkgErr("error executing update query", err)
+		}
+		if status != "" {
+			changes = append(changes, FollowerChange{*updated.toFollower(), status})
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, pkgErr("error committing transaction", err)
+	}
+
+	return changes, nil
+}
+
+type followerCountValFunc func(*FollowerCount) error
+
+func runFollowerCountValFuncs(fc *FollowerCount, fns ...followerCountValFunc) error {
+	if fc == nil {
+		return fmt.Errorf("follower count is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(fc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func followerCountRequirePage(fc *FollowerCount) error {
+	if fc.Page == nil || *fc.Page == "" {
+		return ErrFollowerCountInvalidPage
+	}
+
+	return nil
+}
+
+func followerCountRequireTime(fc *FollowerCount) error {
+	if fc.Time == nil || fc.Time.IsZero() {
+		return ErrFollowerCountInvalidTime
+	}
+
+	return nil
+}
+
+func followerCountRequireFollowers(fc *FollowerCount) error {
+	if fc.Followers == nil || *fc.Followers < 0 {
+		return ErrFollowerCountInvalidFollowers
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	followerMarkColumns = "id, page, followed_on, usernames"
+	followerMarkTable   = "follower_mark"
+)
+
+// FollowerMark is the most recent follow already handled for a page.
+// Usernames are the users who followed at FollowedOn, stored comma-separated,
+// so other follows at the same time are not mistaken for follows already handled.
+type FollowerMark struct {
+	ID         *int64     `json:"id"`
+	Page       *string    `json:"page"`
+	FollowedOn *time.Time `json:"followed_on"`
+	Usernames  []string   `json:"usernames"`
+}
+
+type sqlFollowerMark struct {
+	id         sql.NullInt64
+	page       sql.NullString
+	followedOn sql.NullTime
+	usernames  sql.NullString
+}
+
+func (sfm *sqlFollowerMark) scan(r Row) error {
+	return r.Scan(&sfm.id, &sfm.page, &sfm.followedOn, &sfm.usernames)
+}
+
+func (sfm sqlFollowerMark) toFollowerMark() *FollowerMark {
+	var fm FollowerMark
+	fm.ID = toInt64(sfm.id)
+	fm.Page = toString(sfm.page)
+	fm.FollowedOn = toTime(sfm.followedOn)
+	fm.Usernames = []string{}
+	if sfm.usernames.Valid && sfm.usernames.String != "" {
+		fm.Usernames = strings.Split(sfm.usernames.String, ",")
+	}
+
+	return &fm
+}
+
+type FollowerMarkService interface {
+	AutoMigrate() error
+	ByPage(page string) (*FollowerMark, error)
+	DestructiveReset() error
+	Upsert(fm *FollowerMark) error
+}
+
+func NewFollowerMarkService(db *sql.DB) FollowerMarkService {
+	return &followerMarkService{
+		Database: db,
+	}
+}
+
+var _ FollowerMarkService = &followerMarkService{}
+
+type followerMarkService struct {
+	Database *sql.DB
+}
+
+func (fms *followerMarkService) AutoMigrate() error {
+	err := fms.createFollowerMarkTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", followerMarkTable), err)
+	}
+
+	return nil
+}
+
+func (fms *followerMarkService) createFollowerMarkTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			page TEXT UNIQUE NOT NULL,
+			followed_on DATETIME NOT NULL,
+			usernames TEXT NOT NULL
+		)
+	`, followerMarkTable)
+
+	_, err := fms.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+// ByPage returns the page's follower mark, or nil if none was saved.
+func (fms *followerMarkService) ByPage(page string) (*FollowerMark, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=?
+	`, followerMarkColumns, followerMarkTable)
+
+	var sfm sqlFollowerMark
+	row := fms.Database.QueryRow(selectQ, page)
+	err := sfm.scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, pkgErr("error executing select query", err)
+	}
+
+	return sfm.toFollowerMark(), nil
+}
+
+func (fms *followerMarkService) DestructiveReset() error {
+	err := fms.dropFollowerMarkTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", followerMarkTable), err)
+	}
+
+	return nil
+}
+
+func (fms *followerMarkService) dropFollowerMarkTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, followerMarkTable)
+
+	_, err := fms.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+// Upsert saves the page's follower mark, replacing the previous one.
+func (fms *followerMarkService) Upsert(fm *FollowerMark) error {
+	err := runFollowerMarkValFuncs(
+		fm,
+		followerMarkRequirePage,
+		followerMarkRequireFollowedOn,
+	)
+	if err != nil {
+		return pkgErr("invalid follower mark", err)
+	}
+
+	upsertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (page, followed_on, usernames)
+		VALUES (?, ?, ?)
+		ON CONFLICT (page) DO UPDATE SET
+			followed_on=excluded.followed_on,
+			usernames=excluded.usernames
+	`, followerMarkTable)
+
+	_, err = fms.Database.Exec(upsertQ, fm.Page, fm.FollowedOn.UTC(), strings.Join(fm.Usernames, ","))
+	if err != nil {
+		return pkgErr("error executing upsert query", err)
+	}
+
+	return nil
+}
+
+type followerMarkValFunc func(*FollowerMark) error
+
+func runFollowerMarkValFuncs(fm *FollowerMark, fns ...followerMarkValFunc) error {
+	if fm == nil {
+		return fmt.Errorf("follower mark is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(fm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func followerMarkRequirePage(fm *FollowerMark) error {
+	if fm.Page == nil || *fm.Page == "" {
+		return ErrFollowerMarkInvalidPage
+	}
+
+	return nil
+}
+
+func followerMarkRequireFollowedOn(fm *FollowerMark) error {
+	if fm.FollowedOn == nil || fm.FollowedOn.IsZero() {
+		return ErrFollowerMarkInvalidFollowedOn
+	}
+
+	return nil
+}
@@ -0,0 +1,444 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	goalColumns = "id, page, title, kind, target, reset, milestones, baseline, progress, milestone, livestream, started_at, completed_at"
+	goalTable   = "goal"
+)
+
+const (
+	GoalKindFollowers   = "followers"
+	GoalKindRants       = "rants"
+	GoalKindSubscribers = "subscribers"
+
+	GoalResetNever  = "never"
+	GoalResetStream = "stream"
+)
+
+// Goal is a target number of new followers or subscribers, or of rant cents, for a page.
+// Milestones are the comma-separated percentages of the target announced when reached,
+// and Milestone is the highest one announced so far.
+// Baseline is the follower or subscriber count progress is measured from, or nil if it is not known yet.
+// Goals that reset every stream only make progress while the page is live on Livestream.
+type Goal struct {
+	ID          *int64     `json:"id"`
+	Page        *string    `json:"page"`
+	Title       *string    `json:"title"`
+	Kind        *string    `json:"kind"`
+	Target      *int64     `json:"target"`
+	Reset       *string    `json:"reset"`
+	Milestones  *string    `json:"milestones"`
+	Baseline    *int64     `json:"baseline"`
+	Progress    *int64     `json:"progress"`
+	Milestone   *int64     `json:"milestone"`
+	Livestream  *string    `json:"livestream"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+func (g *Goal) values() []any {
+	return []any{g.ID, g.Page, g.Title, g.Kind, g.Target, g.Reset, g.Milestones, g.Baseline, g.Progress, g.Milestone, g.Livestream, g.StartedAt, g.CompletedAt}
+}
+
+func (g *Goal) valuesNoID() []any {
+	return g.values()[1:]
+}
+
+func (g *Goal) valuesEndID() []any {
+	vals := g.values()
+	return append(vals[1:], vals[0])
+}
+
+func (g *Goal) utc() {
+	for _, t := range []**time.Time{&g.StartedAt, &g.CompletedAt} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+}
+
+type sqlGoal struct {
+	id          sql.NullInt64
+	page        sql.NullString
+	title       sql.NullString
+	kind        sql.NullString
+	target      sql.NullInt64
+	reset       sql.NullString
+	milestones  sql.NullString
+	baseline    sql.NullInt64
+	progress    sql.NullInt64
+	milestone   sql.NullInt64
+	livestream  sql.NullString
+	startedAt   sql.NullTime
+	completedAt sql.NullTime
+}
+
+func (sg *sqlGoal) scan(r Row) error {
+	return r.Scan(&sg.id, &sg.page, &sg.title, &sg.kind, &sg.target, &sg.reset, &sg.milestones, &sg.baseline, &sg.progress, &sg.milestone, &sg.livestream, &sg.startedAt, &sg.completedAt)
+}
+
+func (sg sqlGoal) toGoal() *Goal {
+	var g Goal
+	g.ID = toInt64(sg.id)
+	g.Page = toString(sg.page)
+	g.Title = toString(sg.title)
+	g.Kind = toString(sg.kind)
+	g.Target = toInt64(sg.target)
+	g.Reset = toString(sg.reset)
+	g.Milestones = toString(sg.milestones)
+	g.Baseline = toInt64(sg.baseline)
+	g.Progress = toInt64(sg.progress)
+	g.Milestone = toInt64(sg.milestone)
+	g.Livestream = toString(sg.livestream)
+	g.StartedAt = toTime(sg.startedAt)
+	g.CompletedAt = toTime(sg.completedAt)
+
+	return &g
+}
+
+type GoalService interface {
+	AutoMigrate() error
+	ByID(id int64) (*Goal, error)
+	ByPage(page string) ([]Goal, error)
+	Create(g *Goal) (int64, error)
+	Delete(g *Goal) error
+	DestructiveReset() error
+	Update(g *Goal) error
+}
+
+func NewGoalService(db *sql.DB) GoalService {
+	return &goalService{
+		Database: db,
+	}
+}
+
+var _ GoalService = &goalService{}
+
+type goalService struct {
+	Database *sql.DB
+}
+
+func (gs *goalService) AutoMigrate() error {
+	err := gs.createGoalTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", goalTable), err)
+	}
+
+	return nil
+}
+
+func (gs *goalService) createGoalTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			page TEXT NOT NULL,
+			title TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			target INTEGER NOT NULL,
+			reset TEXT NOT NULL,
+			milestones TEXT NOT NULL,
+			baseline INTEGER,
+			progress INTEGER NOT NULL,
+			milestone INTEGER NOT NULL,
+			livestream TEXT,
+			started_at DATETIME NOT NULL,
+			completed_at DATETIME
+		)
+	`, goalTable)
+
+	_, err := gs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+func (gs *goalService) ByID(id int64) (*Goal, error) {
+	err := runGoalValFuncs(
+		&Goal{ID: &id},
+		goalRequireID,
+	)
+	if err != nil {
+		return nil, pkgErr("", err)
+	}
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE id=?
+	`, goalColumns, goalTable)
+
+	var sg sqlGoal
+	row := gs.Database.QueryRow(selectQ, id)
+	err = sg.scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, pkgErr("error executing select query", err)
+	}
+
+	return sg.toGoal(), nil
+}
+
+// ByPage returns the page's goals in the order they were created.
+func (gs *goalService) ByPage(page string) ([]Goal, error) {
+	err := runGoalValFuncs(
+		&Goal{Page: &page},
+		goalRequirePage,
+	)
+	if err != nil {
+		return nil, pkgErr("", err)
+	}
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=?
+		ORDER BY id
+	`, goalColumns, goalTable)
+
+	rows, err := gs.Database.Query(selectQ, page)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	goals := []Goal{}
+	for rows.Next() {
+		sg := &sqlGoal{}
+
+		err = sg.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		goals = append(goals, *sg.toGoal())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return goals, nil
+}
+
+func (gs *goalService) Create(g *Goal) (int64, error) {
+	err := runGoalValFuncs(
+		g,
+		goalRequirePage,
+		goalRequireTitle,
+		goalRequireKind,
+		goalRequireTarget,
+		goalRequireReset,
+		goalRequireMilestones,
+		goalRequireProgress,
+		goalRequireMilestone,
+		goalRequireStartedAt,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid goal", err)
+	}
+
+	g.utc()
+
+	columns := columnsNoID(goalColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, goalTable, columns, values(columns))
+
+	var id int64
+	row := gs.Database.QueryRow(insertQ, g.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (gs *goalService) Delete(g *Goal) error {
+	err := runGoalValFuncs(
+		g,
+		goalRequireID,
+	)
+	if err != nil {
+		return pkgErr("invalid goal", err)
+	}
+
+	deleteQ := fmt.Sprintf(`
+		DELETE FROM "%s"
+		WHERE id=?
+	`, goalTable)
+
+	_, err = gs.Database.Exec(deleteQ, g.ID)
+	if err != nil {
+		return pkgErr("error executing delete query", err)
+	}
+
+	return nil
+}
+
+func (gs *goalService) DestructiveReset() error {
+	err := gs.dropGoalTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", goalTable), err)
+	}
+
+	return nil
+}
+
+func (gs *goalService) dropGoalTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, goalTable)
+
+	_, err := gs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+func (gs *goalService) Update(g *Goal) error {
+	err := runGoalValFuncs(
+		g,
+		goalRequireID,
+		goalRequirePage,
+		goalRequireTitle,
+		goalRequireKind,
+		goalRequireTarget,
+		goalRequireReset,
+		goalRequireMilestones,
+		goalRequireProgress,
+		goalRequireMilestone,
+		goalRequireStartedAt,
+	)
+	if err != nil {
+		return pkgErr("invalid goal", err)
+	}
+
+	g.utc()
+
+	columns := columnsNoID(goalColumns)
+	updateQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET %s
+		WHERE id=?
+	`, goalTable, set(columns))
+
+	_, err = gs.Database.Exec(updateQ, g.valuesEndID()...)
+	if err != nil {
+		return pkgErr("error executing update query", err)
+	}
+
+	return nil
+}
+
+type goalValFunc func(*Goal) error
+
+func runGoalValFuncs(g *Goal, fns ...goalValFunc) error {
+	if g == nil {
+		return fmt.Errorf("goal is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(g)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func goalRequireID(g *Goal) error {
+	if g.ID == nil || *g.ID < 1 {
+		return ErrGoalInvalidID
+	}
+
+	return nil
+}
+
+func goalRequireKind(g *Goal) error {
+	if g.Kind == nil || !slices.Contains([]string{GoalKindFollowers, GoalKindRants, GoalKindSubscribers}, *g.Kind) {
+		return ErrGoalInvalidKind
+	}
+
+	return nil
+}
+
+func goalRequireMilestone(g *Goal) error {
+	if g.Milestone == nil || *g.Milestone < 0 {
+		return ErrGoalInvalidMilestone
+	}
+
+	return nil
+}
+
+func goalRequireMilestones(g *Goal) error {
+	if g.Milestones == nil {
+		return ErrGoalInvalidMilestones
+	}
+
+	return nil
+}
+
+func goalRequirePage(g *Goal) error {
+	if g.Page == nil || *g.Page == "" {
+		return ErrGoalInvalidPage
+	}
+
+	return nil
+}
+
+func goalRequireProgress(g *Goal) error {
+	if g.Progress == nil {
+		return ErrGoalInvalidProgress
+	}
+
+	return nil
+}
+
+func goalRequireReset(g *Goal) error {
+	if g.Reset == nil || (*g.Reset != GoalResetNever && *g.Reset != GoalResetStream) {
+		return ErrGoalInvalidReset
+	}
+
+	return nil
+}
+
+func goalRequireStartedAt(g *Goal) error {
+	if g.StartedAt == nil || g.StartedAt.IsZero() {
+		return ErrGoalInvalidStartedAt
+	}
+
+	return nil
+}
+
+func goalRequireTarget(g *Goal) error {
+	if g.Target == nil || *g.Target < 1 {
+		return ErrGoalInvalidTarget
+	}
+
+	return nil
+}
+
+func goalRequireTitle(g *Goal) error {
+	if g.Title == nil || *g.Title == "" {
+		return ErrGoalInvalidTitle
+	}
+
+	return nil
+}
@@ -0,0 +1,349 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	rantColumns = "id, livestream, username, amount_cents, text, time, chat_time, api_time"
+	rantTable   = "rant"
+)
+
+// Rant is a paid chat message.
+// ChatTime and ApiTime are the times each source reported for the rant, or nil if the rant was not seen in that source.
+type Rant struct {
+	ID          *int64     `json:"id"`
+	Livestream  *string    `json:"livestream"`
+	Username    *string    `json:"username"`
+	AmountCents *int64     `json:"amount_cents"`
+	Text        *string    `json:"text"`
+	Time        *time.Time `json:"time"`
+	ChatTime    *time.Time `json:"chat_time"`
+	ApiTime     *time.Time `json:"api_time"`
+}
+
+func (r *Rant) values() []any {
+	return []any{r.ID, r.Livestream, r.Username, r.AmountCents, r.Text, r.Time, r.ChatTime, r.ApiTime}
+}
+
+func (r *Rant) valuesNoID() []any {
+	return r.values()[1:]
+}
+
+func (r *Rant) valuesEndID() []any {
+	vals := r.values()
+	return append(vals[1:], vals[0])
+}
+
+func (r *Rant) utc() {
+	for _, t := range []**time.Time{&r.Time, &r.ChatTime, &r.ApiTime} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+}
+
+type sqlRant struct {
+	id          sql.NullInt64
+	livestream  sql.NullString
+	username    sql.NullString
+	amountCents sql.NullInt64
+	text        sql.NullString
+	time        sql.NullTime
+	chatTime    sql.NullTime
+	apiTime     sql.NullTime
+}
+
+func (sr *sqlRant) scan(r Row) error {
+	return r.Scan(&sr.id, &sr.livestream, &sr.username, &sr.amountCents, &sr.text, &sr.time, &sr.chatTime, &sr.apiTime)
+}
+
+func (sr sqlRant) toRant() *Rant {
+	var r Rant
+	r.ID = toInt64(sr.id)
+	r.Livestream = toString(sr.livestream)
+	r.Username = toString(sr.username)
+	r.AmountCents = toInt64(sr.amountCents)
+	r.Text = toString(sr.text)
+	r.Time = toTime(sr.time)
+	r.ChatTime = toTime(sr.chatTime)
+	r.ApiTime = toTime(sr.apiTime)
+
+	return &r
+}
+
+type RantService interface {
+	AutoMigrate() error
+	Between(from *time.Time, to *time.Time) ([]Rant, error)
+	ByLivestream(livestream string) ([]Rant, error)
+	Create(r *Rant) (int64, error)
+	DestructiveReset() error
+	Near(livestream string, username string, amountCents int64, from time.Time, to time.Time) ([]Rant, error)
+	Update(r *Rant) error
+}
+
+func NewRantService(db *sql.DB) RantService {
+	return &rantService{
+		Database: db,
+	}
+}
+
+var _ RantService = &rantService{}
+
+type rantService struct {
+	Database *sql.DB
+}
+
+func (rs *rantService) AutoMigrate() error {
+	err := rs.createRantTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", rantTable), err)
+	}
+
+	return nil
+}
+
+func (rs *rantService) createRantTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			livestream TEXT NOT NULL,
+			username TEXT NOT NULL,
+			amount_cents INTEGER NOT NULL,
+			text TEXT,
+			time DATETIME NOT NULL,
+			chat_time DATETIME,
+			api_time DATETIME
+		)
+	`, rantTable)
+
+	_, err := rs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	indexQ := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS "%s_time" ON "%s" (time)
+	`, rantTable, rantTable)
+
+	_, err = rs.Database.Exec(indexQ)
+	if err != nil {
+		return fmt.Errorf("error executing create index query: %v", err)
+	}
+
+	return nil
+}
+
+// Between returns the rants sent between from and to, oldest first.
+// A nil bound is unbounded.
+func (rs *rantService) Between(from *time.Time, to *time.Time) ([]Rant, error) {
+	lower := time.Time{}
+	if from != nil {
+		lower = from.UTC()
+	}
+	upper := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	if to != nil {
+		upper = to.UTC()
+	}
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE time>=? AND time<=?
+		ORDER BY time, id
+	`, rantColumns, rantTable)
+
+	return rs.query(selectQ, lower, upper)
+}
+
+func (rs *rantService) ByLivestream(livestream string) ([]Rant, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE livestream=?
+		ORDER BY time, id
+	`, rantColumns, rantTable)
+
+	return rs.query(selectQ, livestream)
+}
+
+func (rs *rantService) query(selectQ string, args ...any) ([]Rant, error) {
+	rows, err := rs.Database.Query(selectQ, args...)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	rants := []Rant{}
+	for rows.Next() {
+		sr := &sqlRant{}
+
+		err = sr.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		rants = append(rants, *sr.toRant())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return rants, nil
+}
+
+func (rs *rantService) Create(r *Rant) (int64, error) {
+	err := runRantValFuncs(
+		r,
+		rantRequireLivestream,
+		rantRequireUsername,
+		rantRequireAmountCents,
+		rantRequireTime,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid rant", err)
+	}
+
+	r.utc()
+
+	columns := columnsNoID(rantColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, rantTable, columns, values(columns))
+
+	var id int64
+	row := rs.Database.QueryRow(insertQ, r.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (rs *rantService) DestructiveReset() error {
+	err := rs.dropRantTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", rantTable), err)
+	}
+
+	return nil
+}
+
+func (rs *rantService) dropRantTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, rantTable)
+
+	_, err := rs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+// Near returns the livestream's rants from the user for the amount sent between from and to.
+func (rs *rantService) Near(livestream string, username string, amountCents int64, from time.Time, to time.Time) ([]Rant, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE livestream=? AND username=? COLLATE NOCASE AND amount_cents=? AND time>=? AND time<=?
+		ORDER BY time, id
+	`, rantColumns, rantTable)
+
+	return rs.query(selectQ, livestream, username, amountCents, from.UTC(), to.UTC())
+}
+
+func (rs *rantService) Update(r *Rant) error {
+	err := runRantValFuncs(
+		r,
+		rantRequireID,
+		rantRequireLivestream,
+		rantRequireUsername,
+		rantRequireAmountCents,
+		rantRequireTime,
+	)
+	if err != nil {
+		return pkgErr("invalid rant", err)
+	}
+
+	r.utc()
+
+	columns := columnsNoID(rantColumns)
+	updateQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET %s
+		WHERE id=?
+	`, rantTable, set(columns))
+
+	_, err = rs.Database.Exec(updateQ, r.valuesEndID()...)
+	if err != nil {
+		return pkgErr("error executing update query", err)
+	}
+
+	return nil
+}
+
+type rantValFunc func(*Rant) error
+
+func runRantValFuncs(r *Rant, fns ...rantValFunc) error {
+	if r == nil {
+		return fmt.Errorf("rant is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func rantRequireID(r *Rant) error {
+	if r.ID == nil || *r.ID < 1 {
+		return ErrRantInvalidID
+	}
+
+	return nil
+}
+
+func rantRequireLivestream(r *Rant) error {
+	if r.Livestream == nil || *r.Livestream == "" {
+		return ErrRantInvalidLivestream
+	}
+
+	return nil
+}
+
+func rantRequireUsername(r *Rant) error {
+	if r.Username == nil || *r.Username == "" {
+		return ErrRantInvalidUsername
+	}
+
+	return nil
+}
+
+func rantRequireAmountCents(r *Rant) error {
+	if r.AmountCents == nil || *r.AmountCents < 1 {
+		return ErrRantInvalidAmountCents
+	}
+
+	return nil
+}
+
+func rantRequireTime(r *Rant) error {
+	if r.Time == nil || r.Time.IsZero() {
+		return ErrRantInvalidTime
+	}
+
+	return nil
+}
@@ -14,13 +14,26 @@ type table struct {
 }
 
 type Services struct {
-	AccountS        AccountService
-	AccountChannelS AccountChannelService
-	ChannelS        ChannelService
-	ChatbotS        ChatbotService
-	ChatbotRuleS    ChatbotRuleService
-	Database        *sql.DB
-	tables          []table
+	AccountS           AccountService
+	AccountChannelS    AccountChannelService
+	ChannelS           ChannelService
+	ChatMessageS       ChatMessageService
+	ChatbotS           ChatbotService
+	ChatbotRuleS       ChatbotRuleService
+	ChatbotSupervisorS ChatbotSupervisorService
+	FollowerS          FollowerService
+	FollowerMarkS      FollowerMarkService
+	GoalS              GoalService
+	RantS              RantService
+	SessionReportS     SessionReportService
+	StreamChangeS      StreamChangeService
+	StreamStatS        StreamStatService
+	SubscriberS        SubscriberService
+	ViewerS            ViewerService
+	WebhookS           WebhookService
+	WebhookDeliveryS   WebhookDeliveryService
+	Database           *sql.DB
+	tables             []table
 }
 
 func (s *Services) AutoMigrate() error {
@@ -109,6 +122,15 @@ func WithChannelService() ServicesInit {
 	}
 }
 
+func WithChatMessageService() ServicesInit {
+	return func(s *Services) error {
+		s.ChatMessageS = NewChatMessageService(s.Database)
+		s.tables = append(s.tables, table{chatMessageTable, s.ChatMessageS.AutoMigrate, s.ChatMessageS.DestructiveReset})
+
+		return nil
+	}
+}
+
 func WithChatbotService() ServicesInit {
 	return func(s *Services) error {
 		s.ChatbotS = NewChatbotService(s.Database)
@@ -126,3 +148,111 @@ func WithChatbotRuleService() ServicesInit {
 		return nil
 	}
 }
+
+func WithChatbotSupervisorService() ServicesInit {
+	return func(s *Services) error {
+		s.ChatbotSupervisorS = NewChatbotSupervisorService(s.Database)
+		s.tables = append(s.tables, table{chatbotSupervisorTable, s.ChatbotSupervisorS.AutoMigrate, s.ChatbotSupervisorS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithFollowerService() ServicesInit {
+	return func(s *Services) error {
+		s.FollowerS = NewFollowerService(s.Database)
+		s.tables = append(s.tables, table{followerTable, s.FollowerS.AutoMigrate, s.FollowerS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithFollowerMarkService() ServicesInit {
+	return func(s *Services) error {
+		s.FollowerMarkS = NewFollowerMarkService(s.Database)
+		s.tables = append(s.tables, table{followerMarkTable, s.FollowerMarkS.AutoMigrate, s.FollowerMarkS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithGoalService() ServicesInit {
+	return func(s *Services) error {
+		s.GoalS = NewGoalService(s.Database)
+		s.tables = append(s.tables, table{goalTable, s.GoalS.AutoMigrate, s.GoalS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithRantService() ServicesInit {
+	return func(s *Services) error {
+		s.RantS = NewRantService(s.Database)
+		s.tables = append(s.tables, table{rantTable, s.RantS.AutoMigrate, s.RantS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithSessionReportService() ServicesInit {
+	return func(s *Services) error {
+		s.SessionReportS = NewSessionReportService(s.Database)
+		s.tables = append(s.tables, table{sessionReportTable, s.SessionReportS.AutoMigrate, s.SessionReportS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithStreamChangeService() ServicesInit {
+	return func(s *Services) error {
+		s.StreamChangeS = NewStreamChangeService(s.Database)
+		s.tables = append(s.tables, table{streamChangeTable, s.StreamChangeS.AutoMigrate, s.StreamChangeS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithStreamStatService() ServicesInit {
+	return func(s *Services) error {
+		s.StreamStatS = NewStreamStatService(s.Database)
+		s.tables = append(s.tables, table{streamStatTable, s.StreamStatS.AutoMigrate, s.StreamStatS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithSubscriberService() ServicesInit {
+	return func(s *Services) error {
+		s.SubscriberS = NewSubscriberService(s.Database)
+		s.tables = append(s.tables, table{subscriberTable, s.SubscriberS.AutoMigrate, s.SubscriberS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithViewerService() ServicesInit {
+	return func(s *Services) error {
+		s.ViewerS = NewViewerService(s.Database)
+		s.tables = append(s.tables, table{viewerTable, s.ViewerS.AutoMigrate, s.ViewerS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithWebhookService() ServicesInit {
+	return func(s *Services) error {
+		s.WebhookS = NewWebhookService(s.Database)
+		s.tables = append(s.tables, table{webhookTable, s.WebhookS.AutoMigrate, s.WebhookS.DestructiveReset})
+
+		return nil
+	}
+}
+
+func WithWebhookDeliveryService() ServicesInit {
+	return func(s *Services) error {
+		s.WebhookDeliveryS = NewWebhookDeliveryService(s.Database)
+		s.tables = append(s.tables, table{webhookDeliveryTable, s.WebhookDeliveryS.AutoMigrate, s.WebhookDeliveryS.DestructiveReset})
+
+		return nil
+	}
+}
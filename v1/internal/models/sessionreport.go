@@ -0,0 +1,316 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	sessionReportColumns = "id, livestream, page, title, start, end, report"
+	sessionReportTable   = "session_report"
+)
+
+// SessionReport is the report generated when a livestream ends.
+// Report holds the JSON-encoded report.
+type SessionReport struct {
+	ID         *int64     `json:"id"`
+	Livestream *string    `json:"livestream"`
+	Page       *string    `json:"page"`
+	Title      *string    `json:"title"`
+	Start      *time.Time `json:"start"`
+	End        *time.Time `json:"end"`
+	Report     *string    `json:"report"`
+}
+
+func (s *SessionReport) values() []any {
+	return []any{s.ID, s.Livestream, s.Page, s.Title, s.Start, s.End, s.Report}
+}
+
+func (s *SessionReport) valuesNoID() []any {
+	return s.values()[1:]
+}
+
+type sqlSessionReport struct {
+	id         sql.NullInt64
+	livestream sql.NullString
+	page       sql.NullString
+	title      sql.NullString
+	start      sql.NullTime
+	end        sql.NullTime
+	report     sql.NullString
+}
+
+func (ss *sqlSessionReport) scan(r Row) error {
+	return r.Scan(&ss.id, &ss.livestream, &ss.page, &ss.title, &ss.start, &ss.end, &ss.report)
+}
+
+func (ss sqlSessionReport) toSessionReport() *SessionReport {
+	var s SessionReport
+	s.ID = toInt64(ss.id)
+	s.Livestream = toString(ss.livestream)
+	s.Page = toString(ss.page)
+	s.Title = toString(ss.title)
+	s.Start = toTime(ss.start)
+	s.End = toTime(ss.end)
+	s.Report = toString(ss.report)
+
+	return &s
+}
+
+type SessionReportService interface {
+	All() ([]SessionReport, error)
+	AutoMigrate() error
+	ByID(id int64) (*SessionReport, error)
+	ByLivestream(livestream string) (*SessionReport, error)
+	Create(s *SessionReport) (int64, error)
+	Delete(s *SessionReport) error
+	DestructiveReset() error
+}
+
+func NewSessionReportService(db *sql.DB) SessionReportService {
+	return &sessionReportService{
+		Database: db,
+	}
+}
+
+var _ SessionReportService = &sessionReportService{}
+
+type sessionReportService struct {
+	Database *sql.DB
+}
+
+// All returns every session report without its report body, most recent first.
+func (ss *sessionReportService) All() ([]SessionReport, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT id, livestream, page, title, start, end, NULL
+		FROM "%s"
+		ORDER BY end DESC
+	`, sessionReportTable)
+
+	rows, err := ss.Database.Query(selectQ)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	reports := []SessionReport{}
+	for rows.Next() {
+		sr := &sqlSessionReport{}
+
+		err = sr.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		reports = append(reports, *sr.toSessionReport())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return reports, nil
+}
+
+func (ss *sessionReportService) AutoMigrate() error {
+	err := ss.createSessionReportTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", sessionReportTable), err)
+	}
+
+	return nil
+}
+
+func (ss *sessionReportService) createSessionReportTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			livestream TEXT NOT NULL,
+			page TEXT,
+			title TEXT,
+			start DATETIME,
+			end DATETIME NOT NULL,
+			report TEXT NOT NULL
+		)
+	`, sessionReportTable)
+
+	_, err := ss.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+func (ss *sessionReportService) ByID(id int64) (*SessionReport, error) {
+	err := runSessionReportValFuncs(
+		&SessionReport{ID: &id},
+		sessionReportRequireID,
+	)
+	if err != nil {
+		return nil, pkgErr("", err)
+	}
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE id=?
+	`, sessionReportColumns, sessionReportTable)
+
+	var sr sqlSessionReport
+	row := ss.Database.QueryRow(selectQ, id)
+	err = sr.scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, pkgErr("error executing select query", err)
+	}
+
+	return sr.toSessionReport(), nil
+}
+
+// ByLivestream returns the livestream's most recent report, or nil if it has none.
+func (ss *sessionReportService) ByLivestream(livestream string) (*SessionReport, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE livestream=?
+		ORDER BY id DESC
+		LIMIT 1
+	`, sessionReportColumns, sessionReportTable)
+
+	var sr sqlSessionReport
+	row := ss.Database.QueryRow(selectQ, livestream)
+	err := sr.scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, pkgErr("error executing select query", err)
+	}
+
+	return sr.toSessionReport(), nil
+}
+
+func (ss *sessionReportService) Create(s *SessionReport) (int64, error) {
+	err := runSessionReportValFuncs(
+		s,
+		sessionReportRequireLivestream,
+		sessionReportRequireEnd,
+		sessionReportRequireReport,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid session report", err)
+	}
+
+	columns := columnsNoID(sessionReportColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, sessionReportTable, columns, values(columns))
+
+	var id int64
+	row := ss.Database.QueryRow(insertQ, s.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (ss *sessionReportService) Delete(s *SessionReport) error {
+	err := runSessionReportValFuncs(
+		s,
+		sessionReportRequireID,
+	)
+	if err != nil {
+		return pkgErr("invalid session report", err)
+	}
+
+	deleteQ := fmt.Sprintf(`
+		DELETE FROM "%s"
+		WHERE id=?
+	`, sessionReportTable)
+
+	_, err = ss.Database.Exec(deleteQ, s.ID)
+	if err != nil {
+		return pkgErr("error executing delete query", err)
+	}
+
+	return nil
+}
+
+func (ss *sessionReportService) DestructiveReset() error {
+	err := ss.dropSessionReportTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", sessionReportTable), err)
+	}
+
+	return nil
+}
+
+func (ss *sessionReportService) dropSessionReportTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, sessionReportTable)
+
+	_, err := ss.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+type sessionReportValFunc func(*SessionReport) error
+
+func runSessionReportValFuncs(s *SessionReport, fns ...sessionReportValFunc) error {
+	if s == nil {
+		return fmt.Errorf("session report is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sessionReportRequireID(s *SessionReport) error {
+	if s.ID == nil || *s.ID < 1 {
+		return ErrSessionReportInvalidID
+	}
+
+	return nil
+}
+
+func sessionReportRequireLivestream(s *SessionReport) error {
+	if s.Livestream == nil || *s.Livestream == "" {
+		return ErrSessionReportInvalidLivestream
+	}
+
+	return nil
+}
+
+func sessionReportRequireEnd(s *SessionReport) error {
+	if s.End == nil || s.End.IsZero() {
+		return ErrSessionReportInvalidEnd
+	}
+
+	return nil
+}
+
+func sessionReportRequireReport(s *SessionReport) error {
+	if s.Report == nil || *s.Report == "" {
+		return ErrSessionReportInvalidReport
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 )
 
 type Row interface {
@@ -11,6 +12,47 @@ type Row interface {
 	Scan(dest ...any) error
 }
 
+// addColumnNotExist adds a column to a table created before the column was introduced.
+func addColumnNotExist(db *sql.DB, table string, column string, definition string) error {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info("%s")`, table))
+	if err != nil {
+		return fmt.Errorf("error querying table info: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid int
+		var name string
+		var ctype string
+		var notNull bool
+		var dflt sql.NullString
+		var pk int
+		err = rows.Scan(&cid, &name, &ctype, &notNull, &dflt, &pk)
+		if err != nil {
+			return fmt.Errorf("error scanning table info: %v", err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("error iterating over table info: %v", err)
+	}
+
+	alterQ := fmt.Sprintf(`
+		ALTER TABLE "%s"
+		ADD COLUMN %s %s
+	`, table, column, definition)
+
+	_, err = db.Exec(alterQ)
+	if err != nil {
+		return fmt.Errorf("error executing alter query: %v", err)
+	}
+
+	return nil
+}
+
 func columnsNoID(columns string) string {
 	if len(columns) == 1 {
 		return ""
@@ -44,6 +86,14 @@ func set(columnsS string) string {
 	return strings.Join(vals, ", ")
 }
 
+func toBool(b sql.NullBool) *bool {
+	if b.Valid {
+		return &b.Bool
+	} else {
+		return nil
+	}
+}
+
 func toInt64(i sql.NullInt64) *int64 {
 	if i.Valid {
 		return &i.Int64
@@ -52,6 +102,14 @@ func toInt64(i sql.NullInt64) *int64 {
 	}
 }
 
+func toTime(t sql.NullTime) *time.Time {
+	if t.Valid {
+		return &t.Time
+	} else {
+		return nil
+	}
+}
+
 func toString(i sql.NullString) *string {
 	if i.Valid {
 		return &i.String
@@ -0,0 +1,283 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	streamChangeColumns = "id, livestream, page, kind, previous, value, time"
+	streamChangeTable   = "stream_change"
+)
+
+const (
+	StreamChangeKindCategory = "category"
+	StreamChangeKindTitle    = "title"
+)
+
+// StreamChange is a livestream's title or categories changing.
+// Categories are stored as they are displayed, like "Gaming, Minecraft".
+type StreamChange struct {
+	ID         *int64     `json:"id"`
+	Livestream *string    `json:"livestream"`
+	Page       *string    `json:"page"`
+	Kind       *string    `json:"kind"`
+	Previous   *string    `json:"previous"`
+	Value      *string    `json:"value"`
+	Time       *time.Time `json:"time"`
+}
+
+func (sc *StreamChange) values() []any {
+	return []any{sc.ID, sc.Livestream, sc.Page, sc.Kind, sc.Previous, sc.Value, sc.Time}
+}
+
+func (sc *StreamChange) valuesNoID() []any {
+	return sc.values()[1:]
+}
+
+func (sc *StreamChange) utc() {
+	if sc.Time != nil {
+		utc := sc.Time.UTC()
+		sc.Time = &utc
+	}
+}
+
+type sqlStreamChange struct {
+	id         sql.NullInt64
+	livestream sql.NullString
+	page       sql.NullString
+	kind       sql.NullString
+	previous   sql.NullString
+	value      sql.NullString
+	time       sql.NullTime
+}
+
+func (ssc *sqlStreamChange) scan(r Row) error {
+	return r.Scan(&ssc.id, &ssc.livestream, &ssc.page, &ssc.kind, &ssc.previous, &ssc.value, &ssc.time)
+}
+
+func (ssc sqlStreamChange) toStreamChange() *StreamChange {
+	var sc StreamChange
+	sc.ID = toInt64(ssc.id)
+	sc.Livestream = toString(ssc.livestream)
+	sc.Page = toString(ssc.page)
+	sc.Kind = toString(ssc.kind)
+	sc.Previous = toString(ssc.previous)
+	sc.Value = toString(ssc.value)
+	sc.Time = toTime(ssc.time)
+
+	return &sc
+}
+
+type StreamChangeService interface {
+	AutoMigrate() error
+	ByLivestream(livestream string) ([]StreamChange, error)
+	Create(sc *StreamChange) (int64, error)
+	DestructiveReset() error
+}
+
+func NewStreamChangeService(db *sql.DB) StreamChangeService {
+	return &streamChangeService{
+		Database: db,
+	}
+}
+
+var _ StreamChangeService = &streamChangeService{}
+
+type streamChangeService struct {
+	Database *sql.DB
+}
+
+func (scs *streamChangeService) AutoMigrate() error {
+	err := scs.createStreamChangeTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", streamChangeTable), err)
+	}
+
+	return nil
+}
+
+func (scs *streamChangeService) createStreamChangeTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			livestream TEXT NOT NULL,
+			page TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			previous TEXT NOT NULL,
+			value TEXT NOT NULL,
+			time DATETIME NOT NULL
+		)
+	`, streamChangeTable)
+
+	_, err := scs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	indexQ := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS "%s_livestream" ON "%s" (livestream)
+	`, streamChangeTable, streamChangeTable)
+
+	_, err = scs.Database.Exec(indexQ)
+	if err != nil {
+		return fmt.Errorf("error executing create index query: %v", err)
+	}
+
+	return nil
+}
+
+// ByLivestream returns the livestream's title and category changes, oldest first.
+func (scs *streamChangeService) ByLivestream(livestream string) ([]StreamChange, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE livestream=?
+		ORDER BY time, id
+	`, streamChangeColumns, streamChangeTable)
+
+	rows, err := scs.Database.Query(selectQ, livestream)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	changes := []StreamChange{}
+	for rows.Next() {
+		ssc := &sqlStreamChange{}
+
+		err = ssc.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		changes = append(changes, *ssc.toStreamChange())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return changes, nil
+}
+
+func (scs *streamChangeService) Create(sc *StreamChange) (int64, error) {
+	err := runStreamChangeValFuncs(
+		sc,
+		streamChangeRequireLivestream,
+		streamChangeRequirePage,
+		streamChangeRequireKind,
+		streamChangeRequirePrevious,
+		streamChangeRequireValue,
+		streamChangeRequireTime,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid stream change", err)
+	}
+
+	sc.utc()
+
+	columns := columnsNoID(streamChangeColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, streamChangeTable, columns, values(columns))
+
+	var id int64
+	row := scs.Database.QueryRow(insertQ, sc.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (scs *streamChangeService) DestructiveReset() error {
+	err := scs.dropStreamChangeTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", streamChangeTable), err)
+	}
+
+	return nil
+}
+
+func (scs *streamChangeService) dropStreamChangeTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, streamChangeTable)
+
+	_, err := scs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+type streamChangeValFunc func(*StreamChange) error
+
+func runStreamChangeValFuncs(sc *StreamChange, fns ...streamChangeValFunc) error {
+	if sc == nil {
+		return fmt.Errorf("stream change is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(sc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func streamChangeRequireKind(sc *StreamChange) error {
+	if sc.Kind == nil || (*sc.Kind != StreamChangeKindCategory && *sc.Kind != StreamChangeKindTitle) {
+		return ErrStreamChangeInvalidKind
+	}
+
+	return nil
+}
+
+func streamChangeRequireLivestream(sc *StreamChange) error {
+	if sc.Livestream == nil || *sc.Livestream == "" {
+		return ErrStreamChangeInvalidLivestream
+	}
+
+	return nil
+}
+
+func streamChangeRequirePage(sc *StreamChange) error {
+	if sc.Page == nil || *sc.Page == "" {
+		return ErrStreamChangeInvalidPage
+	}
+
+	return nil
+}
+
+func streamChangeRequirePrevious(sc *StreamChange) error {
+	if sc.Previous == nil {
+		return ErrStreamChangeInvalidPrevious
+	}
+
+	return nil
+}
+
+func streamChangeRequireTime(sc *StreamChange) error {
+	if sc.Time == nil || sc.Time.IsZero() {
+		return ErrStreamChangeInvalidTime
+	}
+
+	return nil
+}
+
+func streamChangeRequireValue(sc *StreamChange) error {
+	if sc.Value == nil {
+		return ErrStreamChangeInvalidValue
+	}
+
+	return nil
+}
@@ -0,0 +1,515 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	streamStatColumns = "id, livestream, time, samples, watching_now, watching_now_max, likes, dislikes, followers, subscribers"
+	streamStatTable   = "stream_stat"
+)
+
+// StreamStat is a snapshot of a livestream's statistics from the API.
+// Downsampled snapshots cover several samples: WatchingNow is their average and WatchingNowMax their peak,
+// the other counts are the latest values.
+type StreamStat struct {
+	ID             *int64     `json:"id"`
+	Livestream     *string    `json:"livestream"`
+	Time           *time.Time `json:"time"`
+	Samples        *int64     `json:"samples"`
+	WatchingNow    *int64     `json:"watching_now"`
+	WatchingNowMax *int64     `json:"watching_now_max"`
+	Likes          *int64     `json:"likes"`
+	Dislikes       *int64     `json:"dislikes"`
+	Followers      *int64     `json:"followers"`
+	Subscribers    *int64     `json:"subscribers"`
+}
+
+func (s *StreamStat) values() []any {
+	return []any{s.ID, s.Livestream, s.Time, s.Samples, s.WatchingNow, s.WatchingNowMax, s.Likes, s.Dislikes, s.Followers, s.Subscribers}
+}
+
+func (s *StreamStat) valuesNoID() []any {
+	return s.values()[1:]
+}
+
+type sqlStreamStat struct {
+	id             sql.NullInt64
+	livestream     sql.NullString
+	time           sql.NullTime
+	samples        sql.NullInt64
+	watchingNow    sql.NullInt64
+	watchingNowMax sql.NullInt64
+	likes          sql.NullInt64
+	dislikes       sql.NullInt64
+	followers      sql.NullInt64
+	subscribers    sql.NullInt64
+}
+
+func (ss *sqlStreamStat) scan(r Row) error {
+	return r.Scan(&ss.id, &ss.livestream, &ss.time, &ss.samples, &ss.watchingNow, &ss.watchingNowMax, &ss.likes, &ss.dislikes, &ss.followers, &ss.subscribers)
+}
+
+func (ss sqlStreamStat) toStreamStat() *StreamStat {
+	var s StreamStat
+	s.ID = toInt64(ss.id)
+	s.Livestream = toString(ss.livestream)
+	s.Time = toTime(ss.time)
+	s.Samples = toInt64(ss.samples)
+	s.WatchingNow = toInt64(ss.watchingNow)
+	s.WatchingNowMax = toInt64(ss.watchingNowMax)
+	s.Likes = toInt64(ss.likes)
+	s.Dislikes = toInt64(ss.dislikes)
+	s.Followers = toInt64(ss.followers)
+	s.Subscribers = toInt64(ss.subscribers)
+
+	return &s
+}
+
+// StreamStatSummary summarizes a livestream's statistics.
+// LikeRatio is the share of likes in all likes and dislikes, between 0 and 1.
+type StreamStatSummary struct {
+	Livestream       string    `json:"livestream"`
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	Samples          int64     `json:"samples"`
+	PeakViewers      int64     `json:"peak_viewers"`
+	AverageViewers   float64   `json:"average_viewers"`
+	Likes            int64     `json:"likes"`
+	Dislikes         int64     `json:"dislikes"`
+	LikeRatio        float64   `json:"like_ratio"`
+	FollowersStart   int64     `json:"followers_start"`
+	FollowersEnd     int64     `json:"followers_end"`
+	FollowerGrowth   int64     `json:"follower_growth"`
+	SubscribersStart int64     `json:"subscribers_start"`
+	SubscribersEnd   int64     `json:"subscribers_end"`
+	SubscriberGrowth int64     `json:"subscriber_growth"`
+}
+
+type StreamStatService interface {
+	AutoMigrate() error
+	ByLivestream(livestream string) ([]StreamStat, error)
+	Create(s *StreamStat) (int64, error)
+	DestructiveReset() error
+	Downsample(before time.Time, interval time.Duration) error
+	Livestreams() ([]string, error)
+	Summary(livestream string) (*StreamStatSummary, error)
+}
+
+func NewStreamStatService(db *sql.DB) StreamStatService {
+	return &streamStatService{
+		Database: db,
+	}
+}
+
+var _ StreamStatService = &streamStatService{}
+
+type streamStatService struct {
+	Database *sql.DB
+}
+
+func (ss *streamStatService) AutoMigrate() error {
+	err := ss.createStreamStatTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", streamStatTable), err)
+	}
+
+	return nil
+}
+
+func (ss *streamStatService) createStreamStatTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			livestream TEXT NOT NULL,
+			time DATETIME NOT NULL,
+			samples INTEGER NOT NULL DEFAULT 1,
+			watching_now INTEGER NOT NULL DEFAULT 0,
+			watching_now_max INTEGER NOT NULL DEFAULT 0,
+			likes INTEGER NOT NULL DEFAULT 0,
+			dislikes INTEGER NOT NULL DEFAULT 0,
+			followers INTEGER NOT NULL DEFAULT 0,
+			subscribers INTEGER NOT NULL DEFAULT 0
+		)
+	`, streamStatTable)
+
+	_, err := ss.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	indexQ := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS "%s_livestream_time" ON "%s" (livestream, time)
+	`, streamStatTable, streamStatTable)
+
+	_, err = ss.Database.Exec(indexQ)
+	if err != nil {
+		return fmt.Errorf("error executing create index query: %v", err)
+	}
+
+	return nil
+}
+
+// ByLivestream returns the livestream's snapshots, oldest first.
+func (ss *streamStatService) ByLivestream(livestream string) ([]StreamStat, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE livestream=?
+		ORDER BY time, id
+	`, streamStatColumns, streamStatTable)
+
+	return ss.query(selectQ, livestream)
+}
+
+func (ss *streamStatService) query(selectQ string, args ...any) ([]StreamStat, error) {
+	rows, err := ss.Database.Query(selectQ, args...)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	stats := []StreamStat{}
+	for rows.Next() {
+		sst := &sqlStreamStat{}
+
+		err = sst.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		stats = append(stats, *sst.toStreamStat())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return stats, nil
+}
+
+func (ss *streamStatService) Create(s *StreamStat) (int64, error) {
+	err := runStreamStatValFuncs(
+		s,
+		streamStatRequireLivestream,
+		streamStatRequireTime,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid stream stat", err)
+	}
+
+	t := s.Time.UTC()
+	s.Time = &t
+	if s.Samples == nil {
+		samples := int64(1)
+		s.Samples = &samples
+	}
+	if s.WatchingNowMax == nil {
+		s.WatchingNowMax = s.WatchingNow
+	}
+
+	columns := columnsNoID(streamStatColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, streamStatTable, columns, values(columns))
+
+	var id int64
+	row := ss.Database.QueryRow(insertQ, s.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (ss *streamStatService) DestructiveReset() error {
+	err := ss.dropStreamStatTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", streamStatTable), err)
+	}
+
+	return nil
+}
+
+func (ss *streamStatService) dropStreamStatTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, streamStatTable)
+
+	_, err := ss.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+// Downsample merges the snapshots taken before the given time into one snapshot per interval.
+// Only intervals that hold more than one snapshot are read, so snapshots that were already merged are left as they are.
+// A livestream's first snapshot is never merged, so that its start and starting counts are kept.
+// Intervals are counted from the Unix epoch in whole seconds.
+func (ss *streamStatService) Downsample(before time.Time, interval time.Duration) error {
+	if interval < time.Second {
+		return pkgErr("", fmt.Errorf("invalid downsample interval: %s", interval))
+	}
+	seconds := int64(interval / time.Second)
+
+	selectQ := fmt.Sprintf(`
+		WITH candidate AS (
+			SELECT *, CAST(strftime('%%s', s.time) AS INTEGER) / ? AS bucket
+			FROM "%s" AS s
+			WHERE s.time<? AND EXISTS (
+				SELECT 1
+				FROM "%s" AS f
+				WHERE f.livestream=s.livestream AND (f.time<s.time OR (f.time=s.time AND f.id<s.id))
+			)
+		)
+		SELECT %s
+		FROM candidate
+		WHERE (livestream, bucket) IN (
+			SELECT livestream, bucket
+			FROM candidate
+			GROUP BY livestream, bucket
+			HAVING COUNT(*)>1
+		)
+		ORDER BY livestream, time, id
+	`, streamStatTable, streamStatTable, streamStatColumns)
+
+	stats, err := ss.query(selectQ, seconds, before.UTC())
+	if err != nil {
+		return err
+	}
+
+	type bucketKey struct {
+		livestream string
+		bucket     int64
+	}
+	buckets := map[bucketKey][]StreamStat{}
+	keys := []bucketKey{}
+	for _, s := range stats {
+		if s.Livestream == nil || s.Time == nil {
+			continue
+		}
+
+		key := bucketKey{*s.Livestream, s.Time.Unix() / seconds}
+		if _, exists := buckets[key]; !exists {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], s)
+	}
+
+	tx, err := ss.Database.Begin()
+	if err != nil {
+		return pkgErr("error beginning transaction", err)
+	}
+	defer tx.Rollback()
+
+	deleteQ := fmt.Sprintf(`
+		DELETE FROM "%s"
+		WHERE id=?
+	`, streamStatTable)
+
+	deleteStmt, err := tx.Prepare(deleteQ)
+	if err != nil {
+		return pkgErr("error preparing delete query", err)
+	}
+	defer deleteStmt.Close()
+
+	columns := columnsNoID(streamStatColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+	`, streamStatTable, columns, values(columns))
+
+	insertStmt, err := tx.Prepare(insertQ)
+	if err != nil {
+		return pkgErr("error preparing insert query", err)
+	}
+	defer insertStmt.Close()
+
+	for _, key := range keys {
+		bucket := buckets[key]
+		if len(bucket) < 2 {
+			continue
+		}
+
+		merged := mergeStreamStats(key.livestream, bucket)
+		_, err = insertStmt.Exec(merged.valuesNoID()...)
+		if err != nil {
+			return pkgErr("error executing insert query", err)
+		}
+
+		for _, s := range bucket {
+			_, err = deleteStmt.Exec(s.ID)
+			if err != nil {
+				return pkgErr("error executing delete query", err)
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return pkgErr("error committing transaction", err)
+	}
+
+	return nil
+}
+
+// mergeStreamStats combines the snapshots, which must be ordered oldest first, into a single snapshot
+// taken at the time of the last one.
+func mergeStreamStats(livestream string, stats []StreamStat) StreamStat {
+	var samples, watchingSum, watchingMax int64
+	for _, s := range stats {
+		n := int64(1)
+		if s.Samples != nil && *s.Samples > 0 {
+			n = *s.Samples
+		}
+		samples += n
+
+		if s.WatchingNow != nil {
+			watchingSum += *s.WatchingNow * n
+		}
+		if s.WatchingNowMax != nil && *s.WatchingNowMax > watchingMax {
+			watchingMax = *s.WatchingNowMax
+		}
+	}
+	watchingNow := watchingSum / samples
+
+	last := stats[len(stats)-1]
+	return StreamStat{
+		Livestream:     &livestream,
+		Time:           last.Time,
+		Samples:        &samples,
+		WatchingNow:    &watchingNow,
+		WatchingNowMax: &watchingMax,
+		Likes:          last.Likes,
+		Dislikes:       last.Dislikes,
+		Followers:      last.Followers,
+		Subscribers:    last.Subscribers,
+	}
+}
+
+// Livestreams returns every livestream with statistics, most recent first.
+func (ss *streamStatService) Livestreams() ([]string, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT livestream
+		FROM "%s"
+		GROUP BY livestream
+		ORDER BY MAX(time) DESC
+	`, streamStatTable)
+
+	rows, err := ss.Database.Query(selectQ)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	livestreams := []string{}
+	for rows.Next() {
+		var livestream string
+		err = rows.Scan(&livestream)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		livestreams = append(livestreams, livestream)
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return livestreams, nil
+}
+
+// Summary returns the livestream's summarized statistics, or nil if it has none.
+func (ss *streamStatService) Summary(livestream string) (*StreamStatSummary, error) {
+	stats, err := ss.ByLivestream(livestream)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, nil
+	}
+
+	first := stats[0]
+	last := stats[len(stats)-1]
+
+	summary := &StreamStatSummary{
+		Livestream:       livestream,
+		Start:            *first.Time,
+		End:              *last.Time,
+		Likes:            int64Value(last.Likes),
+		Dislikes:         int64Value(last.Dislikes),
+		FollowersStart:   int64Value(first.Followers),
+		FollowersEnd:     int64Value(last.Followers),
+		SubscribersStart: int64Value(first.Subscribers),
+		SubscribersEnd:   int64Value(last.Subscribers),
+	}
+	var watchingSum int64
+	for _, s := range stats {
+		n := int64(1)
+		if s.Samples != nil && *s.Samples > 0 {
+			n = *s.Samples
+		}
+		summary.Samples += n
+		watchingSum += int64Value(s.WatchingNow) * n
+		if max := int64Value(s.WatchingNowMax); max > summary.PeakViewers {
+			summary.PeakViewers = max
+		}
+	}
+	summary.AverageViewers = float64(watchingSum) / float64(summary.Samples)
+	if votes := summary.Likes + summary.Dislikes; votes > 0 {
+		summary.LikeRatio = float64(summary.Likes) / float64(votes)
+	}
+	summary.FollowerGrowth = summary.FollowersEnd - summary.FollowersStart
+	summary.SubscriberGrowth = summary.SubscribersEnd - summary.SubscribersStart
+
+	return summary, nil
+}
+
+func int64Value(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+
+	return *i
+}
+
+type streamStatValFunc func(*StreamStat) error
+
+func runStreamStatValFuncs(s *StreamStat, fns ...streamStatValFunc) error {
+	if s == nil {
+		return fmt.Errorf("stream stat is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func streamStatRequireLivestream(s *StreamStat) error {
+	if s.Livestream == nil || *s.Livestream == "" {
+		return ErrStreamStatInvalidLivestream
+	}
+
+	return nil
+}
+
+func streamStatRequireTime(s *StreamStat) error {
+	if s.Time == nil || s.Time.IsZero() {
+		return ErrStreamStatInvalidTime
+	}
+
+	return nil
+}
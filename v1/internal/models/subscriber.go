@@ -0,0 +1,323 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	subscriberColumns = "id, page, username, user, amount_cents, total_cents, first_subscribed_on, subscribed_on, subscriptions, last_seen"
+	subscriberTable   = "subscriber"
+)
+
+// Subscriber is a user who subscribed to a page.
+// AmountCents and SubscribedOn are from the most recent subscription,
+// Subscriptions counts every subscription seen and TotalCents is their sum.
+type Subscriber struct {
+	ID                *int64     `json:"id"`
+	Page              *string    `json:"page"`
+	Username          *string    `json:"username"`
+	User              *string    `json:"user"`
+	AmountCents       *int64     `json:"amount_cents"`
+	TotalCents        *int64     `json:"total_cents"`
+	FirstSubscribedOn *time.Time `json:"first_subscribed_on"`
+	SubscribedOn      *time.Time `json:"subscribed_on"`
+	Subscriptions     *int64     `json:"subscriptions"`
+	LastSeen          *time.Time `json:"last_seen"`
+}
+
+type sqlSubscriber struct {
+	id                sql.NullInt64
+	page              sql.NullString
+	username          sql.NullString
+	user              sql.NullString
+	amountCents       sql.NullInt64
+	totalCents        sql.NullInt64
+	firstSubscribedOn sql.NullTime
+	subscribedOn      sql.NullTime
+	subscriptions     sql.NullInt64
+	lastSeen          sql.NullTime
+}
+
+func (ss *sqlSubscriber) scan(r Row) error {
+	return r.Scan(&ss.id, &ss.page, &ss.username, &ss.user, &ss.amountCents, &ss.totalCents, &ss.firstSubscribedOn, &ss.subscribedOn, &ss.subscriptions, &ss.lastSeen)
+}
+
+func (ss sqlSubscriber) toSubscriber() *Subscriber {
+	var s Subscriber
+	s.ID = toInt64(ss.id)
+	s.Page = toString(ss.page)
+	s.Username = toString(ss.username)
+	s.User = toString(ss.user)
+	s.AmountCents = toInt64(ss.amountCents)
+	s.TotalCents = toInt64(ss.totalCents)
+	s.FirstSubscribedOn = toTime(ss.firstSubscribedOn)
+	s.SubscribedOn = toTime(ss.subscribedOn)
+	s.Subscriptions = toInt64(ss.subscriptions)
+	s.LastSeen = toTime(ss.lastSeen)
+
+	return &s
+}
+
+// SubscriberObservation is a subscription listed in an API response.
+type SubscriberObservation struct {
+	Username     string
+	User         string
+	AmountCents  int64
+	SubscribedOn time.Time
+}
+
+type SubscriberStatus string
+
+const (
+	SubscriberStatusNew   SubscriberStatus = "new"
+	SubscriberStatusResub SubscriberStatus = "resub"
+)
+
+// SubscriberChange is a subscription that was not in the history before it was merged.
+type SubscriberChange struct {
+	Subscriber Subscriber       `json:"subscriber"`
+	Status     SubscriberStatus `json:"status"`
+}
+
+type SubscriberService interface {
+	AutoMigrate() error
+	ByPage(page string) ([]Subscriber, error)
+	CountByPage(page string) (int64, error)
+	DestructiveReset() error
+	Merge(page string, observations []SubscriberObservation, seen time.Time) ([]SubscriberChange, error)
+}
+
+func NewSubscriberService(db *sql.DB) SubscriberService {
+	return &subscriberService{
+		Database: db,
+	}
+}
+
+var _ SubscriberService = &subscriberService{}
+
+type subscriberService struct {
+	Database *sql.DB
+}
+
+func (ss *subscriberService) AutoMigrate() error {
+	err := ss.createSubscriberTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", subscriberTable), err)
+	}
+
+	return nil
+}
+
+func (ss *subscriberService) createSubscriberTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			page TEXT NOT NULL,
+			username TEXT NOT NULL COLLATE NOCASE,
+			user TEXT,
+			amount_cents INTEGER NOT NULL DEFAULT 0,
+			total_cents INTEGER NOT NULL DEFAULT 0,
+			first_subscribed_on DATETIME NOT NULL,
+			subscribed_on DATETIME NOT NULL,
+			subscriptions INTEGER NOT NULL DEFAULT 1,
+			last_seen DATETIME NOT NULL,
+			UNIQUE (page, username)
+		)
+	`, subscriberTable)
+
+	_, err := ss.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+// ByPage returns the page's subscribers, most recent subscription first.
+func (ss *subscriberService) ByPage(page string) ([]Subscriber, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=?
+		ORDER BY subscribed_on DESC
+	`, subscriberColumns, subscriberTable)
+
+	rows, err := ss.Database.Query(selectQ, page)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	subscribers := []Subscriber{}
+	for rows.Next() {
+		s := &sqlSubscriber{}
+
+		err = s.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		subscribers = append(subscribers, *s.toSubscriber())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return subscribers, nil
+}
+
+func (ss *subscriberService) CountByPage(page string) (int64, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM "%s"
+		WHERE page=?
+	`, subscriberTable)
+
+	var count int64
+	err := ss.Database.QueryRow(selectQ, page).Scan(&count)
+	if err != nil {
+		return -1, pkgErr("error executing select query", err)
+	}
+
+	return count, nil
+}
+
+func (ss *subscriberService) DestructiveReset() error {
+	err := ss.dropSubscriberTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", subscriberTable), err)
+	}
+
+	return nil
+}
+
+func (ss *subscriberService) dropSubscriberTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, subscriberTable)
+
+	_, err := ss.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+// Merge adds the observed subscriptions to the page's subscriber history.
+// It returns the subscriptions that are new, either from a user never seen subscribing to the page
+// or from a user subscribing again.
+func (ss *subscriberService) Merge(page string, observations []SubscriberObservation, seen time.Time) ([]SubscriberChange, error) {
+	if page == "" {
+		return nil, pkgErr("", ErrSubscriberInvalidPage)
+	}
+	for _, o := range observations {
+		if o.Username == "" {
+			return nil, pkgErr("invalid subscriber observation", ErrSubscriberInvalidUsername)
+		}
+		if o.SubscribedOn.IsZero() {
+			return nil, pkgErr("invalid subscriber observation", ErrSubscriberInvalidSubscribedOn)
+		}
+	}
+
+	tx, err := ss.Database.Begin()
+	if err != nil {
+		return nil, pkgErr("error beginning transaction", err)
+	}
+	defer tx.Rollback()
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE page=? AND username=?
+	`, subscriberColumns, subscriberTable)
+
+	sel, err := tx.Prepare(selectQ)
+	if err != nil {
+		return nil, pkgErr("error preparing select query", err)
+	}
+	defer sel.Close()
+
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (page, username, user, amount_cents, total_cents, first_subscribed_on, subscribed_on, subscriptions, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?)
+		RETURNING %s
+	`, subscriberTable, subscriberColumns)
+
+	insert, err := tx.Prepare(insertQ)
+	if err != nil {
+		return nil, pkgErr("error preparing insert query", err)
+	}
+	defer insert.Close()
+
+	resubQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET user=?, amount_cents=?, total_cents=total_cents+?, subscribed_on=?, subscriptions=subscriptions+1, last_seen=?
+		WHERE id=?
+		RETURNING %s
+	`, subscriberTable, subscriberColumns)
+
+	resub, err := tx.Prepare(resubQ)
+	if err != nil {
+		return nil, pkgErr("error preparing resub query", err)
+	}
+	defer resub.Close()
+
+	seenQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET last_seen=?
+		WHERE id=?
+	`, subscriberTable)
+
+	seenStmt, err := tx.Prepare(seenQ)
+	if err != nil {
+		return nil, pkgErr("error preparing seen query", err)
+	}
+	defer seenStmt.Close()
+
+	seen = seen.UTC()
+	changes := []SubscriberChange{}
+	for _, o := range observations {
+		subscribedOn := o.SubscribedOn.UTC()
+
+		var s sqlSubscriber
+		err = s.scan(sel.QueryRow(page, o.Username))
+		if err == sql.ErrNoRows {
+			var inserted sqlSubscriber
+			err = inserted.scan(insert.QueryRow(page, o.Username, o.User, o.AmountCents, o.AmountCents, subscribedOn, subscribedOn, seen))
+			if err != nil {
+				return nil, pkgErr("error executing insert query", err)
+			}
+			changes = append(changes, SubscriberChange{*inserted.toSubscriber(), SubscriberStatusNew})
+			continue
+		}
+		if err != nil {
+			return nil, pkgErr("error executing select query", err)
+		}
+
+		if !subscribedOn.After(s.subscribedOn.Time) {
+			_, err = seenStmt.Exec(seen, s.id.Int64)
+			if err != nil {
+				return nil, pkgErr("error executing seen query", err)
+			}
+			continue
+		}
+
+		var updated sqlSubscriber
+		err = updated.scan(resub.QueryRow(o.User, o.AmountCents, o.AmountCents, subscribedOn, seen, s.id.Int64))
+		if err != nil {
+			return nil, pkgErr("error executing resub query", err)
+		}
+		changes = append(changes, SubscriberChange{*updated.toSubscriber(), SubscriberStatusResub})
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, pkgErr("error committing transaction", err)
+	}
+
+	return changes, nil
+}
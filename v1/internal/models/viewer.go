@@ -0,0 +1,424 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	viewerColumns         = "id, username, channel_name, first_seen, last_seen, message_count, streams_attended, rant_cents, follower, subscriber, badges, color"
+	viewerTable           = "viewer"
+	viewerLivestreamTable = "viewer_livestream"
+	viewerDefaultSortBy   = "last_seen"
+)
+
+// viewerSortColumns are the columns viewers can be sorted by.
+var viewerSortColumns = []string{"username", "first_seen", "last_seen", "message_count", "streams_attended", "rant_cents"}
+
+// Viewer is a Rumble user seen in chat.
+// Follower, Subscriber, Badges and Color are as last observed.
+// Badges are stored comma-separated and RantCents is the total of all rants.
+type Viewer struct {
+	ID              *int64     `json:"id"`
+	Username        *string    `json:"username"`
+	ChannelName     *string    `json:"channel_name"`
+	FirstSeen       *time.Time `json:"first_seen"`
+	LastSeen        *time.Time `json:"last_seen"`
+	MessageCount    *int64     `json:"message_count"`
+	StreamsAttended *int64     `json:"streams_attended"`
+	RantCents       *int64     `json:"rant_cents"`
+	Follower        *bool      `json:"follower"`
+	Subscriber      *bool      `json:"subscriber"`
+	Badges          *string    `json:"badges"`
+	Color           *string    `json:"color"`
+}
+
+type sqlViewer struct {
+	id              sql.NullInt64
+	username        sql.NullString
+	channelName     sql.NullString
+	firstSeen       sql.NullTime
+	lastSeen        sql.NullTime
+	messageCount    sql.NullInt64
+	streamsAttended sql.NullInt64
+	rantCents       sql.NullInt64
+	follower        sql.NullBool
+	subscriber      sql.NullBool
+	badges          sql.NullString
+	color           sql.NullString
+}
+
+func (sv *sqlViewer) scan(r Row) error {
+	return r.Scan(&sv.id, &sv.username, &sv.channelName, &sv.firstSeen, &sv.lastSeen, &sv.messageCount, &sv.streamsAttended, &sv.rantCents, &sv.follower, &sv.subscriber, &sv.badges, &sv.color)
+}
+
+func (sv sqlViewer) toViewer() *Viewer {
+	var v Viewer
+	v.ID = toInt64(sv.id)
+	v.Username = toString(sv.username)
+	v.ChannelName = toString(sv.channelName)
+	v.FirstSeen = toTime(sv.firstSeen)
+	v.LastSeen = toTime(sv.lastSeen)
+	v.MessageCount = toInt64(sv.messageCount)
+	v.StreamsAttended = toInt64(sv.streamsAttended)
+	v.RantCents = toInt64(sv.rantCents)
+	v.Follower = toBool(sv.follower)
+	v.Subscriber = toBool(sv.subscriber)
+	v.Badges = toString(sv.badges)
+	v.Color = toString(sv.color)
+
+	return &v
+}
+
+// ViewerObservation is a single chat message's view of its sender.
+type ViewerObservation struct {
+	Username    string
+	ChannelName string
+	Badges      string
+	Color       string
+	Follower    bool
+	Subscriber  bool
+	Livestream  string
+	RantCents   int64
+	Time        time.Time
+}
+
+// ViewerQuery filters and sorts a viewer listing.
+// Search matches part of the username or channel name.
+// Nil or empty fields are ignored.
+type ViewerQuery struct {
+	Search      string `json:"search"`
+	Follower    *bool  `json:"follower"`
+	Subscriber  *bool  `json:"subscriber"`
+	MinMessages int64  `json:"min_messages"`
+	MinRant     int64  `json:"min_rant"`
+	SortBy      string `json:"sort_by"`
+	Descending  bool   `json:"descending"`
+	Offset      int    `json:"offset"`
+	Limit       int    `json:"limit"`
+}
+
+type ViewerService interface {
+	AutoMigrate() error
+	ByUsername(username string) (*Viewer, error)
+	DestructiveReset() error
+	List(q ViewerQuery) ([]Viewer, int64, error)
+	Observe(observations []ViewerObservation) error
+}
+
+func NewViewerService(db *sql.DB) ViewerService {
+	return &viewerService{
+		Database: db,
+	}
+}
+
+var _ ViewerService = &viewerService{}
+
+type viewerService struct {
+	Database *sql.DB
+}
+
+func (vs *viewerService) AutoMigrate() error {
+	err := vs.createViewerTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", viewerTable), err)
+	}
+
+	err = vs.createViewerLivestreamTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", viewerLivestreamTable), err)
+	}
+
+	return nil
+}
+
+func (vs *viewerService) createViewerTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			username TEXT UNIQUE NOT NULL COLLATE NOCASE,
+			channel_name TEXT,
+			first_seen DATETIME NOT NULL,
+			last_seen DATETIME NOT NULL,
+			message_count INTEGER NOT NULL DEFAULT 0,
+			streams_attended INTEGER NOT NULL DEFAULT 0,
+			rant_cents INTEGER NOT NULL DEFAULT 0,
+			follower BOOLEAN NOT NULL DEFAULT FALSE,
+			subscriber BOOLEAN NOT NULL DEFAULT FALSE,
+			badges TEXT,
+			color TEXT
+		)
+	`, viewerTable)
+
+	_, err := vs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+func (vs *viewerService) createViewerLivestreamTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			viewer_id INTEGER NOT NULL,
+			livestream TEXT NOT NULL,
+			PRIMARY KEY (viewer_id, livestream),
+			FOREIGN KEY (viewer_id) REFERENCES "%s" (id)
+		)
+	`, viewerLivestreamTable, viewerTable)
+
+	_, err := vs.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+func (vs *viewerService) ByUsername(username string) (*Viewer, error) {
+	if username == "" {
+		return nil, pkgErr("", ErrViewerInvalidUsername)
+	}
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE username=?
+	`, viewerColumns, viewerTable)
+
+	var sv sqlViewer
+	row := vs.Database.QueryRow(selectQ, username)
+	err := sv.scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, pkgErr("error executing select query", err)
+	}
+
+	return sv.toViewer(), nil
+}
+
+func (vs *viewerService) DestructiveReset() error {
+	err := vs.dropTable(viewerLivestreamTable)
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", viewerLivestreamTable), err)
+	}
+
+	err = vs.dropTable(viewerTable)
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", viewerTable), err)
+	}
+
+	return nil
+}
+
+func (vs *viewerService) dropTable(table string) error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, table)
+
+	_, err := vs.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+// List returns the viewers matching q and the total number of matches.
+func (vs *viewerService) List(q ViewerQuery) ([]Viewer, int64, error) {
+	where := []string{}
+	args := []any{}
+
+	if q.Search != "" {
+		where = append(where, `(username LIKE ? ESCAPE '\' OR channel_name LIKE ? ESCAPE '\')`)
+		pattern := "%" + likeEscaper.Replace(q.Search) + "%"
+		args = append(args, pattern, pattern)
+	}
+	if q.Follower != nil {
+		where = append(where, "follower=?")
+		args = append(args, *q.Follower)
+	}
+	if q.Subscriber != nil {
+		where = append(where, "subscriber=?")
+		args = append(args, *q.Subscriber)
+	}
+	if q.MinMessages > 0 {
+		where = append(where, "message_count>=?")
+		args = append(args, q.MinMessages)
+	}
+	if q.MinRant > 0 {
+		where = append(where, "rant_cents>=?")
+		args = append(args, q.MinRant)
+	}
+
+	whereQ := ""
+	if len(where) > 0 {
+		whereQ = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	sortBy := viewerDefaultSortBy
+	for _, column := range viewerSortColumns {
+		if q.SortBy == column {
+			sortBy = column
+		}
+	}
+	order := "ASC"
+	if q.Descending {
+		order = "DESC"
+	}
+
+	countQ := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM "%s"
+		%s
+	`, viewerTable, whereQ)
+
+	var total int64
+	err := vs.Database.QueryRow(countQ, args...).Scan(&total)
+	if err != nil {
+		return nil, -1, pkgErr("error executing count query", err)
+	}
+
+	limit := q.Limit
+	if limit < 1 {
+		limit = -1
+	}
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		%s
+		ORDER BY %s %s, id
+		LIMIT ? OFFSET ?
+	`, viewerColumns, viewerTable, whereQ, sortBy, order)
+
+	rows, err := vs.Database.Query(selectQ, append(args, limit, q.Offset)...)
+	if err != nil {
+		return nil, -1, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	viewers := []Viewer{}
+	for rows.Next() {
+		sv := &sqlViewer{}
+
+		err = sv.scan(rows)
+		if err != nil {
+			return nil, -1, pkgErr("error scanning row", err)
+		}
+
+		viewers = append(viewers, *sv.toViewer())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, -1, pkgErr("error iterating over rows", err)
+	}
+
+	return viewers, total, nil
+}
+
+// Observe updates the viewers seen in the observations in a single transaction.
+// Each observation counts as one message.
+func (vs *viewerService) Observe(observations []ViewerObservation) error {
+	for _, o := range observations {
+		if o.Username == "" {
+			return pkgErr("invalid viewer observation", ErrViewerInvalidUsername)
+		}
+	}
+
+	tx, err := vs.Database.Begin()
+	if err != nil {
+		return pkgErr("error beginning transaction", err)
+	}
+	defer tx.Rollback()
+
+	upsertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (username, channel_name, first_seen, last_seen, message_count, rant_cents, follower, subscriber, badges, color)
+		VALUES (?, ?, ?, ?, 1, ?, ?, ?, ?, ?)
+		ON CONFLICT (username) DO UPDATE SET
+			channel_name=excluded.channel_name,
+			first_seen=MIN(first_seen, excluded.first_seen),
+			last_seen=MAX(last_seen, excluded.last_seen),
+			message_count=message_count+1,
+			rant_cents=rant_cents+excluded.rant_cents,
+			follower=excluded.follower,
+			subscriber=excluded.subscriber,
+			badges=excluded.badges,
+			color=excluded.color
+		RETURNING id
+	`, viewerTable)
+
+	upsert, err := tx.Prepare(upsertQ)
+	if err != nil {
+		return pkgErr("error preparing upsert query", err)
+	}
+	defer upsert.Close()
+
+	attendQ := fmt.Sprintf(`
+		INSERT INTO "%s" (viewer_id, livestream)
+		VALUES (?, ?)
+		ON CONFLICT (viewer_id, livestream) DO NOTHING
+	`, viewerLivestreamTable)
+
+	attend, err := tx.Prepare(attendQ)
+	if err != nil {
+		return pkgErr("error preparing attend query", err)
+	}
+	defer attend.Close()
+
+	attendedQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET streams_attended=streams_attended+1
+		WHERE id=?
+	`, viewerTable)
+
+	attended, err := tx.Prepare(attendedQ)
+	if err != nil {
+		return pkgErr("error preparing attended query", err)
+	}
+	defer attended.Close()
+
+	for _, o := range observations {
+		t := o.Time.UTC()
+
+		var id int64
+		err = upsert.QueryRow(o.Username, o.ChannelName, t, t, o.RantCents, o.Follower, o.Subscriber, o.Badges, o.Color).Scan(&id)
+		if err != nil {
+			return pkgErr("error executing upsert query", err)
+		}
+
+		if o.Livestream == "" {
+			continue
+		}
+
+		res, err := attend.Exec(id, o.Livestream)
+		if err != nil {
+			return pkgErr("error executing attend query", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return pkgErr("error getting rows affected", err)
+		}
+		if n == 0 {
+			continue
+		}
+
+		_, err = attended.Exec(id)
+		if err != nil {
+			return pkgErr("error executing attended query", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return pkgErr("error committing transaction", err)
+	}
+
+	return nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,367 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	webhookColumns = "id, name, url, secret, events, enabled, created_at"
+	webhookTable   = "webhook"
+)
+
+// Webhook is a url that app events are posted to.
+// Events is the comma-separated list of event kinds the webhook receives,
+// and Secret is the key payloads are signed with.
+type Webhook struct {
+	ID        *int64     `json:"id"`
+	Name      *string    `json:"name"`
+	Url       *string    `json:"url"`
+	Secret    *string    `json:"secret"`
+	Events    *string    `json:"events"`
+	Enabled   *bool      `json:"enabled"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+func (w *Webhook) values() []any {
+	return []any{w.ID, w.Name, w.Url, w.Secret, w.Events, w.Enabled, w.CreatedAt}
+}
+
+func (w *Webhook) valuesNoID() []any {
+	return w.values()[1:]
+}
+
+func (w *Webhook) valuesEndID() []any {
+	vals := w.values()
+	return append(vals[1:], vals[0])
+}
+
+func (w *Webhook) utc() {
+	if w.CreatedAt != nil {
+		utc := w.CreatedAt.UTC()
+		w.CreatedAt = &utc
+	}
+}
+
+type sqlWebhook struct {
+	id        sql.NullInt64
+	name      sql.NullString
+	url       sql.NullString
+	secret    sql.NullString
+	events    sql.NullString
+	enabled   sql.NullBool
+	createdAt sql.NullTime
+}
+
+func (sw *sqlWebhook) scan(r Row) error {
+	return r.Scan(&sw.id, &sw.name, &sw.url, &sw.secret, &sw.events, &sw.enabled, &sw.createdAt)
+}
+
+func (sw sqlWebhook) toWebhook() *Webhook {
+	var w Webhook
+	w.ID = toInt64(sw.id)
+	w.Name = toString(sw.name)
+	w.Url = toString(sw.url)
+	w.Secret = toString(sw.secret)
+	w.Events = toString(sw.events)
+	w.Enabled = toBool(sw.enabled)
+	w.CreatedAt = toTime(sw.createdAt)
+
+	return &w
+}
+
+type WebhookService interface {
+	All() ([]Webhook, error)
+	AutoMigrate() error
+	ByID(id int64) (*Webhook, error)
+	Create(w *Webhook) (int64, error)
+	Delete(w *Webhook) error
+	DestructiveReset() error
+	Update(w *Webhook) error
+}
+
+func NewWebhookService(db *sql.DB) WebhookService {
+	return &webhookService{
+		Database: db,
+	}
+}
+
+var _ WebhookService = &webhookService{}
+
+type webhookService struct {
+	Database *sql.DB
+}
+
+// All returns every webhook in the order they were created.
+func (ws *webhookService) All() ([]Webhook, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		ORDER BY id
+	`, webhookColumns, webhookTable)
+
+	rows, err := ws.Database.Query(selectQ)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		sw := &sqlWebhook{}
+
+		err = sw.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		webhooks = append(webhooks, *sw.toWebhook())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return webhooks, nil
+}
+
+func (ws *webhookService) AutoMigrate() error {
+	err := ws.createWebhookTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", webhookTable), err)
+	}
+
+	return nil
+}
+
+func (ws *webhookService) createWebhookTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL,
+			created_at DATETIME NOT NULL
+		)
+	`, webhookTable)
+
+	_, err := ws.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	return nil
+}
+
+func (ws *webhookService) ByID(id int64) (*Webhook, error) {
+	err := runWebhookValFuncs(
+		&Webhook{ID: &id},
+		webhookRequireID,
+	)
+	if err != nil {
+		return nil, pkgErr("", err)
+	}
+
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE id=?
+	`, webhookColumns, webhookTable)
+
+	var sw sqlWebhook
+	row := ws.Database.QueryRow(selectQ, id)
+	err = sw.scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, pkgErr("error executing select query", err)
+	}
+
+	return sw.toWebhook(), nil
+}
+
+func (ws *webhookService) Create(w *Webhook) (int64, error) {
+	err := runWebhookValFuncs(
+		w,
+		webhookRequireName,
+		webhookRequireUrl,
+		webhookRequireSecret,
+		webhookRequireEvents,
+		webhookRequireEnabled,
+		webhookRequireCreatedAt,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid webhook", err)
+	}
+
+	w.utc()
+
+	columns := columnsNoID(webhookColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, webhookTable, columns, values(columns))
+
+	var id int64
+	row := ws.Database.QueryRow(insertQ, w.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (ws *webhookService) Delete(w *Webhook) error {
+	err := runWebhookValFuncs(
+		w,
+		webhookRequireID,
+	)
+	if err != nil {
+		return pkgErr("invalid webhook", err)
+	}
+
+	deleteQ := fmt.Sprintf(`
+		DELETE FROM "%s"
+		WHERE id=?
+	`, webhookTable)
+
+	_, err = ws.Database.Exec(deleteQ, w.ID)
+	if err != nil {
+		return pkgErr("error executing delete query", err)
+	}
+
+	return nil
+}
+
+func (ws *webhookService) DestructiveReset() error {
+	err := ws.dropWebhookTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", webhookTable), err)
+	}
+
+	return nil
+}
+
+func (ws *webhookService) dropWebhookTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, webhookTable)
+
+	_, err := ws.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+func (ws *webhookService) Update(w *Webhook) error {
+	err := runWebhookValFuncs(
+		w,
+		webhookRequireID,
+		webhookRequireName,
+		webhookRequireUrl,
+		webhookRequireSecret,
+		webhookRequireEvents,
+		webhookRequireEnabled,
+		webhookRequireCreatedAt,
+	)
+	if err != nil {
+		return pkgErr("invalid webhook", err)
+	}
+
+	w.utc()
+
+	columns := columnsNoID(webhookColumns)
+	updateQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET %s
+		WHERE id=?
+	`, webhookTable, set(columns))
+
+	_, err = ws.Database.Exec(updateQ, w.valuesEndID()...)
+	if err != nil {
+		return pkgErr("error executing update query", err)
+	}
+
+	return nil
+}
+
+type webhookValFunc func(*Webhook) error
+
+func runWebhookValFuncs(w *Webhook, fns ...webhookValFunc) error {
+	if w == nil {
+		return fmt.Errorf("webhook is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func webhookRequireCreatedAt(w *Webhook) error {
+	if w.CreatedAt == nil || w.CreatedAt.IsZero() {
+		return ErrWebhookInvalidCreatedAt
+	}
+
+	return nil
+}
+
+func webhookRequireEnabled(w *Webhook) error {
+	if w.Enabled == nil {
+		return ErrWebhookInvalidEnabled
+	}
+
+	return nil
+}
+
+func webhookRequireEvents(w *Webhook) error {
+	if w.Events == nil || *w.Events == "" {
+		return ErrWebhookInvalidEvents
+	}
+
+	return nil
+}
+
+func webhookRequireID(w *Webhook) error {
+	if w.ID == nil || *w.ID < 1 {
+		return ErrWebhookInvalidID
+	}
+
+	return nil
+}
+
+func webhookRequireName(w *Webhook) error {
+	if w.Name == nil || *w.Name == "" {
+		return ErrWebhookInvalidName
+	}
+
+	return nil
+}
+
+func webhookRequireSecret(w *Webhook) error {
+	if w.Secret == nil || *w.Secret == "" {
+		return ErrWebhookInvalidSecret
+	}
+
+	return nil
+}
+
+func webhookRequireUrl(w *Webhook) error {
+	if w.Url == nil || *w.Url == "" {
+		return ErrWebhookInvalidUrl
+	}
+
+	return nil
+}
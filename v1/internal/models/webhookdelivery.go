@@ -0,0 +1,400 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	webhookDeliveryColumns = "id, webhook_id, event, payload, status, attempts, next_attempt, last_attempt, response_code, error, created_at"
+	webhookDeliveryTable   = "webhook_delivery"
+)
+
+const (
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusFailed    = "failed"
+	WebhookDeliveryStatusPending   = "pending"
+)
+
+// WebhookDelivery is an event payload queued for, or sent to, a webhook.
+// Pending deliveries are retried at NextAttempt until they are delivered or fail for good.
+// ResponseCode and Error are from the last attempt.
+type WebhookDelivery struct {
+	ID           *int64     `json:"id"`
+	WebhookID    *int64     `json:"webhook_id"`
+	Event        *string    `json:"event"`
+	Payload      *string    `json:"payload"`
+	Status       *string    `json:"status"`
+	Attempts     *int64     `json:"attempts"`
+	NextAttempt  *time.Time `json:"next_attempt"`
+	LastAttempt  *time.Time `json:"last_attempt"`
+	ResponseCode *int64     `json:"response_code"`
+	Error        *string    `json:"error"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+func (wd *WebhookDelivery) values() []any {
+	return []any{wd.ID, wd.WebhookID, wd.Event, wd.Payload, wd.Status, wd.Attempts, wd.NextAttempt, wd.LastAttempt, wd.ResponseCode, wd.Error, wd.CreatedAt}
+}
+
+func (wd *WebhookDelivery) valuesNoID() []any {
+	return wd.values()[1:]
+}
+
+func (wd *WebhookDelivery) valuesEndID() []any {
+	vals := wd.values()
+	return append(vals[1:], vals[0])
+}
+
+func (wd *WebhookDelivery) utc() {
+	for _, t := range []**time.Time{&wd.NextAttempt, &wd.LastAttempt, &wd.CreatedAt} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+}
+
+type sqlWebhookDelivery struct {
+	id           sql.NullInt64
+	webhookID    sql.NullInt64
+	event        sql.NullString
+	payload      sql.NullString
+	status       sql.NullString
+	attempts     sql.NullInt64
+	nextAttempt  sql.NullTime
+	lastAttempt  sql.NullTime
+	responseCode sql.NullInt64
+	err          sql.NullString
+	createdAt    sql.NullTime
+}
+
+func (swd *sqlWebhookDelivery) scan(r Row) error {
+	return r.Scan(&swd.id, &swd.webhookID, &swd.event, &swd.payload, &swd.status, &swd.attempts, &swd.nextAttempt, &swd.lastAttempt, &swd.responseCode, &swd.err, &swd.createdAt)
+}
+
+func (swd sqlWebhookDelivery) toWebhookDelivery() *WebhookDelivery {
+	var wd WebhookDelivery
+	wd.ID = toInt64(swd.id)
+	wd.WebhookID = toInt64(swd.webhookID)
+	wd.Event = toString(swd.event)
+	wd.Payload = toString(swd.payload)
+	wd.Status = toString(swd.status)
+	wd.Attempts = toInt64(swd.attempts)
+	wd.NextAttempt = toTime(swd.nextAttempt)
+	wd.LastAttempt = toTime(swd.lastAttempt)
+	wd.ResponseCode = toInt64(swd.responseCode)
+	wd.Error = toString(swd.err)
+	wd.CreatedAt = toTime(swd.createdAt)
+
+	return &wd
+}
+
+type WebhookDeliveryService interface {
+	AutoMigrate() error
+	ByWebhook(webhookID int64, limit int) ([]WebhookDelivery, error)
+	Create(wd *WebhookDelivery) (int64, error)
+	DeleteByWebhook(webhookID int64) error
+	DeleteFinishedBefore(t time.Time) error
+	DestructiveReset() error
+	Due(now time.Time, limit int) ([]WebhookDelivery, error)
+	Update(wd *WebhookDelivery) error
+}
+
+func NewWebhookDeliveryService(db *sql.DB) WebhookDeliveryService {
+	return &webhookDeliveryService{
+		Database: db,
+	}
+}
+
+var _ WebhookDeliveryService = &webhookDeliveryService{}
+
+type webhookDeliveryService struct {
+	Database *sql.DB
+}
+
+func (wds *webhookDeliveryService) AutoMigrate() error {
+	err := wds.createWebhookDeliveryTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s table", webhookDeliveryTable), err)
+	}
+
+	return nil
+}
+
+func (wds *webhookDeliveryService) createWebhookDeliveryTable() error {
+	createQ := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS "%s" (
+			id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL,
+			next_attempt DATETIME,
+			last_attempt DATETIME,
+			response_code INTEGER,
+			error TEXT,
+			created_at DATETIME NOT NULL
+		)
+	`, webhookDeliveryTable)
+
+	_, err := wds.Database.Exec(createQ)
+	if err != nil {
+		return fmt.Errorf("error executing create query: %v", err)
+	}
+
+	indexQ := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS "%s_status_next_attempt" ON "%s" (status, next_attempt)
+	`, webhookDeliveryTable, webhookDeliveryTable)
+
+	_, err = wds.Database.Exec(indexQ)
+	if err != nil {
+		return fmt.Errorf("error executing create index query: %v", err)
+	}
+
+	return nil
+}
+
+// ByWebhook returns the webhook's most recent deliveries, most recent first.
+func (wds *webhookDeliveryService) ByWebhook(webhookID int64, limit int) ([]WebhookDelivery, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE webhook_id=?
+		ORDER BY id DESC
+		LIMIT ?
+	`, webhookDeliveryColumns, webhookDeliveryTable)
+
+	return wds.query(selectQ, webhookID, limit)
+}
+
+// Due returns the pending deliveries whose next attempt is at or before now, oldest first.
+func (wds *webhookDeliveryService) Due(now time.Time, limit int) ([]WebhookDelivery, error) {
+	selectQ := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE status=? AND next_attempt<=?
+		ORDER BY next_attempt, id
+		LIMIT ?
+	`, webhookDeliveryColumns, webhookDeliveryTable)
+
+	return wds.query(selectQ, WebhookDeliveryStatusPending, now.UTC(), limit)
+}
+
+func (wds *webhookDeliveryService) query(selectQ string, args ...any) ([]WebhookDelivery, error) {
+	rows, err := wds.Database.Query(selectQ, args...)
+	if err != nil {
+		return nil, pkgErr("error executing select query", err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		swd := &sqlWebhookDelivery{}
+
+		err = swd.scan(rows)
+		if err != nil {
+			return nil, pkgErr("error scanning row", err)
+		}
+
+		deliveries = append(deliveries, *swd.toWebhookDelivery())
+	}
+	err = rows.Err()
+	if err != nil && err != sql.ErrNoRows {
+		return nil, pkgErr("error iterating over rows", err)
+	}
+
+	return deliveries, nil
+}
+
+func (wds *webhookDeliveryService) Create(wd *WebhookDelivery) (int64, error) {
+	err := runWebhookDeliveryValFuncs(
+		wd,
+		webhookDeliveryRequireWebhookID,
+		webhookDeliveryRequireEvent,
+		webhookDeliveryRequirePayload,
+		webhookDeliveryRequireStatus,
+		webhookDeliveryRequireAttempts,
+		webhookDeliveryRequireCreatedAt,
+	)
+	if err != nil {
+		return -1, pkgErr("invalid webhook delivery", err)
+	}
+
+	wd.utc()
+
+	columns := columnsNoID(webhookDeliveryColumns)
+	insertQ := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES (%s)
+		RETURNING id
+	`, webhookDeliveryTable, columns, values(columns))
+
+	var id int64
+	row := wds.Database.QueryRow(insertQ, wd.valuesNoID()...)
+	err = row.Scan(&id)
+	if err != nil {
+		return -1, pkgErr("error executing insert query", err)
+	}
+
+	return id, nil
+}
+
+func (wds *webhookDeliveryService) DeleteByWebhook(webhookID int64) error {
+	deleteQ := fmt.Sprintf(`
+		DELETE FROM "%s"
+		WHERE webhook_id=?
+	`, webhookDeliveryTable)
+
+	_, err := wds.Database.Exec(deleteQ, webhookID)
+	if err != nil {
+		return pkgErr("error executing delete query", err)
+	}
+
+	return nil
+}
+
+// DeleteFinishedBefore deletes the delivered and failed deliveries created before t.
+func (wds *webhookDeliveryService) DeleteFinishedBefore(t time.Time) error {
+	deleteQ := fmt.Sprintf(`
+		DELETE FROM "%s"
+		WHERE status!=? AND created_at<?
+	`, webhookDeliveryTable)
+
+	_, err := wds.Database.Exec(deleteQ, WebhookDeliveryStatusPending, t.UTC())
+	if err != nil {
+		return pkgErr("error executing delete query", err)
+	}
+
+	return nil
+}
+
+func (wds *webhookDeliveryService) DestructiveReset() error {
+	err := wds.dropWebhookDeliveryTable()
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error dropping %s table", webhookDeliveryTable), err)
+	}
+
+	return nil
+}
+
+func (wds *webhookDeliveryService) dropWebhookDeliveryTable() error {
+	dropQ := fmt.Sprintf(`
+		DROP TABLE IF EXISTS "%s"
+	`, webhookDeliveryTable)
+
+	_, err := wds.Database.Exec(dropQ)
+	if err != nil {
+		return fmt.Errorf("error executing drop query: %v", err)
+	}
+
+	return nil
+}
+
+func (wds *webhookDeliveryService) Update(wd *WebhookDelivery) error {
+	err := runWebhookDeliveryValFuncs(
+		wd,
+		webhookDeliveryRequireID,
+		webhookDeliveryRequireWebhookID,
+		webhookDeliveryRequireEvent,
+		webhookDeliveryRequirePayload,
+		webhookDeliveryRequireStatus,
+		webhookDeliveryRequireAttempts,
+		webhookDeliveryRequireCreatedAt,
+	)
+	if err != nil {
+		return pkgErr("invalid webhook delivery", err)
+	}
+
+	wd.utc()
+
+	columns := columnsNoID(webhookDeliveryColumns)
+	updateQ := fmt.Sprintf(`
+		UPDATE "%s"
+		SET %s
+		WHERE id=?
+	`, webhookDeliveryTable, set(columns))
+
+	_, err = wds.Database.Exec(updateQ, wd.valuesEndID()...)
+	if err != nil {
+		return pkgErr("error executing update query", err)
+	}
+
+	return nil
+}
+
+type webhookDeliveryValFunc func(*WebhookDelivery) error
+
+func runWebhookDeliveryValFuncs(wd *WebhookDelivery, fns ...webhookDeliveryValFunc) error {
+	if wd == nil {
+		return fmt.Errorf("webhook delivery is nil")
+	}
+
+	for _, fn := range fns {
+		err := fn(wd)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func webhookDeliveryRequireAttempts(wd *WebhookDelivery) error {
+	if wd.Attempts == nil || *wd.Attempts < 0 {
+		return ErrWebhookDeliveryInvalidAttempts
+	}
+
+	return nil
+}
+
+func webhookDeliveryRequireCreatedAt(wd *WebhookDelivery) error {
+	if wd.CreatedAt == nil || wd.CreatedAt.IsZero() {
+		return ErrWebhookDeliveryInvalidCreatedAt
+	}
+
+	return nil
+}
+
+func webhookDeliveryRequireEvent(wd *WebhookDelivery) error {
+	if wd.Event == nil || *wd.Event == "" {
+		return ErrWebhookDeliveryInvalidEvent
+	}
+
+	return nil
+}
+
+func webhookDeliveryRequireID(wd *WebhookDelivery) error {
+	if wd.ID == nil || *wd.ID < 1 {
+		return ErrWebhookDeliveryInvalidID
+	}
+
+	return nil
+}
+
+func webhookDeliveryRequirePayload(wd *WebhookDelivery) error {
+	if wd.Payload == nil {
+		return ErrWebhookDeliveryInvalidPayload
+	}
+
+	return nil
+}
+
+func webhookDeliveryRequireStatus(wd *WebhookDelivery) error {
+	if wd.Status == nil || !slices.Contains([]string{WebhookDeliveryStatusDelivered, WebhookDeliveryStatusFailed, WebhookDeliveryStatusPending}, *wd.Status) {
+		return ErrWebhookDeliveryInvalidStatus
+	}
+
+	return nil
+}
+
+func webhookDeliveryRequireWebhookID(wd *WebhookDelivery) error {
+	if wd.WebhookID == nil || *wd.WebhookID < 1 {
+		return ErrWebhookDeliveryInvalidWebhookID
+	}
+
+	return nil
+}
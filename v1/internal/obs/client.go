@@ -0,0 +1,360 @@
+package obs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	minReconnectWait = time.Second
+	maxReconnectWait = 30 * time.Second
+	requestTimeout   = 10 * time.Second
+)
+
+// Status is the state of the connection to OBS.
+type Status struct {
+	Connected bool   `json:"connected"`
+	Error     string `json:"error"`
+}
+
+type result struct {
+	data json.RawMessage
+	err  error
+}
+
+// Client keeps a connection to the OBS WebSocket server, reconnecting whenever it is lost.
+type Client struct {
+	cancel    context.CancelFunc
+	conn      *websocket.Conn
+	connMu    sync.Mutex
+	done      chan struct{}
+	logError  *log.Logger
+	nextID    int64
+	onStatus  func(Status)
+	pending   map[string]chan result
+	pendingMu sync.Mutex
+	runMu     sync.Mutex
+	settings  Settings
+	status    Status
+	statusMu  sync.Mutex
+}
+
+// NewClient creates a client for the settings.
+// onStatus, if not nil, is called whenever the connection status changes.
+func NewClient(settings Settings, logError *log.Logger, onStatus func(Status)) *Client {
+	return &Client{
+		logError: logError,
+		onStatus: onStatus,
+		pending:  map[string]chan result{},
+		settings: settings,
+	}
+}
+
+func (c *Client) Settings() Settings {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	return c.settings
+}
+
+// SetSettings disconnects from OBS, replaces the settings and reconnects if the new settings are enabled.
+func (c *Client) SetSettings(settings Settings) error {
+	err := settings.Validate()
+	if err != nil {
+		return pkgErr("invalid settings", err)
+	}
+
+	c.Stop()
+
+	c.runMu.Lock()
+	c.settings = settings
+	c.runMu.Unlock()
+
+	c.Start()
+
+	return nil
+}
+
+// Start connects to OBS in the background if the client is enabled.
+func (c *Client) Start() {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.cancel != nil || !c.settings.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	go c.run(ctx, c.settings, c.done)
+}
+
+// Stop disconnects from OBS and waits for the client to stop.
+func (c *Client) Stop() {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.cancel == nil {
+		return
+	}
+
+	c.cancel()
+	c.closeConn()
+	<-c.done
+	c.cancel = nil
+
+	c.setStatus(Status{})
+}
+
+func (c *Client) Status() Status {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+
+	return c.status
+}
+
+func (c *Client) setStatus(status Status) {
+	c.statusMu.Lock()
+	changed := c.status != status
+	c.status = status
+	c.statusMu.Unlock()
+
+	if changed && c.onStatus != nil {
+		c.onStatus(status)
+	}
+}
+
+func (c *Client) run(ctx context.Context, settings Settings, done chan struct{}) {
+	defer close(done)
+
+	wait := minReconnectWait
+	for {
+		conn, err := c.connect(ctx, settings)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.setStatus(Status{Error: err.Error()})
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			wait = min(wait*2, maxReconnectWait)
+			continue
+		}
+		wait = minReconnectWait
+
+		c.connMu.Lock()
+		c.conn = conn
+		c.connMu.Unlock()
+		if ctx.Err() != nil {
+			c.closeConn()
+			return
+		}
+		c.setStatus(Status{Connected: true})
+
+		err = c.read(conn)
+		c.closeConn()
+		c.failPending(pkgErr("", fmt.Errorf("connection to OBS closed")))
+		if ctx.Err() != nil {
+			return
+		}
+		c.logError.Println(pkgErr("lost connection to OBS", err))
+		c.setStatus(Status{Error: fmt.Sprintf("lost connection: %v", err)})
+	}
+}
+
+// connect opens a connection to OBS and identifies with it.
+func (c *Client) connect(ctx context.Context, settings Settings) (*websocket.Conn, error) {
+	config, err := websocket.NewConfig("ws://"+settings.Address, "http://localhost")
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %v", err)
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	conn, err := config.DialContext(dialCtx)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting: %v", err)
+	}
+
+	err = identifyConn(conn, settings.Password)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func identifyConn(conn *websocket.Conn, password string) error {
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	var msg message
+	err := websocket.JSON.Receive(conn, &msg)
+	if err != nil {
+		return fmt.Errorf("error receiving hello: %v", err)
+	}
+	if msg.Op != opHello {
+		return fmt.Errorf("expected hello, received op %d", msg.Op)
+	}
+	var h hello
+	err = json.Unmarshal(msg.D, &h)
+	if err != nil {
+		return fmt.Errorf("error un-marshaling hello: %v", err)
+	}
+
+	id := identify{RpcVersion: rpcVersion}
+	if h.Authentication != nil {
+		if password == "" {
+			return fmt.Errorf("OBS requires a password")
+		}
+		id.Authentication = authenticate(password, *h.Authentication)
+	}
+	d, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Errorf("error marshaling identify: %v", err)
+	}
+	err = websocket.JSON.Send(conn, message{Op: opIdentify, D: d})
+	if err != nil {
+		return fmt.Errorf("error sending identify: %v", err)
+	}
+
+	err = websocket.JSON.Receive(conn, &msg)
+	if err != nil {
+		// OBS closes the connection if authentication fails.
+		return fmt.Errorf("error receiving identified, check the password: %v", err)
+	}
+	if msg.Op != opIdentified {
+		return fmt.Errorf("expected identified, received op %d", msg.Op)
+	}
+
+	return nil
+}
+
+// read delivers request responses until the connection fails.
+func (c *Client) read(conn *websocket.Conn) error {
+	for {
+		var msg message
+		err := websocket.JSON.Receive(conn, &msg)
+		if err != nil {
+			return err
+		}
+		if msg.Op != opRequestResponse {
+			continue
+		}
+
+		var resp requestResponse
+		err = json.Unmarshal(msg.D, &resp)
+		if err != nil {
+			c.logError.Println(pkgErr("error un-marshaling request response", err))
+			continue
+		}
+
+		c.pendingMu.Lock()
+		ch, exists := c.pending[resp.RequestID]
+		delete(c.pending, resp.RequestID)
+		c.pendingMu.Unlock()
+		if !exists {
+			continue
+		}
+
+		if !resp.RequestStatus.Result {
+			ch <- result{err: &RequestError{resp.RequestType, resp.RequestStatus.Code, resp.RequestStatus.Comment}}
+			continue
+		}
+		ch <- result{data: resp.ResponseData}
+	}
+}
+
+func (c *Client) closeConn() {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+func (c *Client) failPending(err error) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	for id, ch := range c.pending {
+		ch <- result{err: err}
+		delete(c.pending, id)
+	}
+}
+
+// RequestError is returned when OBS fails a request.
+type RequestError struct {
+	RequestType string
+	Code        int
+	Comment     string
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s: %s failed with code %d: %s", pkgName, e.RequestType, e.Code, e.Comment)
+}
+
+// Request sends the request to OBS and returns its response data.
+// If OBS fails the request the error is a *RequestError.
+func (c *Client) Request(requestType string, data any) (json.RawMessage, error) {
+	c.connMu.Lock()
+	conn := c.conn
+	c.connMu.Unlock()
+	if conn == nil {
+		return nil, pkgErr("", fmt.Errorf("not connected to OBS"))
+	}
+
+	ch := make(chan result, 1)
+	c.pendingMu.Lock()
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	c.pending[id] = ch
+	c.pendingMu.Unlock()
+
+	d, err := json.Marshal(request{RequestType: requestType, RequestID: id, RequestData: data})
+	if err != nil {
+		c.removePending(id)
+		return nil, pkgErr("error marshaling request", err)
+	}
+	err = websocket.JSON.Send(conn, message{Op: opRequest, D: d})
+	if err != nil {
+		c.removePending(id)
+		return nil, pkgErr("error sending request", err)
+	}
+
+	timer := time.NewTimer(requestTimeout)
+	defer timer.Stop()
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return res.data, nil
+	case <-timer.C:
+		c.removePending(id)
+		return nil, pkgErr("", fmt.Errorf("%s timed out", requestType))
+	}
+}
+
+func (c *Client) removePending(id string) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	delete(c.pending, id)
+}
@@ -0,0 +1,287 @@
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// fakeObs is a stand-in for the OBS WebSocket server.
+// It requires a password if one is set, and answers the requests the client sends.
+type fakeObs struct {
+	conns    chan *websocket.Conn
+	password string
+	server   *httptest.Server
+
+	requests   []request
+	requestsMu sync.Mutex
+}
+
+const (
+	fakeSalt      = "lM1GncleQOaCu9lT1yeUZhFYnqhsLLP1G5lAGo3ixaI="
+	fakeChallenge = "+IxH4CnCiqpX1rM9scsNynZzbOe4KhDeYcTNS3PDaeY="
+)
+
+func newFakeObs(t *testing.T, password string) *fakeObs {
+	t.Helper()
+
+	obs := &fakeObs{
+		conns:    make(chan *websocket.Conn, 10),
+		password: password,
+	}
+	obs.server = httptest.NewServer(websocket.Handler(obs.serve))
+	t.Cleanup(obs.server.Close)
+
+	return obs
+}
+
+func (obs *fakeObs) address() string {
+	return strings.TrimPrefix(obs.server.URL, "http://")
+}
+
+func (obs *fakeObs) send(conn *websocket.Conn, op int, d any) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return websocket.JSON.Send(conn, message{Op: op, D: b})
+}
+
+func (obs *fakeObs) serve(conn *websocket.Conn) {
+	defer conn.Close()
+
+	h := hello{ObsWebSocketVersion: "5.0.0", RpcVersion: rpcVersion}
+	if obs.password != "" {
+		h.Authentication = &authentication{Challenge: fakeChallenge, Salt: fakeSalt}
+	}
+	if obs.send(conn, opHello, h) != nil {
+		return
+	}
+
+	var msg message
+	if websocket.JSON.Receive(conn, &msg) != nil || msg.Op != opIdentify {
+		return
+	}
+	var id identify
+	if json.Unmarshal(msg.D, &id) != nil || id.RpcVersion != rpcVersion {
+		return
+	}
+	if obs.password != "" && id.Authentication != expectedAuthentication(obs.password) {
+		// OBS closes the connection if authentication fails.
+		return
+	}
+	if obs.send(conn, opIdentified, identified{NegotiatedRpcVersion: rpcVersion}) != nil {
+		return
+	}
+	obs.conns <- conn
+
+	for {
+		var msg message
+		if websocket.JSON.Receive(conn, &msg) != nil {
+			return
+		}
+		var req request
+		if msg.Op != opRequest || json.Unmarshal(msg.D, &req) != nil {
+			continue
+		}
+		obs.requestsMu.Lock()
+		obs.requests = append(obs.requests, req)
+		obs.requestsMu.Unlock()
+
+		resp := requestResponse{RequestType: req.RequestType, RequestID: req.RequestID, RequestStatus: requestStatus{Result: true, Code: 100}}
+		switch req.RequestType {
+		case "GetSceneItemId":
+			resp.ResponseData = json.RawMessage(`{"sceneItemId":7}`)
+		case "GetSceneItemEnabled":
+			resp.ResponseData = json.RawMessage(`{"sceneItemEnabled":true}`)
+		case "StartRecord":
+			resp.RequestStatus = requestStatus{Code: statusOutputRunning, Comment: "recording is already active"}
+		case "SetCurrentProgramScene":
+			var data struct {
+				SceneName string `json:"sceneName"`
+			}
+			b, _ := json.Marshal(req.RequestData)
+			json.Unmarshal(b, &data)
+			if data.SceneName == "Missing" {
+				resp.RequestStatus = requestStatus{Code: 600, Comment: "no scene named Missing"}
+			}
+		}
+		if obs.send(conn, opRequestResponse, resp) != nil {
+			return
+		}
+	}
+}
+
+func (obs *fakeObs) sent() []request {
+	obs.requestsMu.Lock()
+	defer obs.requestsMu.Unlock()
+
+	return append([]request{}, obs.requests...)
+}
+
+// expectedAuthentication computes the authentication string as documented by the OBS WebSocket protocol.
+func expectedAuthentication(password string) string {
+	secret := sha256.Sum256([]byte(password + fakeSalt))
+	response := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + fakeChallenge))
+
+	return base64.StdEncoding.EncodeToString(response[:])
+}
+
+// startClient starts a client for the fake server and returns the statuses it reports.
+func startClient(t *testing.T, obs *fakeObs, password string) (*Client, chan Status) {
+	t.Helper()
+
+	statuses := make(chan Status, 20)
+	c := NewClient(Settings{Enabled: true, Address: obs.address(), Password: password}, log.New(io.Discard, "", 0), func(s Status) {
+		statuses <- s
+	})
+	c.Start()
+	t.Cleanup(c.Stop)
+
+	return c, statuses
+}
+
+// waitStatus waits until the client reports a status that matches.
+func waitStatus(t *testing.T, statuses chan Status, match func(Status) bool) Status {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case s := <-statuses:
+			if match(s) {
+				return s
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for status")
+		}
+	}
+}
+
+func connected(s Status) bool {
+	return s.Connected
+}
+
+func TestClientIdentify(t *testing.T) {
+	tests := []struct {
+		name      string
+		server    string
+		password  string
+		wantError string
+	}{
+		{name: "no authentication", server: "", password: ""},
+		{name: "authentication", server: "secret", password: "secret"},
+		{name: "wrong password", server: "secret", password: "wrong", wantError: "check the password"},
+		{name: "missing password", server: "secret", password: "", wantError: "OBS requires a password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obs := newFakeObs(t, tt.server)
+			_, statuses := startClient(t, obs, tt.password)
+
+			s := waitStatus(t, statuses, func(s Status) bool { return s.Connected || s.Error != "" })
+			if tt.wantError == "" {
+				if !s.Connected {
+					t.Fatalf("status = %+v, want connected", s)
+				}
+				return
+			}
+			if s.Connected || !strings.Contains(s.Error, tt.wantError) {
+				t.Fatalf("status = %+v, want error containing %q", s, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestClientRequests(t *testing.T) {
+	obs := newFakeObs(t, "secret")
+	c, statuses := startClient(t, obs, "secret")
+	waitStatus(t, statuses, connected)
+
+	err := c.SetScene("Live")
+	if err != nil {
+		t.Fatalf("SetScene returned error: %v", err)
+	}
+
+	err = c.SetSourceVisible("Live", "Camera", nil)
+	if err != nil {
+		t.Fatalf("SetSourceVisible returned error: %v", err)
+	}
+
+	err = c.StartRecording()
+	if err != nil {
+		t.Fatalf("StartRecording returned error for a running recording: %v", err)
+	}
+
+	err = c.SetScene("Missing")
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != 600 || reqErr.RequestType != "SetCurrentProgramScene" {
+		t.Fatalf("SetScene error = %v, want a request error with code 600", err)
+	}
+
+	sent := obs.sent()
+	types := []string{}
+	for _, req := range sent {
+		types = append(types, req.RequestType)
+	}
+	want := []string{"SetCurrentProgramScene", "GetSceneItemId", "GetSceneItemEnabled", "SetSceneItemEnabled", "StartRecord", "SetCurrentProgramScene"}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("requests = %v, want %v", types, want)
+	}
+
+	// The source was visible, so toggling it hides it.
+	b, _ := json.Marshal(sent[3].RequestData)
+	if !strings.Contains(string(b), `"sceneItemEnabled":false`) || !strings.Contains(string(b), `"sceneItemId":7`) {
+		t.Fatalf("SetSceneItemEnabled data = %s, want item 7 disabled", b)
+	}
+
+	ids := map[string]bool{}
+	for _, req := range sent {
+		if ids[req.RequestID] {
+			t.Fatalf("request ID %s was reused", req.RequestID)
+		}
+		ids[req.RequestID] = true
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	obs := newFakeObs(t, "")
+	c, statuses := startClient(t, obs, "")
+	waitStatus(t, statuses, connected)
+
+	conn := <-obs.conns
+	conn.Close()
+
+	s := waitStatus(t, statuses, func(s Status) bool { return !s.Connected })
+	if !strings.Contains(s.Error, "lost connection") {
+		t.Fatalf("status = %+v, want lost connection error", s)
+	}
+	waitStatus(t, statuses, connected)
+	<-obs.conns
+
+	err := c.SetScene("Live")
+	if err != nil {
+		t.Fatalf("SetScene after reconnecting returned error: %v", err)
+	}
+
+	c.Stop()
+	if c.Status() != (Status{}) {
+		t.Fatalf("status after stopping = %+v, want disconnected", c.Status())
+	}
+	_, err = c.Request("GetVersion", nil)
+	if err == nil {
+		t.Fatal("request after stopping returned no error")
+	}
+}
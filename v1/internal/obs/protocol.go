@@ -0,0 +1,80 @@
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// OBS WebSocket v5 protocol, see https://github.com/obsproject/obs-websocket/blob/master/docs/generated/protocol.md
+
+const rpcVersion = 1
+
+const (
+	opHello           = 0
+	opIdentify        = 1
+	opIdentified      = 2
+	opEvent           = 5
+	opRequest         = 6
+	opRequestResponse = 7
+)
+
+// Request status codes that mean the output was already in the requested state.
+const (
+	statusOutputRunning    = 500
+	statusOutputNotRunning = 501
+)
+
+type message struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+type hello struct {
+	ObsWebSocketVersion string          `json:"obsWebSocketVersion"`
+	RpcVersion          int             `json:"rpcVersion"`
+	Authentication      *authentication `json:"authentication,omitempty"`
+}
+
+type authentication struct {
+	Challenge string `json:"challenge"`
+	Salt      string `json:"salt"`
+}
+
+type identify struct {
+	RpcVersion         int    `json:"rpcVersion"`
+	Authentication     string `json:"authentication,omitempty"`
+	EventSubscriptions int    `json:"eventSubscriptions"`
+}
+
+type identified struct {
+	NegotiatedRpcVersion int `json:"negotiatedRpcVersion"`
+}
+
+type request struct {
+	RequestType string `json:"requestType"`
+	RequestID   string `json:"requestId"`
+	RequestData any    `json:"requestData,omitempty"`
+}
+
+type requestResponse struct {
+	RequestType   string          `json:"requestType"`
+	RequestID     string          `json:"requestId"`
+	RequestStatus requestStatus   `json:"requestStatus"`
+	ResponseData  json.RawMessage `json:"responseData"`
+}
+
+type requestStatus struct {
+	Result  bool   `json:"result"`
+	Code    int    `json:"code"`
+	Comment string `json:"comment"`
+}
+
+// authenticate returns the authentication string for the password and the challenge sent by OBS.
+func authenticate(password string, auth authentication) string {
+	secret := sha256.Sum256([]byte(password + auth.Salt))
+	secretB64 := base64.StdEncoding.EncodeToString(secret[:])
+	response := sha256.Sum256([]byte(secretB64 + auth.Challenge))
+
+	return base64.StdEncoding.EncodeToString(response[:])
+}
@@ -0,0 +1,79 @@
+package obs
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// SetScene switches the program scene.
+func (c *Client) SetScene(name string) error {
+	_, err := c.Request("SetCurrentProgramScene", map[string]any{"sceneName": name})
+	return err
+}
+
+// SetSourceVisible shows or hides the source in the scene.
+// If visible is nil the source's visibility is toggled.
+func (c *Client) SetSourceVisible(scene string, source string, visible *bool) error {
+	data, err := c.Request("GetSceneItemId", map[string]any{"sceneName": scene, "sourceName": source})
+	if err != nil {
+		return err
+	}
+	var item struct {
+		SceneItemID int `json:"sceneItemId"`
+	}
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		return pkgErr("error un-marshaling scene item ID", err)
+	}
+
+	enabled := false
+	if visible != nil {
+		enabled = *visible
+	} else {
+		data, err := c.Request("GetSceneItemEnabled", map[string]any{"sceneName": scene, "sceneItemId": item.SceneItemID})
+		if err != nil {
+			return err
+		}
+		var current struct {
+			SceneItemEnabled bool `json:"sceneItemEnabled"`
+		}
+		err = json.Unmarshal(data, &current)
+		if err != nil {
+			return pkgErr("error un-marshaling scene item enabled", err)
+		}
+		enabled = !current.SceneItemEnabled
+	}
+
+	_, err = c.Request("SetSceneItemEnabled", map[string]any{"sceneName": scene, "sceneItemId": item.SceneItemID, "sceneItemEnabled": enabled})
+	return err
+}
+
+// StartRecording starts recording, doing nothing if OBS is already recording.
+func (c *Client) StartRecording() error {
+	_, err := c.Request("StartRecord", nil)
+	if requestCode(err) == statusOutputRunning {
+		return nil
+	}
+
+	return err
+}
+
+// StopRecording stops recording, doing nothing if OBS is not recording.
+func (c *Client) StopRecording() error {
+	_, err := c.Request("StopRecord", nil)
+	if requestCode(err) == statusOutputNotRunning {
+		return nil
+	}
+
+	return err
+}
+
+// requestCode returns the status code of a failed request, or 0 if err is not a request error.
+func requestCode(err error) int {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.Code
+	}
+
+	return 0
+}
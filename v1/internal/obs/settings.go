@@ -0,0 +1,80 @@
+package obs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// DefaultAddress is the address OBS listens for WebSocket connections on by default.
+const DefaultAddress = "localhost:4455"
+
+// Settings configure the connection to OBS.
+// Address is the host and port of the OBS WebSocket server.
+// Password is only needed if authentication is enabled in OBS.
+type Settings struct {
+	Enabled  bool   `json:"enabled"`
+	Address  string `json:"address"`
+	Password string `json:"password"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Address: DefaultAddress,
+	}
+}
+
+func (s Settings) Validate() error {
+	if s.Enabled && s.Address == "" {
+		return fmt.Errorf("address is empty")
+	}
+
+	return nil
+}
+
+// LoadSettings reads the settings saved at path, or returns the default settings if none were saved.
+func LoadSettings(path string) (Settings, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return DefaultSettings(), nil
+		}
+		return Settings{}, pkgErr("error reading settings file", err)
+	}
+
+	settings := DefaultSettings()
+	err = json.Unmarshal(b, &settings)
+	if err != nil {
+		return Settings{}, pkgErr("error un-marshaling settings", err)
+	}
+
+	err = settings.Validate()
+	if err != nil {
+		return Settings{}, pkgErr("invalid settings", err)
+	}
+
+	return settings, nil
+}
+
+// SaveSettings writes the settings to path.
+// The file is only readable by the user since it holds the OBS password.
+func SaveSettings(path string, settings Settings) error {
+	err := settings.Validate()
+	if err != nil {
+		return pkgErr("invalid settings", err)
+	}
+
+	b, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return pkgErr("error marshaling settings", err)
+	}
+
+	err = os.WriteFile(path, b, 0600)
+	if err != nil {
+		return pkgErr("error writing settings file", err)
+	}
+
+	return nil
+}
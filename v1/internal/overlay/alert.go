@@ -0,0 +1,95 @@
+package overlay
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Alert is an event shown by the alerts overlay.
+// Message is the rendered alert template and Duration is in milliseconds.
+// Tier and Color are set for rants matching a rant tier.
+type Alert struct {
+	Kind        Kind   `json:"kind"`
+	Username    string `json:"username"`
+	AmountCents int64  `json:"amount_cents"`
+	Text        string `json:"text"`
+	Message     string `json:"message"`
+	Duration    int64  `json:"duration"`
+	Tier        string `json:"tier,omitempty"`
+	Color       string `json:"color,omitempty"`
+}
+
+type alertFields struct {
+	Username string
+	Amount   string
+	Text     string
+}
+
+// newAlert builds the alert for the event from the settings.
+// It returns nil if alerts of that kind are disabled.
+func newAlert(settings Settings, kind Kind, username string, amountCents int64, text string) (*Alert, error) {
+	alertSettings, exists := settings.Alerts[kind]
+	if !exists || !alertSettings.Enabled {
+		return nil, nil
+	}
+
+	alert := &Alert{
+		Kind:        kind,
+		Username:    username,
+		AmountCents: amountCents,
+		Text:        text,
+		Duration:    alertSettings.Duration,
+	}
+	tmpl := alertSettings.Template
+
+	if kind == KindRant {
+		tier := rantTier(settings.RantTiers, amountCents)
+		if tier != nil {
+			alert.Tier = tier.Name
+			alert.Color = tier.Color
+			if tier.Template != "" {
+				tmpl = tier.Template
+			}
+			if tier.Duration > 0 {
+				alert.Duration = tier.Duration
+			}
+		}
+	}
+
+	t, err := template.New(string(kind)).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+
+	var message bytes.Buffer
+	err = t.Execute(&message, alertFields{
+		Username: username,
+		Amount:   formatAmount(amountCents),
+		Text:     text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error executing template: %v", err)
+	}
+	alert.Message = message.String()
+
+	return alert, nil
+}
+
+// rantTier returns the highest tier the amount reaches, or nil if it reaches none.
+func rantTier(tiers []RantTier, amountCents int64) *RantTier {
+	var match *RantTier
+	for i := range tiers {
+		tier := &tiers[i]
+		if amountCents >= tier.MinAmountCents && (match == nil || tier.MinAmountCents > match.MinAmountCents) {
+			match = tier
+		}
+	}
+
+	return match
+}
+
+// formatAmount formats cents as dollars, like $5.00.
+func formatAmount(cents int64) string {
+	return fmt.Sprintf("$%d.%02d", cents/100, cents%100)
+}
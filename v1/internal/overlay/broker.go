@@ -0,0 +1,85 @@
+package overlay
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// keepAlive is how often an idle event stream sends a comment so proxies and browsers keep it open.
+const keepAlive = 15 * time.Second
+
+// broker sends events to every connected Server-Sent Events client.
+type broker struct {
+	clients   map[chan []byte]struct{}
+	clientsMu sync.Mutex
+}
+
+func newBroker() *broker {
+	return &broker{
+		clients: map[chan []byte]struct{}{},
+	}
+}
+
+func (b *broker) subscribe() chan []byte {
+	ch := make(chan []byte, 16)
+
+	b.clientsMu.Lock()
+	defer b.clientsMu.Unlock()
+	b.clients[ch] = struct{}{}
+
+	return ch
+}
+
+func (b *broker) unsubscribe(ch chan []byte) {
+	b.clientsMu.Lock()
+	defer b.clientsMu.Unlock()
+	delete(b.clients, ch)
+}
+
+// publish sends the data to every client, dropping it for clients that are not keeping up.
+func (b *broker) publish(data []byte) {
+	b.clientsMu.Lock()
+	defer b.clientsMu.Unlock()
+
+	for ch := range b.clients {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// serve streams published data to the client as events named event until the request is done.
+func (b *broker) serve(w http.ResponseWriter, r *http.Request, event string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := b.subscribe()
+	defer b.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case data := <-ch:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,74 @@
+package overlay
+
+import (
+	"strings"
+	"time"
+
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+// ChatMessage is a chat message shown by the chat overlay.
+// Name is the channel the user is chatting as, or their username.
+type ChatMessage struct {
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	Badges    []string  `json:"badges"`
+	ImageUrl  string    `json:"image_url,omitempty"`
+	Text      string    `json:"text"`
+	RantCents int       `json:"rant_cents,omitempty"`
+	Rant      string    `json:"rant,omitempty"`
+	RantTier  string    `json:"rant_tier,omitempty"`
+	RantColor string    `json:"rant_color,omitempty"`
+	Raid      bool      `json:"raid,omitempty"`
+	Sub       bool      `json:"sub,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// newChatMessage builds the chat message for the view from the settings.
+// It returns nil if the message is filtered out.
+func newChatMessage(settings Settings, view rumblelivestreamlib.ChatView) *ChatMessage {
+	if view.Type != rumblelivestreamlib.ChatTypeMessages {
+		return nil
+	}
+	if settings.Chat.hides(view.Username) || (view.ChannelName != "" && settings.Chat.hides(view.ChannelName)) {
+		return nil
+	}
+	if settings.Chat.HideCommands && strings.HasPrefix(strings.TrimSpace(view.Text), "!") {
+		return nil
+	}
+
+	name := view.Username
+	if view.ChannelName != "" {
+		name = view.ChannelName
+	}
+
+	badges := view.Badges
+	if badges == nil {
+		badges = []string{}
+	}
+
+	msg := &ChatMessage{
+		Username: view.Username,
+		Name:     name,
+		Color:    view.Color,
+		Badges:   badges,
+		ImageUrl: view.ImageUrl,
+		Text:     view.Text,
+		Raid:     view.Raid,
+		Sub:      view.Sub,
+		Time:     view.Time,
+	}
+
+	if view.Rant > 0 {
+		msg.RantCents = view.Rant
+		msg.Rant = formatAmount(int64(view.Rant))
+		tier := rantTier(settings.RantTiers, int64(view.Rant))
+		if tier != nil {
+			msg.RantTier = tier.Name
+			msg.RantColor = tier.Color
+		}
+	}
+
+	return msg
+}
@@ -0,0 +1,227 @@
+package overlay
+
+// alertsPage shows alerts from /alerts/events one at a time, each for its duration.
+// The alert element gets the classes "alert", the alert's kind and its rant tier, if any, for custom CSS in OBS.
+const alertsPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Rum Goggles Alerts</title>
+<style>
+html, body {
+	background: transparent;
+	margin: 0;
+	overflow: hidden;
+}
+#alert {
+	box-sizing: border-box;
+	color: #ffffff;
+	font-family: sans-serif;
+	font-size: 36px;
+	font-weight: bold;
+	opacity: 0;
+	padding: 24px;
+	text-align: center;
+	text-shadow: 2px 2px 4px #000000;
+	transition: opacity 0.5s;
+	width: 100%;
+}
+#alert.show {
+	opacity: 1;
+}
+</style>
+</head>
+<body>
+<div id="alert" class="alert"></div>
+<script>
+const fade = 500;
+const queue = [];
+let showing = false;
+
+function next() {
+	const alert = queue.shift();
+	if (!alert) {
+		showing = false;
+		return;
+	}
+	showing = true;
+
+	const el = document.getElementById("alert");
+	el.className = "alert " + alert.kind + (alert.tier ? " " + alert.tier : "");
+	el.style.color = alert.color || "";
+	el.textContent = alert.message;
+	requestAnimationFrame(() => el.classList.add("show"));
+
+	setTimeout(() => {
+		el.classList.remove("show");
+		setTimeout(next, fade);
+	}, alert.duration);
+}
+
+const events = new EventSource("/alerts/events");
+events.addEventListener("alert", (e) => {
+	queue.push(JSON.parse(e.data));
+	if (!showing) {
+		next();
+	}
+});
+</script>
+</body>
+</html>
+`
+
+// chatPage shows the messages from /chat/events, newest at the bottom.
+// Its theme is set with url parameters:
+//
+//	font    font family
+//	size    font size, in pixels
+//	color   text color of messages
+//	bg      background color of messages
+//	width   width of the chat, in pixels
+//	badges  0 hides badges
+//	avatars 0 hides avatars
+//	max     maximum number of messages shown, overriding the chat settings
+//	fade    milliseconds a message is shown before fading out, 0 for never, overriding the chat settings
+//
+// Messages get the classes "message", "rant" with the rant tier, "raid" and "sub", for custom CSS in OBS.
+const chatPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Rum Goggles Chat</title>
+<style>
+html, body {
+	background: transparent;
+	margin: 0;
+	overflow: hidden;
+}
+#chat {
+	bottom: 0;
+	box-sizing: border-box;
+	color: #ffffff;
+	font-family: sans-serif;
+	font-size: 20px;
+	padding: 8px;
+	position: absolute;
+	width: 100%;
+}
+.message {
+	border-radius: 6px;
+	margin-top: 6px;
+	opacity: 1;
+	overflow-wrap: anywhere;
+	padding: 4px 8px;
+	text-shadow: 1px 1px 2px #000000;
+	transition: opacity 0.5s;
+}
+.message.fade {
+	opacity: 0;
+}
+.message.rant {
+	border-left: 6px solid #85c742;
+}
+.avatar {
+	border-radius: 50%;
+	height: 1.2em;
+	margin-right: 4px;
+	vertical-align: middle;
+	width: 1.2em;
+}
+.badge {
+	background: rgba(255, 255, 255, 0.2);
+	border-radius: 4px;
+	font-size: 0.7em;
+	margin-right: 4px;
+	padding: 1px 4px;
+	vertical-align: middle;
+}
+.name {
+	font-weight: bold;
+	margin-right: 6px;
+}
+.amount {
+	font-weight: bold;
+	margin-right: 6px;
+}
+</style>
+</head>
+<body>
+<div id="chat"></div>
+<script>
+const defaults = /*DEFAULTS*/{};
+const params = new URLSearchParams(window.location.search);
+const chat = document.getElementById("chat");
+
+function intParam(name, def) {
+	const n = parseInt(params.get(name), 10);
+	return isNaN(n) || n < 0 ? def : n;
+}
+
+const max = Math.max(intParam("max", defaults.max || 20), 1);
+const fade = intParam("fade", defaults.fade || 0);
+const showBadges = params.get("badges") !== "0";
+const showAvatars = params.get("avatars") !== "0";
+const background = params.get("bg");
+
+if (params.has("font")) chat.style.fontFamily = params.get("font");
+if (params.has("size")) chat.style.fontSize = intParam("size", 20) + "px";
+if (params.has("color")) chat.style.color = params.get("color");
+if (params.has("width")) chat.style.width = intParam("width", 0) + "px";
+
+function span(className, text) {
+	const el = document.createElement("span");
+	el.className = className;
+	el.textContent = text;
+	return el;
+}
+
+function add(msg) {
+	const el = document.createElement("div");
+	el.className = "message";
+	if (background) el.style.background = background;
+	if (msg.rant) {
+		el.classList.add("rant");
+		if (msg.rant_tier) el.className += " " + msg.rant_tier;
+		if (msg.rant_color) el.style.borderLeftColor = msg.rant_color;
+	}
+	if (msg.raid) el.classList.add("raid");
+	if (msg.sub) el.classList.add("sub");
+
+	if (showAvatars && msg.image_url) {
+		const img = document.createElement("img");
+		img.className = "avatar";
+		img.src = msg.image_url;
+		el.appendChild(img);
+	}
+	if (showBadges) {
+		for (const badge of msg.badges) {
+			el.appendChild(span("badge " + badge, badge));
+		}
+	}
+	const name = span("name", msg.name);
+	if (msg.color) name.style.color = msg.color;
+	el.appendChild(name);
+	if (msg.rant) {
+		el.appendChild(span("amount", msg.rant));
+	}
+	el.appendChild(span("text", msg.text));
+
+	chat.appendChild(el);
+	while (chat.children.length > max) {
+		chat.removeChild(chat.firstChild);
+	}
+
+	if (fade > 0) {
+		setTimeout(() => {
+			el.classList.add("fade");
+			setTimeout(() => el.remove(), 500);
+		}, fade);
+	}
+}
+
+const events = new EventSource("/chat/events");
+events.addEventListener("chat", (e) => add(JSON.parse(e.data)));
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,343 @@
+package overlay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/goals"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+// Server serves the overlay pages for OBS browser sources on localhost.
+type Server struct {
+	alerts     *broker
+	chat       *broker
+	cancel     context.CancelFunc
+	goals      *broker
+	goalsLast  map[string][]goals.Progress
+	goalsMu    sync.Mutex
+	logError   *log.Logger
+	server     *http.Server
+	serverMu   sync.Mutex
+	settings   Settings
+	settingsMu sync.Mutex
+}
+
+func NewServer(settings Settings, logError *log.Logger) *Server {
+	return &Server{
+		alerts:    newBroker(),
+		chat:      newBroker(),
+		goals:     newBroker(),
+		goalsLast: map[string][]goals.Progress{},
+		logError:  logError,
+		settings:  settings,
+	}
+}
+
+// Handler returns the overlay routes:
+//
+//	GET  /alerts         the alerts overlay page
+//	GET  /alerts/events  the alerts as Server-Sent Events
+//	POST /alerts/test    sends a test alert, with optional kind, username, amount (in cents) and text parameters,
+//	                     only from the overlay's own origin or from clients that are not browsers
+//	GET  /chat           the chat overlay page, see chatPage for its theme parameters
+//	GET  /chat/events    the chat messages as Server-Sent Events
+//	GET  /goals          the latest progress of every page's goals as JSON, or of one page's with the page parameter
+//	GET  /goals/events   each page's goal progress as Server-Sent Events whenever it changes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/alerts", method(http.MethodGet, s.handleAlertsPage))
+	mux.HandleFunc("/alerts/events", method(http.MethodGet, s.handleAlertsEvents))
+	mux.HandleFunc("/alerts/test", method(http.MethodPost, s.handleAlertsTest))
+	mux.HandleFunc("/chat", method(http.MethodGet, s.handleChatPage))
+	mux.HandleFunc("/chat/events", method(http.MethodGet, s.handleChatEvents))
+	mux.HandleFunc("/goals", method(http.MethodGet, s.handleGoals))
+	mux.HandleFunc("/goals/events", method(http.MethodGet, s.handleGoalsEvents))
+
+	return mux
+}
+
+// method only lets requests with the HTTP method through to the handler.
+func method(m string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != m {
+			w.Header().Set("Allow", m)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// Start listens on the configured port of the loopback interface and serves the overlays in the background.
+func (s *Server) Start() error {
+	s.serverMu.Lock()
+	defer s.serverMu.Unlock()
+
+	if s.server != nil {
+		return pkgErr("", fmt.Errorf("server is already running"))
+	}
+
+	listener, err := net.Listen("tcp", s.addr())
+	if err != nil {
+		return pkgErr("error listening", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server := &http.Server{
+		Handler:     s.Handler(),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	s.cancel = cancel
+	s.server = server
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			s.logError.Println("overlay: error serving:", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop closes the event streams and shuts down the server.
+func (s *Server) Stop() error {
+	s.serverMu.Lock()
+	defer s.serverMu.Unlock()
+
+	if s.server == nil {
+		return nil
+	}
+
+	s.cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	s.server = nil
+	if err != nil {
+		return pkgErr("error shutting down server", err)
+	}
+
+	return nil
+}
+
+func (s *Server) addr() string {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	return fmt.Sprintf("127.0.0.1:%d", s.settings.Port)
+}
+
+// Url returns the base url of the overlays.
+func (s *Server) Url() string {
+	return "http://" + s.addr()
+}
+
+func (s *Server) Settings() Settings {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	return s.settings
+}
+
+// SetSettings replaces the settings.
+// A new port takes effect the next time the server is started.
+func (s *Server) SetSettings(settings Settings) error {
+	err := settings.Validate()
+	if err != nil {
+		return pkgErr("invalid settings", err)
+	}
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	s.settings = settings
+
+	return nil
+}
+
+// Alert shows an alert for the event, unless alerts of that kind are disabled.
+func (s *Server) Alert(kind Kind, username string, amountCents int64, text string) error {
+	alert, err := newAlert(s.Settings(), kind, username, amountCents, text)
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error creating %s alert", kind), err)
+	}
+	if alert == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return pkgErr("error marshaling alert", err)
+	}
+	s.alerts.publish(data)
+
+	return nil
+}
+
+// Chat shows the chat message on the chat overlay, unless it is filtered out.
+func (s *Server) Chat(view rumblelivestreamlib.ChatView) error {
+	msg := newChatMessage(s.Settings(), view)
+	if msg == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return pkgErr("error marshaling chat message", err)
+	}
+	s.chat.publish(data)
+
+	return nil
+}
+
+// goalsEvent is a page's goal progress sent to the goals event stream.
+type goalsEvent struct {
+	Page  string           `json:"page"`
+	Goals []goals.Progress `json:"goals"`
+}
+
+// Goals publishes the progress of the page's goals.
+func (s *Server) Goals(page string, progress []goals.Progress) error {
+	data, err := json.Marshal(goalsEvent{Page: page, Goals: progress})
+	if err != nil {
+		return pkgErr("error marshaling goals", err)
+	}
+
+	s.goalsMu.Lock()
+	s.goalsLast[page] = progress
+	s.goalsMu.Unlock()
+	s.goals.publish(data)
+
+	return nil
+}
+
+func (s *Server) handleAlertsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, alertsPage)
+}
+
+func (s *Server) handleAlertsEvents(w http.ResponseWriter, r *http.Request) {
+	s.alerts.serve(w, r, "alert")
+}
+
+func (s *Server) handleAlertsTest(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	kind := Kind(r.FormValue("kind"))
+	if kind == "" {
+		kind = KindFollow
+	}
+	if !kind.Valid() {
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+
+	username := r.FormValue("username")
+	if username == "" {
+		username = "RumGoggles"
+	}
+
+	var amountCents int64
+	if kind == KindRant {
+		amountCents = 500
+	}
+	if amount := r.FormValue("amount"); amount != "" {
+		var err error
+		amountCents, err = strconv.ParseInt(amount, 10, 64)
+		if err != nil || amountCents < 0 {
+			http.Error(w, "invalid amount", http.StatusBadRequest)
+			return
+		}
+	}
+
+	text := r.FormValue("text")
+	if text == "" && kind == KindRant {
+		text = "This is a test rant."
+	}
+
+	err := s.Alert(kind, username, amountCents, text)
+	if err != nil {
+		s.logError.Println("overlay: error sending test alert:", err)
+		http.Error(w, "error sending alert", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sameOrigin returns true if the request was sent to the loopback interface by the overlay itself or by a client that is not a browser.
+// Browsers send an Origin header with cross-origin POST requests, so other web pages cannot send alerts to the overlay.
+// Requiring a loopback host stops a web page from rebinding its own domain to the server to pass as the same origin.
+func sameOrigin(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if host != "127.0.0.1" && host != "localhost" {
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	return origin == "" || origin == "http://"+r.Host
+}
+
+func (s *Server) handleChatPage(w http.ResponseWriter, r *http.Request) {
+	chat := s.Settings().Chat
+	defaults, err := json.Marshal(map[string]any{
+		"max":  chat.MaxMessages,
+		"fade": chat.FadeOut,
+	})
+	if err != nil {
+		s.logError.Println("overlay: error marshaling chat defaults:", err)
+		http.Error(w, "error loading page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, strings.Replace(chatPage, "/*DEFAULTS*/{}", string(defaults), 1))
+}
+
+func (s *Server) handleChatEvents(w http.ResponseWriter, r *http.Request) {
+	s.chat.serve(w, r, "chat")
+}
+
+func (s *Server) handleGoals(w http.ResponseWriter, r *http.Request) {
+	s.goalsMu.Lock()
+	var data []byte
+	var err error
+	if page := r.FormValue("page"); page != "" {
+		progress, exists := s.goalsLast[page]
+		if !exists {
+			progress = []goals.Progress{}
+		}
+		data, err = json.Marshal(goalsEvent{Page: page, Goals: progress})
+	} else {
+		data, err = json.Marshal(s.goalsLast)
+	}
+	s.goalsMu.Unlock()
+	if err != nil {
+		s.logError.Println("overlay: error marshaling goals:", err)
+		http.Error(w, "error loading goals", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (s *Server) handleGoalsEvents(w http.ResponseWriter, r *http.Request) {
+	s.goals.serve(w, r, "goals")
+}
@@ -0,0 +1,391 @@
+package overlay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/goals"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+
+	s := NewServer(DefaultSettings(), log.New(io.Discard, "", 0))
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+
+	return s, ts
+}
+
+// stream is a connected event stream.
+type stream struct {
+	cancel context.CancelFunc
+	r      *bufio.Reader
+}
+
+// connect opens the event stream at path and waits until the server has subscribed it.
+func connect(t *testing.T, ts *httptest.Server, path string) *stream {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+path, nil)
+	if err != nil {
+		cancel()
+		t.Fatalf("error creating request: %v", err)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		cancel()
+		t.Fatalf("error connecting to %s: %v", path, err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		resp.Body.Close()
+	})
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	s := &stream{cancel: cancel, r: bufio.NewReader(resp.Body)}
+	line, err := s.r.ReadString('\n')
+	if err != nil || line != ": connected\n" {
+		t.Fatalf("first line = %q, %v, want connected comment", line, err)
+	}
+
+	return s
+}
+
+// next returns the name and data of the next event, skipping comments.
+func (s *stream) next(t *testing.T) (string, string) {
+	t.Helper()
+
+	var event, data string
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("error reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestHandlerPages(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/alerts", http.StatusOK, `new EventSource("/alerts/events")`},
+		{http.MethodGet, "/chat", http.StatusOK, `const defaults = {"fade":0,"max":20};`},
+		{http.MethodPost, "/alerts", http.StatusMethodNotAllowed, ""},
+		{http.MethodPost, "/chat/events", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/alerts/test", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/missing", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("error creating request: %v", err)
+			}
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatalf("error sending request: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == http.StatusMethodNotAllowed && resp.Header.Get("Allow") == "" {
+				t.Error("Allow header is not set")
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := resp.Header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want text/html", got)
+			}
+			if !strings.Contains(string(body), tt.body) {
+				t.Errorf("body does not contain %q", tt.body)
+			}
+		})
+	}
+}
+
+func TestAlertsEvents(t *testing.T) {
+	s, ts := newTestServer(t)
+	events := connect(t, ts, "/alerts/events")
+
+	err := s.Alert(KindRant, "supporter", 1250, "great stream")
+	if err != nil {
+		t.Fatalf("Alert returned error: %v", err)
+	}
+
+	event, data := events.next(t)
+	if event != "alert" {
+		t.Fatalf("event = %q, want alert", event)
+	}
+	var alert Alert
+	err = json.Unmarshal([]byte(data), &alert)
+	if err != nil {
+		t.Fatalf("error un-marshaling alert %q: %v", data, err)
+	}
+	want := Alert{
+		Kind:        KindRant,
+		Username:    "supporter",
+		AmountCents: 1250,
+		Text:        "great stream",
+		Message:     "supporter ranted $12.50: great stream",
+		Duration:    10000,
+		Tier:        "medium",
+		Color:       "#4285c7",
+	}
+	if alert != want {
+		t.Fatalf("alert = %+v, want %+v", alert, want)
+	}
+}
+
+func TestAlertsTest(t *testing.T) {
+	s, ts := newTestServer(t)
+	events := connect(t, ts, "/alerts/events")
+
+	tests := []struct {
+		form    url.Values
+		status  int
+		message string
+	}{
+		{url.Values{"kind": {"host"}}, http.StatusBadRequest, ""},
+		{url.Values{"kind": {"rant"}, "amount": {"-1"}}, http.StatusBadRequest, ""},
+		{url.Values{"kind": {"rant"}, "amount": {"five"}}, http.StatusBadRequest, ""},
+		{url.Values{}, http.StatusNoContent, "RumGoggles just followed!"},
+		{url.Values{"kind": {"rant"}, "username": {"tester"}}, http.StatusNoContent, "tester ranted $5.00: This is a test rant."},
+	}
+
+	for _, tt := range tests {
+		resp, err := ts.Client().PostForm(ts.URL+"/alerts/test", tt.form)
+		if err != nil {
+			t.Fatalf("error posting %v: %v", tt.form, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Fatalf("status for %v = %d, want %d", tt.form, resp.StatusCode, tt.status)
+		}
+		if tt.message == "" {
+			continue
+		}
+
+		_, data := events.next(t)
+		var alert Alert
+		err = json.Unmarshal([]byte(data), &alert)
+		if err != nil {
+			t.Fatalf("error un-marshaling alert %q: %v", data, err)
+		}
+		if alert.Message != tt.message {
+			t.Errorf("message for %v = %q, want %q", tt.form, alert.Message, tt.message)
+		}
+	}
+
+	settings := DefaultSettings()
+	settings.Alerts[KindFollow] = AlertSettings{Enabled: false}
+	err := s.SetSettings(settings)
+	if err != nil {
+		t.Fatalf("SetSettings returned error: %v", err)
+	}
+	err = s.Alert(KindFollow, "quiet", 0, "")
+	if err != nil {
+		t.Fatalf("Alert returned error: %v", err)
+	}
+	err = s.Alert(KindRaid, "raider", 0, "")
+	if err != nil {
+		t.Fatalf("Alert returned error: %v", err)
+	}
+	_, data := events.next(t)
+	if !strings.Contains(data, `"kind":"raid"`) {
+		t.Fatalf("disabled follow alert was sent: %s", data)
+	}
+}
+
+func TestChatEvents(t *testing.T) {
+	s, ts := newTestServer(t)
+	events := connect(t, ts, "/chat/events")
+
+	views := []rumblelivestreamlib.ChatView{
+		{Type: rumblelivestreamlib.ChatTypeInit, Username: "init", Text: "init"},
+		{Type: rumblelivestreamlib.ChatTypeMessages, Username: "viewer", Text: "!points"},
+		{Type: rumblelivestreamlib.ChatTypeMessages, Username: "viewer", ChannelName: "ViewerChannel", Text: "hello", Rant: 5000},
+	}
+	for _, view := range views {
+		err := s.Chat(view)
+		if err != nil {
+			t.Fatalf("Chat returned error: %v", err)
+		}
+	}
+
+	event, data := events.next(t)
+	if event != "chat" {
+		t.Fatalf("event = %q, want chat", event)
+	}
+	var msg ChatMessage
+	err := json.Unmarshal([]byte(data), &msg)
+	if err != nil {
+		t.Fatalf("error un-marshaling chat message %q: %v", data, err)
+	}
+	if msg.Text != "hello" || msg.Name != "ViewerChannel" || msg.Rant != "$50.00" || msg.RantTier != "large" {
+		t.Fatalf("chat message = %+v, want the rant from ViewerChannel", msg)
+	}
+}
+
+func TestGoals(t *testing.T) {
+	s, ts := newTestServer(t)
+	events := connect(t, ts, "/goals/events")
+
+	progress := []goals.Progress{{ID: 1, Page: "/c/Channel", Title: "Followers", Target: 100, Progress: 50, Percent: 50}}
+	err := s.Goals("/c/Channel", progress)
+	if err != nil {
+		t.Fatalf("Goals returned error: %v", err)
+	}
+
+	event, data := events.next(t)
+	if event != "goals" || !strings.Contains(data, `"title":"Followers"`) {
+		t.Fatalf("event = %q %s, want the page's goals", event, data)
+	}
+
+	for path, want := range map[string]string{
+		"/goals?page=/c/Channel": `"percent":50`,
+		"/goals?page=/c/Other":   `{"page":"/c/Other","goals":[]}`,
+		"/goals":                 `"/c/Channel":[`,
+	} {
+		resp, err := ts.Client().Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("error getting %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(string(body), want) {
+			t.Errorf("%s = %s, want it to contain %s", path, body, want)
+		}
+	}
+}
+
+func TestBroker(t *testing.T) {
+	b := newBroker()
+	ch := b.subscribe()
+
+	// publish must not block on a client that is not reading.
+	for i := 0; i < cap(ch)+4; i++ {
+		b.publish([]byte{byte(i)})
+	}
+	if len(ch) != cap(ch) {
+		t.Fatalf("client has %d events, want %d", len(ch), cap(ch))
+	}
+	if first := <-ch; first[0] != 0 {
+		t.Fatalf("first event = %d, want 0", first[0])
+	}
+
+	b.unsubscribe(ch)
+	for len(ch) > 0 {
+		<-ch
+	}
+	b.publish([]byte("after"))
+	if len(ch) != 0 {
+		t.Fatal("unsubscribed client received an event")
+	}
+}
+
+func TestBrokerUnsubscribesClosedStreams(t *testing.T) {
+	s, ts := newTestServer(t)
+	events := connect(t, ts, "/chat/events")
+
+	count := func() int {
+		s.chat.clientsMu.Lock()
+		defer s.chat.clientsMu.Unlock()
+		return len(s.chat.clients)
+	}
+	if count() != 1 {
+		t.Fatalf("broker has %d clients, want 1", count())
+	}
+
+	events.cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for count() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("closed stream was not unsubscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAlertsTestOrigin(t *testing.T) {
+	_, ts := newTestServer(t)
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("error parsing server url: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		status int
+	}{
+		{name: "no origin", status: http.StatusNoContent},
+		{name: "same origin", origin: ts.URL, status: http.StatusNoContent},
+		{name: "localhost", host: "localhost:" + u.Port(), origin: "http://localhost:" + u.Port(), status: http.StatusNoContent},
+		{name: "other origin", origin: "https://example.com", status: http.StatusForbidden},
+		{name: "null origin", origin: "null", status: http.StatusForbidden},
+		{name: "rebound domain", host: "example.com:" + u.Port(), origin: "http://example.com:" + u.Port(), status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/alerts/test", strings.NewReader("username=tester"))
+			if err != nil {
+				t.Fatalf("error creating request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatalf("error sending request: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package overlay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// DefaultPort is the port the overlay server listens on if none is configured.
+const DefaultPort = 7373
+
+type Kind string
+
+const (
+	KindFollow Kind = "follow"
+	KindRaid   Kind = "raid"
+	KindRant   Kind = "rant"
+	KindSub    Kind = "sub"
+)
+
+var kinds = []Kind{KindFollow, KindRaid, KindRant, KindSub}
+
+func (k Kind) Valid() bool {
+	return slices.Contains(kinds, k)
+}
+
+// Settings configure the overlays.
+type Settings struct {
+	Port      int                    `json:"port"`
+	Alerts    map[Kind]AlertSettings `json:"alerts"`
+	RantTiers []RantTier             `json:"rant_tiers"`
+	Chat      ChatSettings           `json:"chat"`
+}
+
+// AlertSettings configure the alert shown for one kind of event.
+// Template is a Go text template executed with the alert's Username, Amount and Text.
+// Duration is how long the alert is shown, in milliseconds.
+type AlertSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Template string `json:"template"`
+	Duration int64  `json:"duration"`
+}
+
+// RantTier changes how rants of at least MinAmountCents are shown.
+// An empty Template or zero Duration keeps the rant alert's setting.
+// Color is any CSS color and Name is added to the alert's CSS classes.
+type RantTier struct {
+	Name           string `json:"name"`
+	MinAmountCents int64  `json:"min_amount_cents"`
+	Template       string `json:"template"`
+	Duration       int64  `json:"duration"`
+	Color          string `json:"color"`
+}
+
+// ChatSettings configure the chat overlay.
+// MaxMessages is how many messages are shown at once and FadeOut is how long each is shown, in milliseconds.
+// A FadeOut of 0 keeps messages until they are pushed out by newer ones.
+// Messages from Bots and Blocked users are hidden, as are commands if HideCommands is set.
+type ChatSettings struct {
+	MaxMessages  int      `json:"max_messages"`
+	FadeOut      int64    `json:"fade_out"`
+	HideCommands bool     `json:"hide_commands"`
+	Bots         []string `json:"bots"`
+	Blocked      []string `json:"blocked"`
+}
+
+// hides returns true if the user's messages are hidden from the chat overlay.
+func (c ChatSettings) hides(username string) bool {
+	matches := func(name string) bool {
+		return strings.EqualFold(name, username)
+	}
+
+	return slices.ContainsFunc(c.Bots, matches) || slices.ContainsFunc(c.Blocked, matches)
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Port: DefaultPort,
+		Alerts: map[Kind]AlertSettings{
+			KindFollow: {true, "{{.Username}} just followed!", 5000},
+			KindRaid:   {true, "{{.Username}} is raiding!", 8000},
+			KindRant:   {true, "{{.Username}} ranted {{.Amount}}: {{.Text}}", 8000},
+			KindSub:    {true, "{{.Username}} just subscribed!", 6000},
+		},
+		RantTiers: []RantTier{
+			{Name: "small", MinAmountCents: 100, Color: "#85c742"},
+			{Name: "medium", MinAmountCents: 1000, Color: "#4285c7", Duration: 10000},
+			{Name: "large", MinAmountCents: 5000, Color: "#c74285", Duration: 15000},
+		},
+		Chat: ChatSettings{
+			MaxMessages:  20,
+			HideCommands: true,
+		},
+	}
+}
+
+// Validate checks the settings and parses their templates.
+func (s Settings) Validate() error {
+	if s.Port < 1 || s.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", s.Port)
+	}
+
+	for kind, alert := range s.Alerts {
+		if !kind.Valid() {
+			return fmt.Errorf("invalid alert kind: %s", kind)
+		}
+		if alert.Duration < 0 {
+			return fmt.Errorf("invalid %s alert duration: %d", kind, alert.Duration)
+		}
+		_, err := template.New(string(kind)).Parse(alert.Template)
+		if err != nil {
+			return fmt.Errorf("invalid %s alert template: %v", kind, err)
+		}
+	}
+
+	for _, tier := range s.RantTiers {
+		if tier.MinAmountCents < 0 || tier.Duration < 0 {
+			return fmt.Errorf("invalid rant tier: %s", tier.Name)
+		}
+		_, err := template.New(tier.Name).Parse(tier.Template)
+		if err != nil {
+			return fmt.Errorf("invalid rant tier %s template: %v", tier.Name, err)
+		}
+	}
+
+	if s.Chat.MaxMessages < 1 {
+		return fmt.Errorf("invalid chat max messages: %d", s.Chat.MaxMessages)
+	}
+	if s.Chat.FadeOut < 0 {
+		return fmt.Errorf("invalid chat fade out: %d", s.Chat.FadeOut)
+	}
+
+	return nil
+}
+
+// LoadSettings reads the settings saved at path, or returns the default settings if none were saved.
+func LoadSettings(path string) (Settings, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return DefaultSettings(), nil
+		}
+		return Settings{}, pkgErr("error reading settings file", err)
+	}
+
+	settings := DefaultSettings()
+	err = json.Unmarshal(b, &settings)
+	if err != nil {
+		return Settings{}, pkgErr("error un-marshaling settings", err)
+	}
+
+	err = settings.Validate()
+	if err != nil {
+		return Settings{}, pkgErr("invalid settings", err)
+	}
+
+	return settings, nil
+}
+
+// SaveSettings writes the settings to path.
+func SaveSettings(path string, settings Settings) error {
+	err := settings.Validate()
+	if err != nil {
+		return pkgErr("invalid settings", err)
+	}
+
+	b, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return pkgErr("error marshaling settings", err)
+	}
+
+	err = os.WriteFile(path, b, 0644)
+	if err != nil {
+		return pkgErr("error writing settings file", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "md"
+)
+
+func (f Format) Valid() bool {
+	return f == FormatHTML || f == FormatMarkdown
+}
+
+// Extension returns the file extension for the format, including the leading dot.
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+func (f Format) Name() string {
+	switch f {
+	case FormatHTML:
+		return "HTML"
+	case FormatMarkdown:
+		return "Markdown"
+	default:
+		return string(f)
+	}
+}
+
+// Write renders the report to w in the given format.
+func Write(w io.Writer, format Format, r *Report) error {
+	var err error
+	switch format {
+	case FormatHTML:
+		err = htmlTmpl.Execute(w, r)
+	case FormatMarkdown:
+		err = markdownTmpl.Execute(w, r)
+	default:
+		return pkgErr("", fmt.Errorf("unsupported format: %s", format))
+	}
+	if err != nil {
+		return pkgErr(fmt.Sprintf("error writing %s", format.Name()), err)
+	}
+
+	return nil
+}
+
+var funcs = map[string]any{
+	"dollars":  dollars,
+	"duration": duration,
+	"md":       markdownEscaper.Replace,
+	"round":    func(f float64) string { return fmt.Sprintf("%.0f", f) },
+	"time":     func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+}
+
+func dollars(cents int64) string {
+	return fmt.Sprintf("$%d.%02d", cents/100, cents%100)
+}
+
+func duration(seconds int64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+	"#", `\#`,
+	"|", `\|`,
+	"\n", " ",
+)
+
+var markdownTmpl = template.Must(template.New("markdown").Funcs(funcs).Parse(`# Session report: {{md .Title}}
+
+- **Livestream:** {{.Livestream}}
+- **Start:** {{time .Start}}
+- **End:** {{time .End}}
+- **Duration:** {{duration .Duration}}
+- **Peak viewers:** {{.PeakViewers}}
+- **Average viewers:** {{round .AverageViewers}}
+- **New followers:** {{.NewFollowers}}
+- **New subscribers:** {{.NewSubscribers}}
+- **Chat messages:** {{.ChatMessages}}
+- **Unique chatters:** {{.UniqueChatters}}
+
+## Rants ({{dollars .RantTotal}})
+{{if .Rants}}
+| Time | User | Amount | Message |
+| --- | --- | --- | --- |
+{{- range .Rants}}
+| {{time .Time}} | {{md .Username}} | {{dollars .Amount}} | {{md .Text}} |
+{{- end}}
+{{else}}
+No rants.
+{{end}}
+## Raids
+{{if .Raids}}
+| Time | User | Message |
+| --- | --- | --- |
+{{- range .Raids}}
+| {{time .Time}} | {{md .Username}} | {{md .Text}} |
+{{- end}}
+{{else}}
+No raids.
+{{end}}
+## Title and category changes
+{{if .Changes}}
+| Time | Changed | From | To |
+| --- | --- | --- | --- |
+{{- range .Changes}}
+| {{time .Time}} | {{.Kind}} | {{md .Previous}} | {{md .Value}} |
+{{- end}}
+{{else}}
+No changes.
+{{end}}
+## Top chatters
+{{if .TopChatters}}
+| User | Messages |
+| --- | --- |
+{{- range .TopChatters}}
+| {{md .Name}} | {{.Count}} |
+{{- end}}
+{{else}}
+No chat.
+{{end}}
+## Most-used commands
+{{if .TopCommands}}
+| Command | Uses |
+| --- | --- |
+{{- range .TopCommands}}
+| {{md .Name}} | {{.Count}} |
+{{- end}}
+{{else}}
+No commands.
+{{end}}`))
+
+var htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Session report: {{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Session report: {{.Title}}</h1>
+<table>
+<tr><th>Livestream</th><td>{{.Livestream}}</td></tr>
+<tr><th>Start</th><td>{{time .Start}}</td></tr>
+<tr><th>End</th><td>{{time .End}}</td></tr>
+<tr><th>Duration</th><td>{{duration .Duration}}</td></tr>
+<tr><th>Peak viewers</th><td>{{.PeakViewers}}</td></tr>
+<tr><th>Average viewers</th><td>{{round .AverageViewers}}</td></tr>
+<tr><th>New followers</th><td>{{.NewFollowers}}</td></tr>
+<tr><th>New subscribers</th><td>{{.NewSubscribers}}</td></tr>
+<tr><th>Chat messages</th><td>{{.ChatMessages}}</td></tr>
+<tr><th>Unique chatters</th><td>{{.UniqueChatters}}</td></tr>
+</table>
+<h2>Rants ({{dollars .RantTotal}})</h2>
+{{if .Rants}}<table>
+<tr><th>Time</th><th>User</th><th>Amount</th><th>Message</th></tr>
+{{range .Rants}}<tr><td>{{time .Time}}</td><td>{{.Username}}</td><td>{{dollars .Amount}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>{{else}}<p>No rants.</p>{{end}}
+<h2>Raids</h2>
+{{if .Raids}}<table>
+<tr><th>Time</th><th>User</th><th>Message</th></tr>
+{{range .Raids}}<tr><td>{{time .Time}}</td><td>{{.Username}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>{{else}}<p>No raids.</p>{{end}}
+<h2>Title and category changes</h2>
+{{if .Changes}}<table>
+<tr><th>Time</th><th>Changed</th><th>From</th><th>To</th></tr>
+{{range .Changes}}<tr><td>{{time .Time}}</td><td>{{.Kind}}</td><td>{{.Previous}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>{{else}}<p>No changes.</p>{{end}}
+<h2>Top chatters</h2>
+{{if .TopChatters}}<table>
+<tr><th>User</th><th>Messages</th></tr>
+{{range .TopChatters}}<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>{{else}}<p>No chat.</p>{{end}}
+<h2>Most-used commands</h2>
+{{if .TopCommands}}<table>
+<tr><th>Command</th><th>Uses</th></tr>
+{{range .TopCommands}}<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>{{else}}<p>No commands.</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,162 @@
+package report
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+)
+
+// topCount is how many top chatters and commands are listed in a report.
+const topCount = 10
+
+// Report summarizes a livestream session.
+// Amounts are in cents and durations in seconds.
+type Report struct {
+	Livestream     string    `json:"livestream"`
+	Page           string    `json:"page"`
+	Title          string    `json:"title"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Duration       int64     `json:"duration"`
+	PeakViewers    int64     `json:"peak_viewers"`
+	AverageViewers float64   `json:"average_viewers"`
+	NewFollowers   int64     `json:"new_followers"`
+	NewSubscribers int64     `json:"new_subscribers"`
+	Rants          []Event   `json:"rants"`
+	RantTotal      int64     `json:"rant_total"`
+	Raids          []Event   `json:"raids"`
+	ChatMessages   int64     `json:"chat_messages"`
+	UniqueChatters int64     `json:"unique_chatters"`
+	TopChatters    []Count   `json:"top_chatters"`
+	TopCommands    []Count   `json:"top_commands"`
+	Changes        []Change  `json:"changes"`
+}
+
+// Event is a rant or raid received during the session.
+type Event struct {
+	Username string    `json:"username"`
+	Amount   int64     `json:"amount,omitempty"`
+	Text     string    `json:"text"`
+	Time     time.Time `json:"time"`
+}
+
+// Change is the livestream's title or category changing during the session.
+type Change struct {
+	Kind     string    `json:"kind"`
+	Previous string    `json:"previous"`
+	Value    string    `json:"value"`
+	Time     time.Time `json:"time"`
+}
+
+type Count struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// Session identifies the livestream session a report is generated for.
+type Session struct {
+	Livestream string
+	Page       string
+	Title      string
+	Start      time.Time
+	End        time.Time
+}
+
+// Generate builds the report for the session from its chat messages, title and category changes, and statistics.
+// Stats may be nil if no statistics were recorded.
+func Generate(session Session, messages []models.ChatMessage, changes []models.StreamChange, stats *models.StreamStatSummary) *Report {
+	r := &Report{
+		Livestream:  session.Livestream,
+		Page:        session.Page,
+		Title:       session.Title,
+		Start:       session.Start,
+		End:         session.End,
+		Rants:       []Event{},
+		Raids:       []Event{},
+		TopChatters: []Count{},
+		TopCommands: []Count{},
+		Changes:     []Change{},
+	}
+	if !r.Start.IsZero() && r.End.After(r.Start) {
+		r.Duration = int64(r.End.Sub(r.Start).Seconds())
+	}
+
+	if stats != nil {
+		r.PeakViewers = stats.PeakViewers
+		r.AverageViewers = stats.AverageViewers
+		r.NewFollowers = stats.FollowerGrowth
+		r.NewSubscribers = stats.SubscriberGrowth
+	}
+
+	chatters := map[string]int64{}
+	commands := map[string]int64{}
+	for _, message := range messages {
+		if message.Username == nil {
+			continue
+		}
+		username := *message.Username
+		text := ""
+		if message.Text != nil {
+			text = *message.Text
+		}
+		t := time.Time{}
+		if message.Time != nil {
+			t = *message.Time
+		}
+
+		r.ChatMessages++
+		chatters[username]++
+
+		if strings.HasPrefix(text, "!") {
+			command := strings.ToLower(strings.Fields(text)[0])
+			if command != "!" {
+				commands[command]++
+			}
+		}
+
+		if message.Rant != nil && *message.Rant > 0 {
+			r.Rants = append(r.Rants, Event{username, *message.Rant, text, t})
+			r.RantTotal += *message.Rant
+		}
+		if message.Raid != nil && *message.Raid {
+			r.Raids = append(r.Raids, Event{Username: username, Text: text, Time: t})
+		}
+	}
+
+	for _, change := range changes {
+		if change.Kind == nil || change.Previous == nil || change.Value == nil || change.Time == nil {
+			continue
+		}
+		r.Changes = append(r.Changes, Change{*change.Kind, *change.Previous, *change.Value, *change.Time})
+	}
+
+	r.UniqueChatters = int64(len(chatters))
+	r.TopChatters = top(chatters)
+	r.TopCommands = top(commands)
+
+	return r
+}
+
+// top returns the highest counts, highest first and ties by name.
+func top(counts map[string]int64) []Count {
+	all := []Count{}
+	for name, count := range counts {
+		all = append(all, Count{name, count})
+	}
+
+	slices.SortFunc(all, func(a, b Count) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	if len(all) > topCount {
+		all = all[:topCount]
+	}
+
+	return all
+}
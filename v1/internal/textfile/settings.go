@@ -0,0 +1,140 @@
+package textfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"text/template"
+)
+
+// File is the name of a text file kept up to date by the writer.
+type File string
+
+const (
+	FileFollowerGoal     File = "follower_goal.txt"
+	FileLatestFollower   File = "latest_follower.txt"
+	FileLatestRant       File = "latest_rant.txt"
+	FileLatestSubscriber File = "latest_subscriber.txt"
+	FileTopRanterSession File = "top_ranter_session.txt"
+	FileViewerCount      File = "viewer_count.txt"
+)
+
+var files = []File{
+	FileFollowerGoal,
+	FileLatestFollower,
+	FileLatestRant,
+	FileLatestSubscriber,
+	FileTopRanterSession,
+	FileViewerCount,
+}
+
+func (f File) Valid() bool {
+	return slices.Contains(files, f)
+}
+
+// Settings configure the text files.
+// Page limits the files to the events of one page, like "/c/Channel", or to every page if empty.
+// FollowerGoal is the number of followers written to the follower goal file.
+type Settings struct {
+	Enabled      bool                  `json:"enabled"`
+	Page         string                `json:"page"`
+	FollowerGoal int64                 `json:"follower_goal"`
+	Files        map[File]FileSettings `json:"files"`
+}
+
+// FileSettings configure one text file.
+// Template is a Go text template executed with the file's Fields.
+type FileSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Template string `json:"template"`
+}
+
+// Fields are the values available to the file templates.
+// Only the fields that apply to a file are set.
+type Fields struct {
+	Username  string
+	Amount    string
+	Text      string
+	Viewers   int64
+	Followers int64
+	Goal      int64
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		FollowerGoal: 100,
+		Files: map[File]FileSettings{
+			FileFollowerGoal:     {true, "{{.Followers}} / {{.Goal}}"},
+			FileLatestFollower:   {true, "{{.Username}}"},
+			FileLatestRant:       {true, "{{.Username}} {{.Amount}}"},
+			FileLatestSubscriber: {true, "{{.Username}}"},
+			FileTopRanterSession: {true, "{{.Username}} {{.Amount}}"},
+			FileViewerCount:      {true, "{{.Viewers}}"},
+		},
+	}
+}
+
+func (s Settings) Validate() error {
+	if s.FollowerGoal < 0 {
+		return fmt.Errorf("invalid follower goal: %d", s.FollowerGoal)
+	}
+
+	for file, fileSettings := range s.Files {
+		if !file.Valid() {
+			return fmt.Errorf("invalid file: %s", file)
+		}
+		_, err := template.New(string(file)).Parse(fileSettings.Template)
+		if err != nil {
+			return fmt.Errorf("invalid %s template: %v", file, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadSettings reads the settings saved at path, or returns the default settings if none were saved.
+func LoadSettings(path string) (Settings, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return DefaultSettings(), nil
+		}
+		return Settings{}, pkgErr("error reading settings file", err)
+	}
+
+	settings := DefaultSettings()
+	err = json.Unmarshal(b, &settings)
+	if err != nil {
+		return Settings{}, pkgErr("error un-marshaling settings", err)
+	}
+
+	err = settings.Validate()
+	if err != nil {
+		return Settings{}, pkgErr("invalid settings", err)
+	}
+
+	return settings, nil
+}
+
+// SaveSettings writes the settings to path.
+func SaveSettings(path string, settings Settings) error {
+	err := settings.Validate()
+	if err != nil {
+		return pkgErr("invalid settings", err)
+	}
+
+	b, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return pkgErr("error marshaling settings", err)
+	}
+
+	err = os.WriteFile(path, b, 0644)
+	if err != nil {
+		return pkgErr("error writing settings file", err)
+	}
+
+	return nil
+}
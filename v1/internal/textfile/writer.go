@@ -0,0 +1,273 @@
+package textfile
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/events"
+	"github.com/tylertravisty/rum-goggles/v1/internal/ledger"
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+// Writer keeps the text files in a directory up to date with a page's API responses and chat.
+type Writer struct {
+	dir        string
+	latest     map[File]time.Time
+	livestream string
+	logError   *log.Logger
+	mu         sync.Mutex
+	rantS      models.RantService
+	settings   Settings
+	values     map[File]*Fields
+	written    map[File]string
+}
+
+func NewWriter(dir string, settings Settings, rantS models.RantService, logError *log.Logger) *Writer {
+	return &Writer{
+		dir:      dir,
+		latest:   map[File]time.Time{},
+		logError: logError,
+		rantS:    rantS,
+		settings: settings,
+		values:   map[File]*Fields{},
+		written:  map[File]string{},
+	}
+}
+
+func (w *Writer) Dir() string {
+	return w.dir
+}
+
+func (w *Writer) Settings() Settings {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.settings
+}
+
+// SetSettings replaces the settings and rewrites the files with the latest values.
+func (w *Writer) SetSettings(settings Settings) error {
+	err := settings.Validate()
+	if err != nil {
+		return pkgErr("invalid settings", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if settings.Page != w.settings.Page {
+		w.latest = map[File]time.Time{}
+		w.livestream = ""
+		w.values = map[File]*Fields{}
+	}
+	w.settings = settings
+	w.written = map[File]string{}
+
+	if goal, exists := w.values[FileFollowerGoal]; exists && goal != nil {
+		goal.Goal = settings.FollowerGoal
+	}
+	for file, fields := range w.values {
+		w.write(file, fields)
+	}
+
+	return nil
+}
+
+// HandleApi updates the files from the page's API response.
+func (w *Writer) HandleApi(page string, resp *rumblelivestreamlib.LivestreamResponse) {
+	if resp == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.handles(page) {
+		return
+	}
+
+	viewers := int64(0)
+	for _, ls := range resp.Livestreams {
+		viewers += ls.WatchingNow
+	}
+	w.set(FileViewerCount, &Fields{Viewers: viewers})
+	w.set(FileFollowerGoal, &Fields{Followers: resp.Followers.NumFollowers, Goal: w.settings.FollowerGoal})
+
+	for _, f := range resp.Followers.RecentFollowers {
+		w.setLatest(FileLatestFollower, f.FollowedOn, &Fields{Username: f.Username})
+	}
+	for _, s := range resp.Subscribers.RecentSubscribers {
+		w.setLatest(FileLatestSubscriber, s.SubscribedOn, &Fields{Username: s.Username, Amount: formatAmount(s.AmountCents)})
+	}
+
+	if len(resp.Livestreams) > 0 {
+		w.livestream = fmt.Sprintf("https://rumble.com/v%s", resp.Livestreams[0].ID)
+	}
+	w.updateRants()
+}
+
+// HandleChat updates the rant files from a chat message on the page's livestream.
+// The rant must already be recorded in the ledger.
+func (w *Writer) HandleChat(page string, livestream string, view rumblelivestreamlib.ChatView) {
+	if view.Type != rumblelivestreamlib.ChatTypeMessages || view.Rant <= 0 {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.handles(page) {
+		return
+	}
+
+	if w.livestream == "" {
+		w.livestream = livestream
+	}
+	if livestream == w.livestream {
+		w.updateRants()
+	}
+}
+
+func (w *Writer) handles(page string) bool {
+	return w.settings.Enabled && (w.settings.Page == "" || w.settings.Page == page)
+}
+
+// setLatest sets the file to fields if the event at the API time s is newer than the one written.
+func (w *Writer) setLatest(file File, s string, fields *Fields) {
+	t, err := events.ParseTime(s)
+	if err != nil {
+		return
+	}
+	if !t.After(w.latest[file]) {
+		return
+	}
+
+	w.latest[file] = t
+	w.set(file, fields)
+}
+
+// updateRants sets the latest rant and the top ranter of the current livestream from the ledger.
+// The latest rant is the one most recently added to the ledger, rather than the one with the latest time,
+// because chat and API times for the same rant can be hours apart. It is kept if nobody has ranted yet.
+// The top ranter is cleared if nobody has ranted yet.
+func (w *Writer) updateRants() {
+	if w.livestream == "" {
+		return
+	}
+
+	rants, err := w.rantS.ByLivestream(w.livestream)
+	if err != nil {
+		w.logError.Println(pkgErr("error querying rants by livestream", err))
+		return
+	}
+	var latest *models.Rant
+	for i := range rants {
+		r := &rants[i]
+		if r.ID == nil || r.Username == nil || r.AmountCents == nil || r.Text == nil {
+			continue
+		}
+		if latest == nil || *r.ID > *latest.ID {
+			latest = r
+		}
+	}
+	if latest != nil {
+		w.set(FileLatestRant, &Fields{Username: *latest.Username, Amount: formatAmount(*latest.AmountCents), Text: *latest.Text})
+	}
+
+	totals, err := ledger.Totals(rants, ledger.PeriodSupporter)
+	if err != nil {
+		w.logError.Println(pkgErr("error totaling rants", err))
+		return
+	}
+
+	if len(totals) == 0 {
+		w.set(FileTopRanterSession, nil)
+		return
+	}
+	w.set(FileTopRanterSession, &Fields{Username: totals[0].Key, Amount: formatAmount(totals[0].AmountCents)})
+}
+
+func (w *Writer) set(file File, fields *Fields) {
+	w.values[file] = fields
+	w.write(file, fields)
+}
+
+// write renders the file's template with fields, or empties the file if fields is nil.
+// Files are only written when their content changes.
+func (w *Writer) write(file File, fields *Fields) {
+	fileSettings, exists := w.settings.Files[file]
+	if !exists || !fileSettings.Enabled {
+		return
+	}
+
+	content := ""
+	if fields != nil {
+		tmpl, err := template.New(string(file)).Parse(fileSettings.Template)
+		if err != nil {
+			w.logError.Println(pkgErr(fmt.Sprintf("error parsing %s template", file), err))
+			return
+		}
+
+		var b bytes.Buffer
+		err = tmpl.Execute(&b, fields)
+		if err != nil {
+			w.logError.Println(pkgErr(fmt.Sprintf("error executing %s template", file), err))
+			return
+		}
+		content = b.String()
+	}
+
+	if written, exists := w.written[file]; exists && written == content {
+		return
+	}
+
+	err := writeFileAtomic(filepath.Join(w.dir, string(file)), []byte(content))
+	if err != nil {
+		w.logError.Println(pkgErr(fmt.Sprintf("error writing %s", file), err))
+		return
+	}
+	w.written[file] = content
+}
+
+// writeFileAtomic replaces the file at path with data by renaming a temporary file over it,
+// so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing temporary file: %v", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("error closing temporary file: %v", err)
+	}
+	err = os.Chmod(tmp.Name(), 0644)
+	if err != nil {
+		return fmt.Errorf("error changing temporary file mode: %v", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("error renaming temporary file: %v", err)
+	}
+
+	return nil
+}
+
+// formatAmount formats cents as dollars, like $5.00.
+func formatAmount(cents int64) string {
+	return fmt.Sprintf("$%d.%02d", cents/100, cents%100)
+}
@@ -0,0 +1,369 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is attempted before it fails.
+	MaxAttempts = 8
+
+	dueBatch        = 20
+	keepDeliveries  = 7 * 24 * time.Hour
+	maxBackoff      = time.Hour
+	minBackoff      = 10 * time.Second
+	pollInterval    = 5 * time.Second
+	publishQueue    = 1024
+	pruneInterval   = time.Hour
+	requestTimeout  = 10 * time.Second
+	responseErrSize = 512
+)
+
+// Dispatcher queues events for the webhooks subscribed to them and delivers them in the background.
+// Deliveries are stored before they are sent, so pending deliveries are retried after a restart.
+type Dispatcher struct {
+	cancel     context.CancelFunc
+	client     *http.Client
+	deliveryS  models.WebhookDeliveryService
+	done       chan struct{}
+	logError   *log.Logger
+	onDelivery func(models.WebhookDelivery)
+	published  chan published
+	runMu      sync.Mutex
+	wake       chan struct{}
+	webhookS   models.WebhookService
+}
+
+// NewDispatcher creates a dispatcher that sends requests with client, or a default client if nil.
+// onDelivery, if not nil, is called after every delivery attempt.
+func NewDispatcher(webhookS models.WebhookService, deliveryS models.WebhookDeliveryService, client *http.Client, logError *log.Logger, onDelivery func(models.WebhookDelivery)) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: requestTimeout}
+	}
+
+	return &Dispatcher{
+		client:     client,
+		deliveryS:  deliveryS,
+		logError:   logError,
+		onDelivery: onDelivery,
+		published:  make(chan published, publishQueue),
+		wake:       make(chan struct{}, 1),
+		webhookS:   webhookS,
+	}
+}
+
+// Start delivers queued events in the background.
+func (d *Dispatcher) Start() {
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+
+	if d.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done = make(chan struct{})
+	go d.run(ctx, d.done)
+}
+
+// Stop waits for the delivery in progress, if any, and stops delivering.
+func (d *Dispatcher) Stop() {
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+
+	if d.cancel == nil {
+		return
+	}
+
+	d.cancel()
+	<-d.done
+	d.cancel = nil
+}
+
+// published is an event waiting to be queued for the webhooks subscribed to it.
+type published struct {
+	kind    string
+	payload string
+	time    time.Time
+}
+
+// Publish queues the event for every enabled webhook subscribed to kind.
+// The deliveries are stored in the background, so that the chat processing loop is never blocked by the database.
+// If the queue is full, the event is dropped rather than blocking the caller.
+func (d *Dispatcher) Publish(kind string, page string, data any) error {
+	now := time.Now()
+	payload, err := Event{Event: kind, Page: page, Time: now, Data: data}.payload()
+	if err != nil {
+		return pkgErr("error encoding event", err)
+	}
+
+	select {
+	case d.published <- published{kind: kind, payload: payload, time: now}:
+	default:
+		return pkgErr("", fmt.Errorf("event queue is full, dropping %s event", kind))
+	}
+
+	return nil
+}
+
+// Test queues a test event for the webhook, even if it is disabled.
+func (d *Dispatcher) Test(id int64) error {
+	w, err := d.webhookS.ByID(id)
+	if err != nil {
+		return pkgErr("error querying webhook by ID", err)
+	}
+	if w == nil {
+		return pkgErr("", fmt.Errorf("webhook does not exist"))
+	}
+
+	now := time.Now()
+	payload, err := Event{Event: KindTest, Time: now, Data: map[string]string{"message": "This is a test event from Rum Goggles."}}.payload()
+	if err != nil {
+		return pkgErr("error encoding event", err)
+	}
+
+	return d.queue(id, KindTest, payload, now)
+}
+
+func (d *Dispatcher) queue(webhookID int64, kind string, payload string, now time.Time) error {
+	status := models.WebhookDeliveryStatusPending
+	attempts := int64(0)
+	_, err := d.deliveryS.Create(&models.WebhookDelivery{
+		WebhookID:   &webhookID,
+		Event:       &kind,
+		Payload:     &payload,
+		Status:      &status,
+		Attempts:    &attempts,
+		NextAttempt: &now,
+		CreatedAt:   &now,
+	})
+	if err != nil {
+		return pkgErr("error creating webhook delivery", err)
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	stored := make(chan struct{})
+	go d.runPublished(ctx, stored)
+	defer func() { <-stored }()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		now := time.Now()
+		if now.Sub(pruned) >= pruneInterval {
+			err := d.deliveryS.DeleteFinishedBefore(now.Add(-keepDeliveries))
+			if err != nil {
+				d.logError.Println("error deleting old webhook deliveries:", err)
+			}
+			pruned = now
+		}
+
+		d.deliverDue(ctx)
+
+		select {
+		case <-d.wake:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runPublished stores the deliveries for published events until ctx is done,
+// and then stores the deliveries for the events still in the queue.
+func (d *Dispatcher) runPublished(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		select {
+		case e := <-d.published:
+			d.store(e)
+		case <-ctx.Done():
+			d.storePublished()
+			return
+		}
+	}
+}
+
+// storePublished stores the deliveries for the events in the queue without waiting for more.
+func (d *Dispatcher) storePublished() {
+	for {
+		select {
+		case e := <-d.published:
+			d.store(e)
+		default:
+			return
+		}
+	}
+}
+
+// store queues the event for every enabled webhook subscribed to it.
+func (d *Dispatcher) store(e published) {
+	webhooks, err := d.webhookS.All()
+	if err != nil {
+		d.logError.Println("error querying webhooks:", err)
+		return
+	}
+
+	for _, w := range webhooks {
+		if w.ID == nil || w.Enabled == nil || !*w.Enabled || w.Events == nil || !subscribed(*w.Events, e.kind) {
+			continue
+		}
+
+		err = d.queue(*w.ID, e.kind, e.payload, e.time)
+		if err != nil {
+			d.logError.Println("error queueing webhook delivery:", err)
+		}
+	}
+}
+
+// deliverDue attempts every delivery that is due, until there are none left or ctx is done.
+// If a delivery's result cannot be stored, it stops until the next poll rather than fetching the same deliveries again.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := d.deliveryS.Due(time.Now(), dueBatch)
+		if err != nil {
+			d.logError.Println("error querying due webhook deliveries:", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+
+		for i := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			err = d.deliver(ctx, &due[i])
+			if err != nil {
+				d.logError.Println("error delivering webhook event:", err)
+				return
+			}
+		}
+	}
+}
+
+// deliver attempts the delivery and records the result.
+// Deliveries to webhooks that were deleted or disabled fail without being sent, except for test events.
+// It returns an error if the webhook cannot be queried or the result cannot be stored.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	w, err := d.webhookS.ByID(*delivery.WebhookID)
+	switch {
+	case err != nil:
+		return pkgErr("error querying webhook by ID", err)
+	case w == nil:
+		return d.finish(delivery, models.WebhookDeliveryStatusFailed, nil, "webhook was deleted", now)
+	case (w.Enabled == nil || !*w.Enabled) && *delivery.Event != KindTest:
+		return d.finish(delivery, models.WebhookDeliveryStatusFailed, nil, "webhook is disabled", now)
+	}
+
+	code, err := d.send(ctx, w, delivery, now)
+	if ctx.Err() != nil {
+		// The app is closing, the delivery is retried on the next start.
+		return nil
+	}
+
+	attempts := *delivery.Attempts + 1
+	delivery.Attempts = &attempts
+	if err == nil {
+		return d.finish(delivery, models.WebhookDeliveryStatusDelivered, code, "", now)
+	}
+	if attempts >= MaxAttempts {
+		return d.finish(delivery, models.WebhookDeliveryStatusFailed, code, err.Error(), now)
+	}
+
+	next := now.Add(Backoff(attempts))
+	delivery.NextAttempt = &next
+	return d.finish(delivery, models.WebhookDeliveryStatusPending, code, err.Error(), now)
+}
+
+// send posts the delivery's payload to the webhook.
+// It returns the response status code, if there was a response, and an error if the status is not 2xx.
+func (d *Dispatcher) send(ctx context.Context, w *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (*int64, error) {
+	body := []byte(*delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *w.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Rum-Goggles-Webhook")
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(*delivery.ID, 10))
+	req.Header.Set(HeaderEvent, *delivery.Event)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(*w.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	code := int64(resp.StatusCode)
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, responseErrSize))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &code, fmt.Errorf("received status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	return &code, nil
+}
+
+func (d *Dispatcher) finish(delivery *models.WebhookDelivery, status string, code *int64, errMsg string, now time.Time) error {
+	delivery.Status = &status
+	delivery.ResponseCode = code
+	delivery.LastAttempt = &now
+	delivery.Error = nil
+	if errMsg != "" {
+		delivery.Error = &errMsg
+	}
+	if status != models.WebhookDeliveryStatusPending {
+		delivery.NextAttempt = nil
+	}
+
+	err := d.deliveryS.Update(delivery)
+	if err != nil {
+		return pkgErr("error updating webhook delivery", err)
+	}
+
+	if d.onDelivery != nil {
+		d.onDelivery(*delivery)
+	}
+
+	return nil
+}
+
+// Backoff returns how long to wait before retrying a delivery that failed attempts times.
+// It doubles from 10 seconds after each failure, up to an hour.
+func Backoff(attempts int64) time.Duration {
+	backoff := minBackoff
+	for i := int64(1); i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxBackoff)
+}
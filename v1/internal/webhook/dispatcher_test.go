@@ -0,0 +1,468 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+)
+
+func openServices(t *testing.T, file string) *models.Services {
+	t.Helper()
+
+	services, err := models.NewServices(
+		models.WithDatabase(file),
+		models.WithWebhookService(),
+		models.WithWebhookDeliveryService(),
+	)
+	if err != nil {
+		t.Fatalf("error opening services: %v", err)
+	}
+	err = services.AutoMigrate()
+	if err != nil {
+		t.Fatalf("error migrating services: %v", err)
+	}
+
+	return services
+}
+
+func newServices(t *testing.T) *models.Services {
+	t.Helper()
+
+	services := openServices(t, filepath.Join(t.TempDir(), "test.db"))
+	t.Cleanup(func() { services.Close() })
+
+	return services
+}
+
+func createWebhook(t *testing.T, services *models.Services, url string, events string, enabled bool) *models.Webhook {
+	t.Helper()
+
+	name := "test"
+	now := time.Now()
+	w := &models.Webhook{Name: &name, Url: &url, Events: &events, Enabled: &enabled, CreatedAt: &now}
+	err := Normalize(w)
+	if err != nil {
+		t.Fatalf("error normalizing webhook: %v", err)
+	}
+	id, err := services.WebhookS.Create(w)
+	if err != nil {
+		t.Fatalf("error creating webhook: %v", err)
+	}
+	w.ID = &id
+
+	return w
+}
+
+func deliveries(t *testing.T, services *models.Services, webhookID int64) []models.WebhookDelivery {
+	t.Helper()
+
+	list, err := services.WebhookDeliveryS.ByWebhook(webhookID, 100)
+	if err != nil {
+		t.Fatalf("error querying deliveries: %v", err)
+	}
+
+	return list
+}
+
+// receiver is a webhook endpoint that records the requests it receives and responds with the next status.
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	statuses []int
+}
+
+func (rcv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+
+	status := http.StatusOK
+	if len(rcv.statuses) > 0 {
+		status = rcv.statuses[0]
+		rcv.statuses = rcv.statuses[1:]
+	}
+	w.WriteHeader(status)
+	if status != http.StatusOK {
+		w.Write([]byte("try again later"))
+	}
+}
+
+func (rcv *receiver) count() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	return len(rcv.requests)
+}
+
+func newReceiver(t *testing.T, statuses ...int) (*receiver, *httptest.Server) {
+	t.Helper()
+
+	rcv := &receiver{statuses: statuses}
+	server := httptest.NewServer(rcv)
+	t.Cleanup(server.Close)
+
+	return rcv, server
+}
+
+func newTestDispatcher(services *models.Services) *Dispatcher {
+	return NewDispatcher(services.WebhookS, services.WebhookDeliveryS, nil, log.New(io.Discard, "", 0), nil)
+}
+
+// makeDue moves the webhook's pending deliveries' next attempt into the past.
+func makeDue(t *testing.T, services *models.Services, webhookID int64) {
+	t.Helper()
+
+	past := time.Now().Add(-time.Second)
+	for _, delivery := range deliveries(t, services, webhookID) {
+		if *delivery.Status != models.WebhookDeliveryStatusPending {
+			continue
+		}
+		delivery.NextAttempt = &past
+		err := services.WebhookDeliveryS.Update(&delivery)
+		if err != nil {
+			t.Fatalf("error updating delivery: %v", err)
+		}
+	}
+}
+
+func TestDeliverySignature(t *testing.T) {
+	services := newServices(t)
+	rcv, server := newReceiver(t)
+	_, otherServer := newReceiver(t)
+	w := createWebhook(t, services, server.URL, "rant,follow", true)
+	unsubscribed := createWebhook(t, services, otherServer.URL, "follow", true)
+	disabled := createWebhook(t, services, otherServer.URL, "rant", false)
+	d := newTestDispatcher(services)
+
+	err := d.Publish(KindRant, "/c/Channel", Chat{Username: "supporter", Text: "great stream", AmountCents: 500})
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	d.storePublished()
+	d.deliverDue(context.Background())
+
+	if len(deliveries(t, services, *unsubscribed.ID)) != 0 || len(deliveries(t, services, *disabled.ID)) != 0 {
+		t.Fatal("event was queued for a webhook that is not subscribed or disabled")
+	}
+	if rcv.count() != 1 {
+		t.Fatalf("webhook received %d requests, want 1", rcv.count())
+	}
+
+	req, body := rcv.requests[0], rcv.bodies[0]
+	list := deliveries(t, services, *w.ID)
+	if len(list) != 1 {
+		t.Fatalf("webhook has %d deliveries, want 1", len(list))
+	}
+	delivery := list[0]
+	if *delivery.Status != models.WebhookDeliveryStatusDelivered || *delivery.Attempts != 1 || *delivery.ResponseCode != http.StatusOK {
+		t.Fatalf("delivery = %s after %d attempts with %d, want delivered after 1 with 200", *delivery.Status, *delivery.Attempts, *delivery.ResponseCode)
+	}
+
+	if got := req.Header.Get(HeaderEvent); got != KindRant {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, KindRant)
+	}
+	if got := req.Header.Get(HeaderDelivery); got != strconv.FormatInt(*delivery.ID, 10) {
+		t.Errorf("%s = %q, want %d", HeaderDelivery, got, *delivery.ID)
+	}
+	timestamp := req.Header.Get(HeaderTimestamp)
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+		t.Errorf("%s = %q, want the current unix time", HeaderTimestamp, timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(*w.Secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.Header.Get(HeaderSignature); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if Sign("other secret", timestamp, body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+
+	var event struct {
+		Event string `json:"event"`
+		Page  string `json:"page"`
+		Data  Chat   `json:"data"`
+	}
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		t.Fatalf("error un-marshaling body %s: %v", body, err)
+	}
+	if event.Event != KindRant || event.Page != "/c/Channel" || event.Data.Username != "supporter" || event.Data.AmountCents != 500 {
+		t.Fatalf("body = %s, want the rant event", body)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int64
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDeliveryRetry(t *testing.T) {
+	services := newServices(t)
+	rcv, server := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	w := createWebhook(t, services, server.URL, "follow", true)
+	d := newTestDispatcher(services)
+
+	err := d.Publish(KindFollow, "/c/Channel", map[string]string{"username": "follower"})
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	d.storePublished()
+
+	for attempt := int64(1); attempt <= 2; attempt++ {
+		d.deliverDue(context.Background())
+
+		delivery := deliveries(t, services, *w.ID)[0]
+		if *delivery.Status != models.WebhookDeliveryStatusPending || *delivery.Attempts != attempt {
+			t.Fatalf("delivery = %s after %d attempts, want pending after %d", *delivery.Status, *delivery.Attempts, attempt)
+		}
+		if delivery.Error == nil || !strings.Contains(*delivery.Error, "try again later") {
+			t.Fatalf("delivery error = %v, want the response body", delivery.Error)
+		}
+		if wait := delivery.NextAttempt.Sub(*delivery.LastAttempt); wait != Backoff(attempt) {
+			t.Fatalf("next attempt is %s after the last, want %s", wait, Backoff(attempt))
+		}
+
+		// The retry is not due yet.
+		d.deliverDue(context.Background())
+		if rcv.count() != int(attempt) {
+			t.Fatalf("webhook received %d requests before the retry was due, want %d", rcv.count(), attempt)
+		}
+		makeDue(t, services, *w.ID)
+	}
+
+	d.deliverDue(context.Background())
+	delivery := deliveries(t, services, *w.ID)[0]
+	if *delivery.Status != models.WebhookDeliveryStatusDelivered || *delivery.Attempts != 3 || delivery.NextAttempt != nil || delivery.Error != nil {
+		t.Fatalf("delivery = %+v, want delivered after 3 attempts", delivery)
+	}
+}
+
+func TestDeliveryFails(t *testing.T) {
+	services := newServices(t)
+	statuses := make([]int, MaxAttempts)
+	for i := range statuses {
+		statuses[i] = http.StatusServiceUnavailable
+	}
+	rcv, server := newReceiver(t, statuses...)
+	w := createWebhook(t, services, server.URL, "follow", true)
+	d := newTestDispatcher(services)
+
+	err := d.Publish(KindFollow, "", nil)
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	d.storePublished()
+	for i := 0; i < MaxAttempts+2; i++ {
+		d.deliverDue(context.Background())
+		makeDue(t, services, *w.ID)
+	}
+
+	delivery := deliveries(t, services, *w.ID)[0]
+	if *delivery.Status != models.WebhookDeliveryStatusFailed || *delivery.Attempts != MaxAttempts || delivery.NextAttempt != nil {
+		t.Fatalf("delivery = %s after %d attempts, want failed after %d", *delivery.Status, *delivery.Attempts, MaxAttempts)
+	}
+	if rcv.count() != MaxAttempts {
+		t.Fatalf("webhook received %d requests, want %d", rcv.count(), MaxAttempts)
+	}
+}
+
+func TestPendingDeliveriesSurviveRestart(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.db")
+	services := openServices(t, file)
+
+	blocked := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hold the request until the dispatcher gives up on it.
+		// The body must be read for the server to notice the request being cancelled.
+		io.ReadAll(r.Body)
+		close(blocked)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	w := createWebhook(t, services, server.URL, "live_start", true)
+
+	d := newTestDispatcher(services)
+	d.Start()
+	err := d.Publish(KindLiveStart, "/c/Channel", nil)
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	select {
+	case <-blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivery was not sent")
+	}
+	d.Stop()
+
+	delivery := deliveries(t, services, *w.ID)[0]
+	if *delivery.Status != models.WebhookDeliveryStatusPending || *delivery.Attempts != 0 {
+		t.Fatalf("delivery interrupted by stopping = %s after %d attempts, want pending after 0", *delivery.Status, *delivery.Attempts)
+	}
+	services.Close()
+
+	// Restart with a webhook endpoint that accepts the delivery.
+	rcv, accepting := newReceiver(t)
+	services = openServices(t, file)
+	defer services.Close()
+	w.Url = &accepting.URL
+	err = services.WebhookS.Update(w)
+	if err != nil {
+		t.Fatalf("error updating webhook: %v", err)
+	}
+
+	delivered := make(chan models.WebhookDelivery, 1)
+	d = NewDispatcher(services.WebhookS, services.WebhookDeliveryS, nil, log.New(io.Discard, "", 0), func(delivery models.WebhookDelivery) {
+		delivered <- delivery
+	})
+	d.Start()
+	defer d.Stop()
+
+	select {
+	case delivery = <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending delivery was not sent after restarting")
+	}
+	if *delivery.ID != *deliveries(t, services, *w.ID)[0].ID || *delivery.Status != models.WebhookDeliveryStatusDelivered {
+		t.Fatalf("delivery after restart = %+v, want the pending delivery delivered", delivery)
+	}
+	if rcv.count() != 1 || rcv.requests[0].Header.Get(HeaderEvent) != KindLiveStart {
+		t.Fatalf("webhook received %d requests, want the live start event", rcv.count())
+	}
+}
+
+func TestPruneDeliveries(t *testing.T) {
+	services := newServices(t)
+	_, server := newReceiver(t)
+	w := createWebhook(t, services, server.URL, "follow", true)
+
+	now := time.Now()
+	old := now.Add(-keepDeliveries - time.Hour)
+	recent := now.Add(-keepDeliveries + time.Hour)
+	later := now.Add(time.Hour)
+	create := func(status string, createdAt time.Time, next *time.Time) int64 {
+		event, payload, attempts := KindFollow, "{}", int64(1)
+		id, err := services.WebhookDeliveryS.Create(&models.WebhookDelivery{
+			WebhookID:   w.ID,
+			Event:       &event,
+			Payload:     &payload,
+			Status:      &status,
+			Attempts:    &attempts,
+			NextAttempt: next,
+			CreatedAt:   &createdAt,
+		})
+		if err != nil {
+			t.Fatalf("error creating delivery: %v", err)
+		}
+		return id
+	}
+	create(models.WebhookDeliveryStatusDelivered, old, nil)
+	create(models.WebhookDeliveryStatusFailed, old, nil)
+	oldPending := create(models.WebhookDeliveryStatusPending, old, &later)
+	recentDelivered := create(models.WebhookDeliveryStatusDelivered, recent, nil)
+
+	d := newTestDispatcher(services)
+	d.Start()
+	d.Stop()
+
+	kept := map[int64]bool{}
+	for _, delivery := range deliveries(t, services, *w.ID) {
+		kept[*delivery.ID] = true
+	}
+	if len(kept) != 2 || !kept[oldPending] || !kept[recentDelivered] {
+		t.Fatalf("kept deliveries %v, want the pending and recent deliveries %d and %d", kept, oldPending, recentDelivered)
+	}
+}
+
+// failingUpdates is a delivery service that cannot store delivery results.
+type failingUpdates struct {
+	models.WebhookDeliveryService
+	due int
+}
+
+func (f *failingUpdates) Due(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	f.due++
+	return f.WebhookDeliveryService.Due(now, limit)
+}
+
+func (f *failingUpdates) Update(wd *models.WebhookDelivery) error {
+	return fmt.Errorf("database is locked")
+}
+
+func TestDeliverDueStopsWhenResultIsNotStored(t *testing.T) {
+	services := newServices(t)
+	rcv, server := newReceiver(t)
+	createWebhook(t, services, server.URL, "follow", true)
+	deliveryS := &failingUpdates{WebhookDeliveryService: services.WebhookDeliveryS}
+	d := NewDispatcher(services.WebhookS, deliveryS, nil, log.New(io.Discard, "", 0), nil)
+
+	err := d.Publish(KindFollow, "/c/Channel", nil)
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	d.storePublished()
+	d.deliverDue(context.Background())
+
+	if deliveryS.due != 1 || rcv.count() != 1 {
+		t.Fatalf("queried due deliveries %d times and sent %d requests, want to wait for the next poll after 1", deliveryS.due, rcv.count())
+	}
+}
+
+func TestStopStoresPublishedEvents(t *testing.T) {
+	services := newServices(t)
+	_, server := newReceiver(t)
+	w := createWebhook(t, services, server.URL, "follow", true)
+	d := newTestDispatcher(services)
+
+	// Events published before the dispatcher starts wait in the queue.
+	err := d.Publish(KindFollow, "/c/Channel", nil)
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	if len(deliveries(t, services, *w.ID)) != 0 {
+		t.Fatal("Publish stored the delivery, want it stored in the background")
+	}
+
+	d.Start()
+	d.Stop()
+	if len(deliveries(t, services, *w.ID)) != 1 {
+		t.Fatal("published event was not stored before stopping")
+	}
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tylertravisty/rum-goggles/v1/internal/models"
+	rumblelivestreamlib "github.com/tylertravisty/rumble-livestream-lib-go"
+)
+
+const (
+	KindChatMessage = "chat_message"
+	KindFollow      = "follow"
+	KindLiveEnd     = "live_end"
+	KindLiveStart   = "live_start"
+	KindRaid        = "raid"
+	KindRant        = "rant"
+	KindRuleError   = "rule_error"
+	KindSub         = "sub"
+	KindTest        = "test"
+)
+
+// Kinds are the event kinds a webhook can subscribe to.
+// Test events are sent only when a webhook is tested, and are always accepted.
+var Kinds = []string{
+	KindChatMessage,
+	KindFollow,
+	KindLiveEnd,
+	KindLiveStart,
+	KindRaid,
+	KindRant,
+	KindRuleError,
+	KindSub,
+}
+
+const (
+	HeaderDelivery  = "X-RumGoggles-Delivery"
+	HeaderEvent     = "X-RumGoggles-Event"
+	HeaderSignature = "X-RumGoggles-Signature"
+	HeaderTimestamp = "X-RumGoggles-Timestamp"
+)
+
+// Event is the JSON body posted to webhooks.
+// Page is the name of the page the event happened on, if any.
+type Event struct {
+	Event string    `json:"event"`
+	Page  string    `json:"page,omitempty"`
+	Time  time.Time `json:"time"`
+	Data  any       `json:"data"`
+}
+
+// Chat is the data of chat message, rant, raid and sub events.
+type Chat struct {
+	Livestream  string    `json:"livestream"`
+	Username    string    `json:"username"`
+	ChannelName string    `json:"channel_name,omitempty"`
+	Text        string    `json:"text"`
+	AmountCents int64     `json:"amount_cents,omitempty"`
+	Badges      []string  `json:"badges,omitempty"`
+	Raid        bool      `json:"raid,omitempty"`
+	Sub         bool      `json:"sub,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+func NewChat(livestream string, message rumblelivestreamlib.ChatView) Chat {
+	return Chat{
+		Livestream:  livestream,
+		Username:    message.Username,
+		ChannelName: message.ChannelName,
+		Text:        message.Text,
+		AmountCents: int64(message.Rant),
+		Badges:      message.Badges,
+		Raid:        message.Raid,
+		Sub:         message.Sub,
+		Time:        message.Time,
+	}
+}
+
+func (e Event) payload() (string, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// ParseKinds parses a comma-separated list of event kinds into a sorted list without duplicates.
+func ParseKinds(s string) ([]string, error) {
+	kinds := []string{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(Kinds, field) {
+			return nil, fmt.Errorf("unknown event %q", field)
+		}
+		kinds = append(kinds, field)
+	}
+	if len(kinds) == 0 {
+		return nil, fmt.Errorf("no events")
+	}
+	slices.Sort(kinds)
+
+	return slices.Compact(kinds), nil
+}
+
+// Normalize checks the webhook's url and events, sorting its events and generating a secret if it has none.
+func Normalize(w *models.Webhook) error {
+	if w == nil {
+		return pkgErr("", fmt.Errorf("webhook is nil"))
+	}
+
+	if w.Url == nil {
+		return pkgErr("", fmt.Errorf("url is empty"))
+	}
+	u, err := url.Parse(*w.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return pkgErr("", fmt.Errorf("url must be an http or https url"))
+	}
+
+	events := ""
+	if w.Events != nil {
+		events = *w.Events
+	}
+	kinds, err := ParseKinds(events)
+	if err != nil {
+		return pkgErr("invalid events", err)
+	}
+	events = strings.Join(kinds, ",")
+	w.Events = &events
+
+	if w.Secret == nil || *w.Secret == "" {
+		secret, err := NewSecret()
+		if err != nil {
+			return err
+		}
+		w.Secret = &secret
+	}
+
+	return nil
+}
+
+// subscribed returns true if the comma-separated events include kind.
+func subscribed(events string, kind string) bool {
+	if kind == KindTest {
+		return true
+	}
+
+	for _, field := range strings.Split(events, ",") {
+		if strings.TrimSpace(field) == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewSecret returns a random secret for signing payloads.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", pkgErr("error reading random bytes", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature of the body sent at the timestamp, as set in the signature header.
+// The signature is the hex HMAC-SHA256 of the timestamp, a period and the body, keyed by the webhook's secret.
+// Receivers should compute the same and compare it in constant time.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}